@@ -1,15 +1,18 @@
 package cli
 
 import (
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"runtime/debug"
+	"strings"
 	"time"
 
 	"github.com/briandowns/spinner"
 	"github.com/joho/godotenv"
+	"github.com/sst/sst/v3/cmd/sst/mosaic/ui"
 	"github.com/sst/sst/v3/internal/util"
 	"github.com/sst/sst/v3/pkg/flag"
 	"github.com/sst/sst/v3/pkg/project"
@@ -28,11 +31,30 @@ var logFile = (func() *os.File {
 })()
 
 func (c *Cli) InitProject() (*project.Project, error) {
+	p, err := c.InitProjectLocal()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.LoadHome(); err != nil {
+		return nil, err
+	}
+
+	app := p.App()
+	slog.Info("loaded config", "app", app.Name, "stage", app.Stage)
+
+	c.configureLog()
+	return p, nil
+}
+
+// InitProjectLocal initializes the project without loading its home, for
+// commands that don't need the state of the app or access to the cloud.
+func (c *Cli) InitProjectLocal() (*project.Project, error) {
 	slog.Info("initializing project", "version", c.version)
 
-	cfgPath, err := project.Discover()
+	cfgPath, err := c.Discover()
 	if err != nil {
-		return nil, util.NewReadableError(err, "Could not find sst.config.ts")
+		return nil, util.NewReadableError(err, "Could not find a config file, one of "+strings.Join(project.ConfigNames, ", "))
 	}
 
 	stage, err := c.Stage(cfgPath)
@@ -44,6 +66,7 @@ func (c *Cli) InitProject() (*project.Project, error) {
 		Version: c.version,
 		Stage:   stage,
 		Config:  cfgPath,
+		NoCache: c.Bool("no-cache"),
 	})
 	if err != nil {
 		return nil, err
@@ -95,17 +118,25 @@ func (c *Cli) InitProject() (*project.Project, error) {
 		}
 	}
 
-	if err := p.LoadHome(); err != nil {
-		return nil, err
-	}
-
-	app := p.App()
-	slog.Info("loaded config", "app", app.Name, "stage", app.Stage)
-
-	c.configureLog()
 	return p, nil
 }
 
+// Discover finds the config file, using the --config flag if it is set.
+func (c *Cli) Discover() (string, error) {
+	input := c.String("config")
+	if input == "" {
+		return project.Discover()
+	}
+	cfgPath, err := project.DiscoverPath(input)
+	if err != nil {
+		return "", err
+	}
+	// so child processes resolve the same config
+	os.Setenv("SST_CONFIG", cfgPath)
+	c.env = append(c.env, "SST_CONFIG="+cfgPath)
+	return cfgPath, nil
+}
+
 func (c *Cli) configureLog() {
 	writers := []io.Writer{logFile}
 	if c.Bool("print-logs") || flag.SST_PRINT_LOGS {
@@ -119,3 +150,42 @@ func (c *Cli) configureLog() {
 	)
 	debug.SetCrashOutput(logFile, debug.CrashOptions{})
 }
+
+// Targets resolves the --target and --exclude flags into a list of URNs, along
+// with the dependents of the targets if the command affects them.
+func (c *Cli) Targets(p *project.Project, dependents bool) ([]string, error) {
+	include := splitList(c.String("target"))
+	exclude := splitList(c.String("exclude"))
+	if len(include) == 0 && len(exclude) > 0 {
+		fmt.Println(ui.TEXT_WARNING_BOLD.Render("Warning: ") + "Only resources that have been deployed before are targeted when using --exclude without --target. New resources will be skipped.")
+	}
+	return p.ResolveTargets(c.Context, include, exclude, dependents)
+}
+
+// TargetDependents reports whether the stack command should target the
+// dependents of the targets. With --exclude they are already resolved by
+// Targets, leaving out the excluded ones.
+func (c *Cli) TargetDependents() bool {
+	return !c.Bool("no-dependents") && c.String("exclude") == ""
+}
+
+// DebugFunctions returns the functions passed to --debug, or SST_DEBUG if the
+// flag is not set.
+func (c *Cli) DebugFunctions() []string {
+	input := c.String("debug")
+	if input == "" {
+		input = flag.SST_DEBUG
+	}
+	return splitList(input)
+}
+
+func splitList(input string) []string {
+	result := []string{}
+	for _, item := range strings.Split(input, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"strconv"
 	"strings"
 
 	"github.com/sst/sst/v3/cmd/sst/cli"
 	"github.com/sst/sst/v3/cmd/sst/mosaic/ui"
+	"github.com/sst/sst/v3/internal/util"
 	"github.com/sst/sst/v3/pkg/bus"
 	"github.com/sst/sst/v3/pkg/project"
 	"github.com/sst/sst/v3/pkg/server"
@@ -23,11 +25,30 @@ var CmdDeploy = &cli.Command{
 			"sst deploy --stage production",
 			"```",
 			"",
-			"Optionally, deploy specific resources by passing in a list of their URNs.",
-			"You can get the URN of a resource from the [Console](/docs/console/#resources).",
+			"Optionally, deploy specific resources by passing in a list of their names.",
 			"",
 			"```bash frame=\"none\"",
-			"sst deploy --target urn:pulumi:prod::www::sst:aws:Astro::Astro,urn:pulumi:prod::www::sst:aws:Bucket::Assets",
+			"sst deploy --target MyWeb,MyBucket",
+			"```",
+			"",
+			"You can also use a glob, like `Api*`, filter by type, like `type=sst:aws:Function`, or pass in",
+			"the URN of a resource. Any children of the matched components are included as well. And you",
+			"can exclude resources with `--exclude`, using the same selectors. With only `--exclude`,",
+			"everything that was deployed before is targeted, so new resources are skipped.",
+			"",
+			"```bash frame=\"none\"",
+			"sst deploy --exclude \"type=sst:aws:Nextjs\"",
+			"```",
+			"",
+			"The resources that depend on the targets are deployed as well, unless you pass in",
+			"`--no-dependents` or exclude them.",
+			"",
+			"If some resources fail with errors that are usually temporary, like throttling,",
+			"conflicting updates, or IAM roles that haven't propagated yet, the deploy is retried",
+			"for just those resources. By default, this is retried twice. Use `--retries` to change this.",
+			"",
+			"```bash frame=\"none\"",
+			"sst deploy --retries 0",
 			"```",
 			"",
 			"All the resources are deployed as concurrently as possible, based on their dependencies.",
@@ -61,14 +82,41 @@ var CmdDeploy = &cli.Command{
 			"```bash frame=\"none\"",
 			"SST_BUILD_CONCURRENCY_SITE=2 SST_BUILD_CONCURRENCY_CONTAINER=2 SST_BUILD_CONCURRENCY_FUNCTION=8 sst deploy",
 			"```",
+			"",
+			"Function builds are cached in `.sst/cache/functions`. A function is only rebuilt if the files",
+			"it's built from, its props, its links, its runtime, or your lockfile changed. The output shows",
+			"if each function was a cache hit or a miss.",
+			"",
+			"To persist the cache in CI, point it to a directory your CI restores between runs.",
+			"",
+			"```bash frame=\"none\"",
+			"SST_BUILD_CACHE_DIR=/tmp/sst-cache sst deploy",
+			"```",
+			"",
+			"Or set `SST_NO_BUILD_CACHE=1` to always rebuild your functions.",
 		}, "\n"),
 	},
 	Flags: []cli.Flag{
 		{
 			Name: "target",
 			Description: cli.Description{
-				Short: "Comma separated list of target URNs",
-				Long:  "Comma separated list of target URNs.",
+				Short: "Comma separated list of resources to target",
+				Long:  "Comma separated list of resources to target. Takes names, globs, `type=<type>`, or URNs.",
+			},
+		},
+		{
+			Name: "exclude",
+			Description: cli.Description{
+				Short: "Comma separated list of resources to exclude",
+				Long:  "Comma separated list of resources to exclude. Takes the same selectors as `--target`.",
+			},
+		},
+		{
+			Name: "no-dependents",
+			Type: "bool",
+			Description: cli.Description{
+				Short: "Don't include resources that depend on the targets",
+				Long:  "Don't include the resources that depend on the targets.",
 			},
 		},
 		{
@@ -79,6 +127,13 @@ var CmdDeploy = &cli.Command{
 				Long:  "Continue on error.",
 			},
 		},
+		{
+			Name: "retries",
+			Description: cli.Description{
+				Short: "Number of times to retry transient failures",
+				Long:  "Number of times to retry resources that fail with transient errors. Defaults to `2`. Set to `0` to disable retries.",
+			},
+		},
 		{
 			Name: "dev",
 			Type: "bool",
@@ -103,9 +158,17 @@ var CmdDeploy = &cli.Command{
 		}
 		defer p.Cleanup()
 
-		target := []string{}
-		if c.String("target") != "" {
-			target = strings.Split(c.String("target"), ",")
+		target, err := c.Targets(p, !c.Bool("no-dependents"))
+		if err != nil {
+			return err
+		}
+
+		retry := project.DefaultRetryPolicy
+		if c.String("retries") != "" {
+			retry.Attempts, err = strconv.Atoi(c.String("retries"))
+			if err != nil || retry.Attempts < 0 {
+				return util.NewReadableError(err, "The --retries flag must be a number greater than or equal to 0")
+			}
 		}
 
 		var wg errgroup.Group
@@ -132,12 +195,14 @@ var CmdDeploy = &cli.Command{
 		defer ui.Destroy()
 		defer c.Cancel()
 		err = p.Run(c.Context, &project.StackInput{
-			Command:    "deploy",
-			Target:     target,
-			Dev:        c.Bool("dev"),
-			ServerPort: s.Port,
-			Verbose:    c.Bool("verbose"),
-			Continue:   c.Bool("continue"),
+			Command:          "deploy",
+			Target:           target,
+			TargetDependents: c.TargetDependents(),
+			Dev:              c.Bool("dev"),
+			ServerURL:        s.URL(),
+			Verbose:          c.Bool("verbose"),
+			Continue:         c.Bool("continue"),
+			Retry:            &retry,
 		})
 		if err != nil {
 			return err
@@ -23,9 +23,9 @@ func CmdDiff(c *cli.Cli) error {
 	}
 	defer p.Cleanup()
 
-	target := []string{}
-	if c.String("target") != "" {
-		target = strings.Split(c.String("target"), ",")
+	target, err := c.Targets(p, false)
+	if err != nil {
+		return err
 	}
 
 	var wg errgroup.Group
@@ -56,11 +56,11 @@ func CmdDiff(c *cli.Cli) error {
 	defer u.Destroy()
 	defer c.Cancel()
 	err = p.Run(c.Context, &project.StackInput{
-		Command:    "diff",
-		ServerPort: s.Port,
-		Dev:        c.Bool("dev"),
-		Target:     target,
-		Verbose:    c.Bool("verbose"),
+		Command:   "diff",
+		ServerURL: s.URL(),
+		Dev:       c.Bool("dev"),
+		Target:    target,
+		Verbose:   c.Bool("verbose"),
 	})
 	if err != nil {
 		return err
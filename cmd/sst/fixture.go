@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/sst/sst/v3/cmd/sst/cli"
+	"github.com/sst/sst/v3/cmd/sst/mosaic/aws"
+	"github.com/sst/sst/v3/cmd/sst/mosaic/aws/fixture"
+	"github.com/sst/sst/v3/cmd/sst/mosaic/dev"
+	"github.com/sst/sst/v3/cmd/sst/mosaic/ui"
+	"github.com/sst/sst/v3/internal/util"
+	"github.com/sst/sst/v3/pkg/server"
+)
+
+var CmdFixture = &cli.Command{
+	Name: "fixture",
+	Description: cli.Description{
+		Short: "Manage events to invoke functions with",
+		Long: strings.Join([]string{
+			"Manage the events you can invoke your functions with in `sst invoke`.",
+			"",
+			"There are built-in events for the common Lambda triggers. You can also capture real",
+			"events while running `sst dev` and save them as fixtures in `.sst/fixtures`.",
+		}, "\n"),
+	},
+	Children: []*cli.Command{
+		CmdFixtureList,
+		CmdFixtureShow,
+		CmdFixtureCapture,
+	},
+}
+
+var CmdFixtureList = &cli.Command{
+	Name: "list",
+	Description: cli.Description{
+		Short: "List the built-in events and saved fixtures",
+		Long:  "Lists the built-in events with the params they take and their defaults, along with the fixtures you've saved.",
+	},
+	Run: func(c *cli.Cli) error {
+		p, err := c.InitProjectLocal()
+		if err != nil {
+			return err
+		}
+		defer p.Cleanup()
+
+		color.White("# built-in")
+		for _, builtin := range fixture.Builtins() {
+			fmt.Println(builtin.Name + " " + ui.TEXT_DIM.Render(builtin.Description))
+			for _, param := range builtin.ParamNames() {
+				fmt.Println("  " + param + "=" + builtin.Params[param])
+			}
+		}
+		saved, err := fixture.List(fixture.Dir(p.PathConfig()))
+		if err != nil {
+			return err
+		}
+		if len(saved) > 0 {
+			fmt.Println()
+			color.White("# saved")
+			for _, name := range saved {
+				fmt.Println(name)
+			}
+		}
+		return nil
+	},
+}
+
+var CmdFixtureShow = &cli.Command{
+	Name: "show",
+	Description: cli.Description{
+		Short: "Print an event",
+		Long: strings.Join([]string{
+			"Prints a built-in event filled in with the given params, or a saved fixture.",
+			"",
+			"```bash frame=\"none\"",
+			"sst fixture show sqs --params \"body={\\\"id\\\":1}\"",
+			"```",
+			"",
+			"This is useful to start a fixture of your own, or to check what your function",
+			"will be invoked with.",
+		}, "\n"),
+	},
+	Args: []cli.Argument{
+		{
+			Name:     "name",
+			Required: true,
+			Description: cli.Description{
+				Short: "The name of the event",
+				Long:  "The name of a built-in event or a saved fixture.",
+			},
+		},
+	},
+	Flags: []cli.Flag{
+		{
+			Name: "params",
+			Type: "string",
+			Description: cli.Description{
+				Short: "Params for the built-in event",
+				Long:  "Params to fill in the built-in event, as a query string. For example, `bucket=uploads&key=photo.jpg`.",
+			},
+		},
+	},
+	Run: func(c *cli.Cli) error {
+		p, err := c.InitProjectLocal()
+		if err != nil {
+			return err
+		}
+		defer p.Cleanup()
+		params, err := fixture.ParseParams(c.String("params"))
+		if err != nil {
+			return err
+		}
+		event, err := fixture.Resolve(fixture.Dir(p.PathConfig()), c.Positional(0), params)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(event))
+		return nil
+	},
+}
+
+var CmdFixtureCapture = &cli.Command{
+	Name: "capture",
+	Description: cli.Description{
+		Short: "Save the next event from sst dev",
+		Long: strings.Join([]string{
+			"Waits for the next invocation in a running `sst dev` session and saves its event as",
+			"a fixture in `.sst/fixtures`.",
+			"",
+			"```bash frame=\"none\"",
+			"sst fixture capture new-order MyFunction",
+			"```",
+			"",
+			"Pass in the name of a function to only capture its invocations. Then invoke the",
+			"function with the saved event.",
+			"",
+			"```bash frame=\"none\"",
+			"sst invoke MyFunction --event new-order",
+			"```",
+		}, "\n"),
+	},
+	Args: []cli.Argument{
+		{
+			Name:     "name",
+			Required: true,
+			Description: cli.Description{
+				Short: "The name of the fixture",
+				Long:  "The name to save the fixture as. A fixture with the same name is replaced.",
+			},
+		},
+		{
+			Name: "function",
+			Description: cli.Description{
+				Short: "The name of the function",
+				Long:  "The name of the function component to capture the event of.",
+			},
+		},
+	},
+	Run: func(c *cli.Cli) error {
+		name := c.Positional(0)
+		functionID := c.Positional(1)
+		p, err := c.InitProjectLocal()
+		if err != nil {
+			return err
+		}
+		defer p.Cleanup()
+		url, err := server.Discover(p.PathConfig(), p.App().Stage)
+		if err != nil {
+			return err
+		}
+		evts, err := dev.Stream(c.Context, url, aws.FunctionInvokedEvent{})
+		if err != nil {
+			return err
+		}
+		fmt.Println(ui.TEXT_DIM.Render("Waiting for an invocation..."))
+		for {
+			select {
+			case <-c.Context.Done():
+				return nil
+			case unknown, ok := <-evts:
+				if !ok {
+					return util.NewReadableError(nil, "The sst dev session stopped before an invocation was captured")
+				}
+				evt := unknown.(*aws.FunctionInvokedEvent)
+				if functionID != "" && evt.FunctionID != functionID {
+					continue
+				}
+				err := fixture.Save(fixture.Dir(p.PathConfig()), name, evt.Input)
+				if err != nil {
+					return err
+				}
+				ui.Success(fmt.Sprintf("Saved the event of %s as %s", evt.FunctionID, name))
+				return nil
+			}
+		}
+	},
+}
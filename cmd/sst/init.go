@@ -21,10 +21,12 @@ import (
 )
 
 func CmdInit(cli *cli.Cli) error {
-	if _, err := os.Stat("sst.config.ts"); err == nil {
-		color.New(color.FgRed, color.Bold).Print("×")
-		color.New(color.FgWhite, color.Bold).Println("  SST project already exists")
-		return nil
+	for _, name := range project.ConfigNames {
+		if _, err := os.Stat(name); err == nil {
+			color.New(color.FgRed, color.Bold).Print("×")
+			color.New(color.FgWhite, color.Bold).Println("  SST project already exists")
+			return nil
+		}
 	}
 
 	logo := []string{
@@ -197,7 +199,7 @@ func CmdInit(cli *cli.Cli) error {
 	spin.Suffix = "  Installing providers..."
 	spin.Start()
 
-	cfgPath, err := project.Discover()
+	cfgPath, err := cli.Discover()
 	if err != nil {
 		return err
 	}
@@ -234,14 +236,13 @@ func CmdInit(cli *cli.Cli) error {
 		})
 
 		if hasEslint {
-			configFile := "sst.config.ts"
-			content, err := os.ReadFile(configFile)
+			content, err := os.ReadFile(cfgPath)
 			if err != nil {
 				return err
 			}
 
 			newContent := "// eslint-disable-next-line @typescript-eslint/triple-slash-reference\n" + string(content)
-			err = os.WriteFile(configFile, []byte(newContent), 0644)
+			err = os.WriteFile(cfgPath, []byte(newContent), 0644)
 			if err != nil {
 				return err
 			}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sst/sst/v3/cmd/sst/cli"
+	"github.com/sst/sst/v3/cmd/sst/mosaic/aws"
+	"github.com/sst/sst/v3/cmd/sst/mosaic/aws/fixture"
+	"github.com/sst/sst/v3/cmd/sst/mosaic/ui"
+	"github.com/sst/sst/v3/internal/util"
+	"github.com/sst/sst/v3/pkg/bus"
+)
+
+var CmdInvoke = &cli.Command{
+	Name: "invoke",
+	Description: cli.Description{
+		Short: "Invoke a function locally",
+		Long: strings.Join([]string{
+			"Builds a function and runs it once on your machine with the given payload.",
+			"",
+			"```bash frame=\"none\"",
+			"sst invoke MyFunction --payload event.json",
+			"```",
+			"",
+			"The function is run the same way as in `sst dev`, through a local version of the",
+			"Lambda runtime API. It prints its logs, and the response or the error.",
+			"",
+			"This doesn't need to connect to AWS. It uses the handler, props, and links of the",
+			"function from the last time it was deployed or run in `sst dev`. The linked resources",
+			"are passed in as environment variables, and the function has the same credentials",
+			"as your shell.",
+			"",
+			"The response is printed to stdout, so you can use it in scripts or smoke tests.",
+			"",
+			"```bash frame=\"none\"",
+			"sst invoke MyFunction --payload - < event.json | jq .statusCode",
+			"```",
+			"",
+			"Instead of a payload, you can use one of the built-in events with `--event`, and fill",
+			"it in with `--params`.",
+			"",
+			"```bash frame=\"none\"",
+			"sst invoke MyFunction --event s3 --params \"bucket=uploads&key=photo.jpg\"",
+			"```",
+			"",
+			"The built-in events are `api-v1`, `api-v2`, `alb`, `sqs`, `sns`, `s3`, `eventbridge`,",
+			"`dynamodb`, `kinesis`, and `cognito`. Use `sst fixture list` to see the params they",
+			"take. You can also pass in the name of a fixture you've saved with `sst fixture capture`.",
+		}, "\n"),
+	},
+	Args: []cli.Argument{
+		{
+			Name:     "function",
+			Required: true,
+			Description: cli.Description{
+				Short: "The name of the function",
+				Long:  "The name of the function component to invoke.",
+			},
+		},
+	},
+	Flags: []cli.Flag{
+		{
+			Name: "payload",
+			Type: "string",
+			Description: cli.Description{
+				Short: "Path to the JSON payload",
+				Long:  "Path to a JSON file with the event to invoke the function with. Use `-` to read it from stdin. Defaults to `{}`.",
+			},
+		},
+		{
+			Name: "event",
+			Type: "string",
+			Description: cli.Description{
+				Short: "A built-in event or a saved fixture",
+				Long:  "The name of a built-in event, like `api-v2` or `sqs`, or a fixture saved with `sst fixture capture`. Run `sst fixture list` to see them.",
+			},
+		},
+		{
+			Name: "params",
+			Type: "string",
+			Description: cli.Description{
+				Short: "Params for the built-in event",
+				Long:  "Params to fill in the built-in event passed in with `--event`, as a query string. For example, `method=POST&path=/users`.",
+			},
+		},
+	},
+	Examples: []cli.Example{
+		{
+			Content: "sst invoke MyFunction --payload event.json",
+			Description: cli.Description{
+				Short: "Invoke MyFunction with the event in event.json",
+			},
+		},
+		{
+			Content: "sst invoke MyFunction --event api-v2 --params \"method=POST&path=/users\"",
+			Description: cli.Description{
+				Short: "Invoke MyFunction with an API Gateway request",
+			},
+		},
+	},
+	Run: func(c *cli.Cli) error {
+		functionID := c.Positional(0)
+		p, err := c.InitProjectLocal()
+		if err != nil {
+			return err
+		}
+		defer p.Cleanup()
+
+		payload := []byte("{}")
+		if c.String("payload") != "" && c.String("event") != "" {
+			return util.NewReadableError(nil, "Use either --payload or --event")
+		}
+		switch path := c.String("payload"); path {
+		case "":
+		case "-":
+			payload, err = io.ReadAll(os.Stdin)
+		default:
+			payload, err = os.ReadFile(path)
+		}
+		if err != nil {
+			return util.NewReadableError(err, "Could not read the payload: "+err.Error())
+		}
+		if name := c.String("event"); name != "" {
+			params, err := fixture.ParseParams(c.String("params"))
+			if err != nil {
+				return err
+			}
+			payload, err = fixture.Resolve(fixture.Dir(p.PathConfig()), name, params)
+			if err != nil {
+				return err
+			}
+		}
+
+		// logs go to stderr so the response can be piped
+		u := ui.New(c.Context, ui.WithStderr)
+		defer u.Destroy()
+		flush := printFunctionEvents(u)
+
+		result, err := aws.Invoke(c.Context, p, functionID, payload)
+		// print what was published before the invoke returned
+		flush()
+		if err != nil {
+			return err
+		}
+		if result.Error != nil {
+			return util.NewReadableError(nil, fmt.Sprintf("%s failed: %s", functionID, result.Error.ErrorMessage))
+		}
+		fmt.Println(string(result.Output))
+		return nil
+	},
+}
+
+// printFunctionEvents shows the build, logs and result of local invocations.
+// The returned function waits until the events published so far are printed.
+func printFunctionEvents(u *ui.UI) func() {
+	events := bus.Subscribe(
+		&aws.FunctionBuildEvent{},
+		&aws.FunctionInvokedEvent{},
+		&aws.FunctionLogEvent{},
+		&aws.FunctionResponseEvent{},
+		&aws.FunctionErrorEvent{},
+	)
+	flush := make(chan chan struct{})
+	go func() {
+		for {
+			select {
+			case evt := <-events:
+				u.Event(evt)
+			case done := <-flush:
+				drain(events, u)
+				close(done)
+			}
+		}
+	}()
+	return func() {
+		done := make(chan struct{})
+		flush <- done
+		<-done
+	}
+}
+
+func drain(events <-chan interface{}, u *ui.UI) {
+	for {
+		select {
+		case evt := <-events:
+			u.Event(evt)
+		default:
+			return
+		}
+	}
+}
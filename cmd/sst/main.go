@@ -281,6 +281,56 @@ var root = &cli.Command{
 				}, "\n"),
 			},
 		},
+		{
+			Name: "config",
+			Type: "string",
+			Description: cli.Description{
+				Short: "Path to the config file",
+				Long: strings.Join([]string{
+					"",
+					"By default, the CLI looks for a `sst.config.ts`, `sst.config.mts`, `sst.config.js`,",
+					"or `sst.config.mjs` in the current directory and its parents.",
+					"",
+					"Use this flag to point to a specific config. This is useful in monorepos that have",
+					"more than one config.",
+					"",
+					"```bash",
+					"sst [command] --config infra/sst.config.ts",
+					"```",
+					"",
+					"It can also be set using the `SST_CONFIG` environment variable.",
+					"",
+					"The config is evaluated with Node by default, or with Bun if Node is not installed.",
+					"Set the `SST_CONFIG_RUNTIME` environment variable to `node`, `bun`, or `deno` to pick one.",
+					"",
+					"```bash",
+					"SST_CONFIG_RUNTIME=bun sst [command]",
+					"```",
+					"",
+				}, "\n"),
+			},
+		},
+		{
+			Name: "no-cache",
+			Type: "bool",
+			Description: cli.Description{
+				Short: "Skip the cached config",
+				Long: strings.Join([]string{
+					"",
+					"The CLI caches the result of evaluating the `app` function in your `sst.config.ts`",
+					"in the `.sst/` directory. The cache is invalidated when the config or any file it",
+					"imports changes, or when the stage, the SST version, or an environment variable",
+					"the config reads changes.",
+					"",
+					"Use this flag to ignore the cache and evaluate the config again.",
+					"",
+					"```bash",
+					"sst [command] --no-cache",
+					"```",
+					"",
+				}, "\n"),
+			},
+		},
 		{
 			Name: "help",
 			Type: "bool",
@@ -414,6 +464,62 @@ var root = &cli.Command{
 					"```bash frame=\"none\"",
 					"sst dev -- next dev --turbo",
 					"```",
+					"",
+					"The watcher skips anything in your `.gitignore` files, along with `node_modules`",
+					"and directories that start with a `.`. To skip other paths, list them in a",
+					"`.sstignore` file next to your `sst.config.ts`. It uses the same syntax as",
+					"`.gitignore`.",
+					"",
+					"The processes of a `DevCommand` can be restarted when they exit with `restart`, and",
+					"started only once the ones they need are ready with `dependsOn`. Set `ready` to",
+					"a port, URL, or log line to say when a process is ready. The status of each one",
+					"is shown in the sidebar.",
+					"",
+					"Changes that come in while your app is deploying are deployed together once it's",
+					"done. To cancel the deploy in progress instead, set `SST_DEV_SUPERSEDE`.",
+					"",
+					"```bash frame=\"none\"",
+					"SST_DEV_SUPERSEDE=1 sst dev",
+					"```",
+					"",
+					"To debug your functions, pass in the ones you want to attach a debugger to.",
+					"",
+					"```bash frame=\"none\"",
+					"sst dev --debug MyFunction,Api*",
+					"```",
+					"",
+					"Node functions are started with `--inspect`, Go functions are built without",
+					"optimizations and run with `dlv --headless`, and Python functions are run with",
+					"`debugpy`. Each worker gets its own port, starting at `9229`, `2345`, and `5678`",
+					"respectively, and it's shown in the **Functions** tab. A worker keeps its port",
+					"when your function is rebuilt, so a debugger set to reconnect keeps your",
+					"breakpoints.",
+					"",
+					"The processes started by `sst dev` talk to it through a local server. It only",
+					"listens on `127.0.0.1` and every request needs a token that's generated for the",
+					"session. It's passed to the processes in `SST_SERVER`, and written to",
+					"`.sst/<stage>.server.token` for other commands. To reach it from another machine,",
+					"set `SST_SERVER_HOST` to the address to listen on, like `0.0.0.0`. It keeps",
+					"listening on `127.0.0.1` as well.",
+					"",
+					"The server also has a read only API for tools and scripts that need the state of",
+					"your app while `sst dev` is running. It's under `/api/v1` and returns JSON.",
+					"",
+					"- `/resources` takes `type` and `name` to filter by",
+					"- `/outputs` returns the outputs of your app",
+					"- `/functions` takes a `status` of `pending`, `built`, or `failed`",
+					"- `/invocations` takes a `function` and `errors=true` for only the failed ones",
+					"- `/processes` takes a `status` of `stopped`, `waiting`, `starting`, `running`,",
+					"  `unhealthy`, `restarting`, or `exited`",
+					"- `/logs` lists the log files, and `/logs/<name>?lines=100` returns the end of one",
+					"",
+					"Lists are returned as `{ \"data\": [...], \"next\": \"...\" }`. Pass `limit`, up to",
+					"`500`, and the `next` value as the `cursor` to page through them.",
+					"",
+					"```bash frame=\"none\"",
+					"curl \"$(cat .sst/<stage>.server)/api/v1/invocations?errors=true\" \\",
+					"  -H \"Authorization: Bearer $(cat .sst/<stage>.server.token)\"",
+					"```",
 				}, "\n"),
 			},
 			Flags: []cli.Flag{
@@ -425,6 +531,17 @@ var root = &cli.Command{
 						Long:  "Defaults to using the multiplexer or `mosaic` mode. Use `basic` to turn it off.",
 					},
 				},
+				{
+					Name: "debug",
+					Type: "string",
+					Description: cli.Description{
+						Short: "Attach a debugger to these functions",
+						Long: strings.Join([]string{
+							"Run the given functions with a debugger attached. Takes a comma separated list of",
+							"function names, globs of them, or `*` for all of them. Can also be set with `SST_DEBUG`.",
+						}, "\n"),
+					},
+				},
 			},
 			Args: []cli.Argument{
 				{
@@ -457,6 +574,9 @@ var root = &cli.Command{
 			Run: CmdMosaic,
 		},
 		CmdDeploy,
+		CmdInvoke,
+		CmdFixture,
+		CmdReplay,
 		{
 			Name: "diff",
 			Description: cli.Description{
@@ -474,10 +594,11 @@ var root = &cli.Command{
 					"This is useful for cases when you pull some changes from a teammate and want to",
 					"see what will be deployed; before doing the actual deploy.",
 					"",
-					"Optionally, you can diff a specific set of resources by passing in a list of their URNs.",
+					"Optionally, you can diff a specific set of resources by passing in a list of their names,",
+					"globs, `type=<type>` selectors, or URNs. Or use `--exclude` to leave some out.",
 					"",
 					"```bash frame=\"none\"",
-					"sst diff --target urn:pulumi:prod::www::sst:aws:Astro::Astro,urn:pulumi:prod::www::sst:aws:Bucket::Assets",
+					"sst diff --target MyWeb,Api*",
 					"```",
 					"",
 					"By default, this compares to the last deploy of the given stage as it would be",
@@ -495,8 +616,15 @@ var root = &cli.Command{
 				{
 					Name: "target",
 					Description: cli.Description{
-						Short: "Comma separated list of target URNs",
-						Long:  "Comma separated list of target URNs.",
+						Short: "Comma separated list of resources to target",
+						Long:  "Comma separated list of resources to target. Takes names, globs, `type=<type>`, or URNs.",
+					},
+				},
+				{
+					Name: "exclude",
+					Description: cli.Description{
+						Short: "Comma separated list of resources to exclude",
+						Long:  "Comma separated list of resources to exclude. Takes the same selectors as `--target`.",
 					},
 				},
 				{
@@ -588,7 +716,7 @@ var root = &cli.Command{
 				spin.Suffix = "  Adding provider..."
 				spin.Start()
 				defer spin.Stop()
-				cfgPath, err := project.Discover()
+				cfgPath, err := cli.Discover()
 				if err != nil {
 					return err
 				}
@@ -600,6 +728,7 @@ var root = &cli.Command{
 					Version: version,
 					Config:  cfgPath,
 					Stage:   stage,
+					NoCache: cli.Bool("no-cache"),
 				})
 				if err != nil {
 					return err
@@ -623,6 +752,7 @@ var root = &cli.Command{
 					Version: version,
 					Config:  cfgPath,
 					Stage:   stage,
+					NoCache: cli.Bool("no-cache"),
 				})
 				if err != nil {
 					return err
@@ -653,10 +783,36 @@ var root = &cli.Command{
 					"Behind the scenes, it installs the packages for your providers and adds the providers to your globals.",
 					"",
 					"If you don't have a version specified for your providers in your `sst.config.ts`, it'll install their latest versions.",
+					"",
+					"To install on a machine without internet access, create a bundle on a connected machine.",
+					"",
+					"```bash frame=\"none\"",
+					"sst install --bundle sst-bundle.tar",
+					"```",
+					"",
+					"This contains the providers, their Pulumi plugins, and the Pulumi and Bun binaries for the current OS",
+					"and architecture. Then point the `SST_OFFLINE` environment variable to the bundle, or to a directory",
+					"it was extracted to, on the other machine.",
+					"",
+					"```bash frame=\"none\"",
+					"SST_OFFLINE=./sst-bundle.tar sst install",
+					"```",
+					"",
+					"Offline installs check the installed providers against the integrity hashes recorded in the bundle.",
 				}, "\n"),
 			},
+			Flags: []cli.Flag{
+				{
+					Name: "bundle",
+					Type: "string",
+					Description: cli.Description{
+						Short: "Write an offline bundle to the given path",
+						Long:  "Write a bundle with everything needed to install offline to the given path.",
+					},
+				},
+			},
 			Run: func(cli *cli.Cli) error {
-				cfgPath, err := project.Discover()
+				cfgPath, err := cli.Discover()
 				if err != nil {
 					return err
 				}
@@ -670,6 +826,7 @@ var root = &cli.Command{
 					Version: version,
 					Config:  cfgPath,
 					Stage:   stage,
+					NoCache: cli.Bool("no-cache"),
 				})
 				if err != nil {
 					return err
@@ -690,6 +847,16 @@ var root = &cli.Command{
 				if err != nil {
 					return err
 				}
+				if bundle := cli.String("bundle"); bundle != "" {
+					spin.Suffix = "  Creating bundle..."
+					err = p.Bundle(cli.Context, bundle)
+					if err != nil {
+						return err
+					}
+					spin.Stop()
+					ui.Success("Created offline bundle " + bundle)
+					return nil
+				}
 				spin.Stop()
 				ui.Success("Installed providers")
 				return nil
@@ -818,12 +985,15 @@ var root = &cli.Command{
 					"```bash frame=\"none\" frame=\"none\"",
 					"sst remove --stage production",
 					"```",
-					"Optionally, remove specific resources by passing in a list of their URNs.",
-					"You can get the URN of a resource from the [Console](/docs/console/#resources).",
+					"Optionally, remove specific resources by passing in a list of their names,",
+					"globs, `type=<type>` selectors, or URNs. Or use `--exclude` to leave some out.",
 					"",
 					"```bash frame=\"none\"",
-					"sst remove --target urn:pulumi:prod::www::sst:aws:Astro::Astro,urn:pulumi:prod::www::sst:aws:Bucket::Assets",
+					"sst remove --target MyWeb,MyBucket",
 					"```",
+					"",
+					"The resources that depend on the targets are removed as well, unless you pass in",
+					"`--no-dependents` or exclude them.",
 				}, "\n"),
 			},
 			Flags: []cli.Flag{
@@ -831,8 +1001,24 @@ var root = &cli.Command{
 					Name: "target",
 					Type: "string",
 					Description: cli.Description{
-						Short: "Comma separated list of target URNs",
-						Long:  "Comma separated list of target URNs.",
+						Short: "Comma separated list of resources to target",
+						Long:  "Comma separated list of resources to target. Takes names, globs, `type=<type>`, or URNs.",
+					},
+				},
+				{
+					Name: "exclude",
+					Type: "string",
+					Description: cli.Description{
+						Short: "Comma separated list of resources to exclude",
+						Long:  "Comma separated list of resources to exclude. Takes the same selectors as `--target`.",
+					},
+				},
+				{
+					Name: "no-dependents",
+					Type: "bool",
+					Description: cli.Description{
+						Short: "Don't include resources that depend on the targets",
+						Long:  "Don't include the resources that depend on the targets.",
 					},
 				},
 			},
@@ -981,11 +1167,11 @@ var root = &cli.Command{
 					":::note",
 					"The `sst refresh` does not make changes to the resources in the cloud provider.",
 					":::",
-					"Optionally, refresh specific resources by passing in a list of their URNs.",
-					"You can get the URN of a resource from the [Console](/docs/console/#resources).",
+					"Optionally, refresh specific resources by passing in a list of their names,",
+					"globs, `type=<type>` selectors, or URNs. Or use `--exclude` to leave some out.",
 					"",
 					"```bash frame=\"none\"",
-					"sst refresh --target urn:pulumi:prod::www::sst:aws:Astro::Astro,urn:pulumi:prod::www::sst:aws:Bucket::Assets",
+					"sst refresh --target \"type=sst:aws:Function\"",
 					"```",
 					"",
 					"This is useful for cases where you want to ensure that your local state is in sync with your cloud provider. [Learn more about how state works](/docs/providers/#how-state-works).",
@@ -996,8 +1182,16 @@ var root = &cli.Command{
 					Name: "target",
 					Type: "string",
 					Description: cli.Description{
-						Short: "Comma separated list of target URNs",
-						Long:  "Comma separated list of target URNs.",
+						Short: "Comma separated list of resources to target",
+						Long:  "Comma separated list of resources to target. Takes names, globs, `type=<type>`, or URNs.",
+					},
+				},
+				{
+					Name: "exclude",
+					Type: "string",
+					Description: cli.Description{
+						Short: "Comma separated list of resources to exclude",
+						Long:  "Comma separated list of resources to exclude. Takes the same selectors as `--target`.",
 					},
 				},
 			},
@@ -1006,6 +1200,7 @@ var root = &cli.Command{
 		CmdState,
 		CmdCert,
 		CmdTunnel,
+		CmdRelay,
 		CmdDiagnostic,
 	},
 }
@@ -18,6 +18,7 @@ import (
 	"github.com/sst/sst/v3/cmd/sst/mosaic/monoplexer"
 	"github.com/sst/sst/v3/cmd/sst/mosaic/multiplexer"
 	"github.com/sst/sst/v3/cmd/sst/mosaic/socket"
+	"github.com/sst/sst/v3/cmd/sst/mosaic/supervisor"
 	"github.com/sst/sst/v3/cmd/sst/mosaic/watcher"
 	"github.com/sst/sst/v3/internal/util"
 	"github.com/sst/sst/v3/pkg/bus"
@@ -39,7 +40,7 @@ func CmdMosaic(c *cli.Cli) error {
 			args = append(args, strings.Fields(arg)...)
 		}
 		slog.Info("dev mode with target", "args", c.Arguments())
-		cfgPath, err := project.Discover()
+		cfgPath, err := c.Discover()
 		stage, err := c.Stage(cfgPath)
 		if err != nil {
 			return err
@@ -135,6 +136,7 @@ func CmdMosaic(c *cli.Cli) error {
 	}
 	os.Setenv("SST_STAGE", p.App().Stage)
 	slog.Info("mosaic", "project", p.PathRoot())
+	p.Runtime.SetDebug(c.DebugFunctions())
 
 	wg.Go(func() error {
 		defer c.Cancel()
@@ -171,7 +173,7 @@ func CmdMosaic(c *cli.Cli) error {
 		}
 	})
 
-	os.Setenv("SST_SERVER", fmt.Sprintf("http://localhost:%v", server.Port))
+	os.Setenv("SST_SERVER", server.URL())
 	for name, a := range p.App().Providers {
 		args := a
 		switch name {
@@ -208,7 +210,7 @@ func CmdMosaic(c *cli.Cli) error {
 		}
 		multiEnv := append(
 			c.Env(),
-			fmt.Sprintf("SST_SERVER=http://localhost:%v", server.Port),
+			"SST_SERVER="+server.URL(),
 			"SST_STAGE="+p.App().Stage,
 		)
 		multi.AddProcess("deploy", []string{currentExecutable, "ui", "--filter=sst"}, "⑆", "SST", "", false, true, append(multiEnv, "SST_LOG="+p.PathLog("ui-deploy"))...)
@@ -229,24 +231,18 @@ func CmdMosaic(c *cli.Cli) error {
 				case unknown := <-evts:
 					switch evt := unknown.(type) {
 					case *project.CompleteEvent:
-						for _, d := range evt.Devs {
-							if d.Command == "" {
-								continue
-							}
+						for _, d := range devCommands(evt.Devs) {
 							dir := filepath.Join(cwd, d.Directory)
 							words, _ := shellquote.Split(d.Command)
 							title := d.Title
 							if title == "" {
 								title = d.Name
 							}
-							multi.AddProcess(
-								d.Name,
+							multi.AddDev(
+								d,
 								append([]string{currentExecutable, "dev", "--"}, words...),
-								"→",
 								title,
 								dir,
-								true,
-								d.Autostart,
 								append([]string{"SST_CHILD=" + d.Name}, multiEnv...)...,
 							)
 						}
@@ -272,7 +268,7 @@ func CmdMosaic(c *cli.Cli) error {
 	}
 
 	if mode == "mono" {
-		mono := monoplexer.New()
+		mono := monoplexer.New(c.Context)
 		mono.AddProcess("deploy", []string{currentExecutable, "ui", "--filter=sst"}, "", "SST")
 		mono.AddProcess("function", []string{currentExecutable, "ui", "--filter=function"}, "", "Function")
 
@@ -291,18 +287,17 @@ func CmdMosaic(c *cli.Cli) error {
 				case unknown := <-evts:
 					switch evt := unknown.(type) {
 					case *project.CompleteEvent:
-						for _, d := range evt.Devs {
-							if d.Command == "" {
-								continue
-							}
+						for _, d := range devCommands(evt.Devs) {
 							dir := filepath.Join(cwd, d.Directory)
 							words, _ := shellquote.Split(d.Command)
 							title := d.Title
 							if title == "" {
 								title = d.Name
 							}
-							mono.AddProcess(
-								d.Name,
+							// there's no way to start a process by hand here
+							d.Autostart = true
+							mono.AddDev(
+								d,
 								append([]string{currentExecutable, "dev", "--"}, words...),
 								dir,
 								title,
@@ -344,3 +339,16 @@ func diff(a map[string]string, b map[string]string) bool {
 	}
 	return false
 }
+
+// devCommands returns the dev processes that have a command to run, with the
+// dependencies that can't be met dropped.
+func devCommands(devs project.Devs) project.Devs {
+	result := project.Devs{}
+	for name, d := range devs {
+		if d.Command == "" {
+			continue
+		}
+		result[name] = d
+	}
+	return supervisor.Resolve(result)
+}
@@ -9,6 +9,8 @@ import (
 
 	"github.com/sst/sst/v3/cmd/sst/mosaic/aws/appsync"
 	"github.com/sst/sst/v3/cmd/sst/mosaic/aws/bridge"
+	"github.com/sst/sst/v3/cmd/sst/mosaic/aws/relay"
+	"github.com/sst/sst/v3/pkg/flag"
 	"github.com/sst/sst/v3/pkg/project"
 	"github.com/sst/sst/v3/pkg/project/provider"
 	"github.com/sst/sst/v3/pkg/server"
@@ -29,31 +31,15 @@ func Start(
 	slog.Info("getting endpoint")
 	prefix := fmt.Sprintf("/sst/%s/%s", p.App().Name, p.App().Stage)
 
-	rest, realtime, err := prov.ResolveAppSync(ctx)
+	conn, err := dial(ctx, prov)
 	if err != nil {
 		return err
 	}
-	slog.Info("found appsync", "rest", rest, "realtime", realtime)
-
-	now := time.Now()
-	for {
-		slog.Info("checking if appsync is ready")
-		_, err := http.Get("https://" + rest)
-		if err != nil {
-			slog.Error("appsync not ready", "err", err)
-			if time.Since(now) > time.Second*10 {
-				return ErrAppsyncNotReady
-			}
-			time.Sleep(time.Second)
-			continue
-		}
-		break
-	}
-	conn, err := appsync.Dial(ctx, config, rest, realtime)
+	client, err := bridge.NewClient(ctx, conn, "dev", prefix)
 	if err != nil {
 		return err
 	}
-	client := bridge.NewClient(ctx, conn, "dev", prefix)
+	go heartbeat(ctx, conn, prefix)
 
 	functionsChan := make(chan bridge.Message, 1000)
 	tasksChan := make(chan bridge.Message, 1000)
@@ -85,3 +71,51 @@ func Start(
 		}
 	}
 }
+
+// heartbeat lets the bridge functions know sst dev is running, so they can
+// fail fast or fall back when it isn't.
+func heartbeat(ctx context.Context, conn bridge.Transport, prefix string) {
+	ticker := time.NewTicker(bridge.HEARTBEAT_INTERVAL)
+	defer ticker.Stop()
+	for {
+		err := conn.Publish(ctx, bridge.LiveChannel(prefix), bridge.HeartbeatBody{Source: "dev"})
+		if err != nil {
+			slog.Error("failed to publish heartbeat", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dial connects to the relay when one is configured, otherwise to the AppSync
+// Events api of the account.
+func dial(ctx context.Context, prov *provider.AwsProvider) (bridge.Transport, error) {
+	if flag.SST_RELAY_URL != "" {
+		slog.Info("using relay", "url", flag.SST_RELAY_URL)
+		return relay.Dial(ctx, flag.SST_RELAY_URL, flag.SST_RELAY_TOKEN)
+	}
+	rest, realtime, err := prov.ResolveAppSync(ctx)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("found appsync", "rest", rest, "realtime", realtime)
+
+	now := time.Now()
+	for {
+		slog.Info("checking if appsync is ready")
+		_, err := http.Get("https://" + rest)
+		if err != nil {
+			slog.Error("appsync not ready", "err", err)
+			if time.Since(now) > time.Second*10 {
+				return nil, ErrAppsyncNotReady
+			}
+			time.Sleep(time.Second)
+			continue
+		}
+		break
+	}
+	return appsync.Dial(ctx, prov.Config(), rest, realtime)
+}
@@ -1,17 +1,28 @@
 package bridge
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"io"
-	"iter"
 	"log/slog"
+	"sync"
+	"time"
 
-	"github.com/sst/sst/v3/cmd/sst/mosaic/aws/appsync"
 	"github.com/sst/sst/v3/pkg/id"
 )
 
+// Transport carries packets between the bridge function and sst dev. Events
+// published to a channel are delivered to everyone subscribed to it. It's
+// implemented by AppSync Events and by the relay.
+type Transport interface {
+	Subscribe(ctx context.Context, channel string) (chan string, error)
+	Publish(ctx context.Context, channel string, event interface{}) error
+}
+
 type Packet struct {
 	Type   MessageType `json:"type"`
 	Source string      `json:"source"`
@@ -19,6 +30,16 @@ type Packet struct {
 	Index  int         `json:"index"`
 	Data   string      `json:"data"`
 	Final  bool        `json:"final"`
+	// Older versions of the bridge leave out the fields below. They don't
+	// acknowledge messages or decompress them, so neither is used until the
+	// other side has shown it understands them by setting Reply.
+	Reply    string `json:"reply,omitempty"`
+	Ack      bool   `json:"ack,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	// RequestID is the id the message is read with when it's set. The ID is
+	// then unique to every message sent, so a request id that comes up again,
+	// like for a retried async invocation, isn't taken for a retransmission.
+	RequestID string `json:"requestID,omitempty"`
 }
 
 type MessageType int
@@ -44,7 +65,7 @@ type Message struct {
 }
 
 type Writer struct {
-	conn     *appsync.Connection
+	client   *Client
 	message  MessageType
 	source   string
 	channel  string
@@ -53,6 +74,9 @@ type Writer struct {
 	position int
 	index    int
 	id       string
+	// the id of every packet, when the other side understands RequestID
+	packetID string
+	reliable bool
 }
 
 type InitBody struct {
@@ -68,17 +92,37 @@ type TaskStartBody struct {
 type TaskCompleteBody struct {
 }
 
+type ErrorBody struct {
+	ErrorType    string `json:"errorType"`
+	ErrorMessage string `json:"errorMessage"`
+}
+
 type PingBody struct {
 }
 
+// HeartbeatBody is published by sst dev on the live channel every
+// HEARTBEAT_INTERVAL, so the bridge function knows someone is there to handle
+// its invocations.
+type HeartbeatBody struct {
+	Source string `json:"source"`
+}
+
+const HEARTBEAT_INTERVAL = time.Second * 3
+
+func LiveChannel(prefix string) string {
+	return prefix + "/live"
+}
+
 type RebootBody struct {
 }
 
-func newWriter(conn *appsync.Connection, source string, channel string, message MessageType) *Writer {
+func newWriter(client *Client, channel string, message MessageType) *Writer {
 	return &Writer{
 		id:       id.Ascending(),
-		conn:     conn,
-		source:   source,
+		packetID: id.Ascending(),
+		reliable: client.reliable(channel),
+		client:   client,
+		source:   client.source,
 		message:  message,
 		channel:  channel,
 		buffer:   make([]byte, BUFFER_SIZE),
@@ -93,6 +137,9 @@ func (w *Writer) SetID(id string) {
 
 const BUFFER_SIZE = 1024 * 128
 
+// chunks smaller than this aren't worth compressing
+const COMPRESS_THRESHOLD = 1024
+
 func (w *Writer) Write(p []byte) (int, error) {
 	total := 0
 
@@ -115,15 +162,30 @@ func (w *Writer) Flush(final bool) error {
 	if !final && w.position == 0 {
 		return nil
 	}
-	encoded := base64.StdEncoding.EncodeToString(w.buffer[:w.position])
-	err := w.conn.Publish(context.Background(), w.channel, Packet{
+	packet := Packet{
 		ID:     w.id,
 		Index:  w.index,
 		Type:   w.message,
 		Source: w.source,
-		Data:   encoded,
 		Final:  final,
-	})
+		Reply:  w.client.inbox(),
+	}
+	if w.reliable {
+		packet.ID = w.packetID
+		packet.RequestID = w.id
+	}
+	data := w.buffer[:w.position]
+	if w.reliable && len(data) >= COMPRESS_THRESHOLD {
+		if compressed, err := compress(data); err == nil && len(compressed) < len(data) {
+			data = compressed
+			packet.Encoding = "gzip"
+		}
+	}
+	packet.Data = base64.StdEncoding.EncodeToString(data)
+	if w.reliable {
+		w.client.track(w.channel, packet)
+	}
+	err := w.client.conn.Publish(context.Background(), w.channel, packet)
 	w.index++
 	if err != nil {
 		return err
@@ -137,50 +199,65 @@ func (w *Writer) Close() error {
 	return w.Flush(true)
 }
 
+const (
+	// how long to wait for an ack before sending a message again, doubled on
+	// every attempt
+	ackTimeout  = time.Second
+	maxAttempts = 5
+	// how long a message can go without new packets before it's given up on,
+	// longer than the sender keeps retrying for
+	incompleteTimeout = time.Second * 40
+	// how long delivered messages are remembered to drop retransmissions
+	completedTTL = time.Minute * 5
+)
+
 type Client struct {
-	as      *appsync.Connection
-	prefix  string
-	pending map[string]chan []byte
-	out     chan Message
+	conn   Transport
+	prefix string
+	out    chan Message
+	source string
+
+	mut sync.Mutex
+	// channels that acknowledge messages, learned from the Reply of the
+	// packets they send
+	peers map[string]bool
+	// messages waiting for an ack by id
+	outgoing map[string]*outgoing
+}
+
+type outgoing struct {
+	channel  string
+	packets  []Packet
+	sent     bool
+	attempts int
+	deadline time.Time
+}
+
+type incoming struct {
+	id      string
+	typ     MessageType
 	source  string
+	packets map[int]Packet
+	total   int
+	updated time.Time
 }
 
-func NewClient(ctx context.Context, as *appsync.Connection, source string, prefix string) *Client {
+func NewClient(ctx context.Context, conn Transport, source string, prefix string) (*Client, error) {
 	slog.Info("subscribing to", "prefix", prefix+"/in")
-	sub, _ := as.Subscribe(ctx, prefix+"/in")
+	sub, err := conn.Subscribe(ctx, prefix+"/in")
+	if err != nil {
+		return nil, err
+	}
 	result := &Client{
-		as:      as,
-		source:  source,
-		prefix:  prefix,
-		pending: map[string]chan []byte{},
-		out:     make(chan Message, 1000),
+		conn:     conn,
+		source:   source,
+		prefix:   prefix,
+		out:      make(chan Message, 1000),
+		peers:    map[string]bool{},
+		outgoing: map[string]*outgoing{},
 	}
-	go func() {
-		for packet := range sorted(ctx, sub) {
-			pending, ok := result.pending[packet.ID]
-			if !ok {
-				pending = make(chan []byte, 100)
-				result.pending[packet.ID] = pending
-				result.out <- Message{
-					Type:   packet.Type,
-					ID:     packet.ID,
-					Source: packet.Source,
-					Body:   NewChannelReader(ctx, pending),
-				}
-			}
-			bytes, err := base64.StdEncoding.DecodeString(packet.Data)
-			if err != nil {
-				continue
-			}
-			pending <- bytes
-			if packet.Final {
-				close(pending)
-				delete(result.pending, packet.ID)
-			}
-		}
-	}()
-
-	return result
+	go result.receive(ctx, sub)
+	return result, nil
 }
 
 func (c *Client) Read() <-chan Message {
@@ -188,85 +265,224 @@ func (c *Client) Read() <-chan Message {
 }
 
 func (c *Client) NewWriter(message MessageType, destination string) *Writer {
-	writer := newWriter(c.as, c.source, destination, message)
+	writer := newWriter(c, destination, message)
 	return writer
 }
 
-type ChannelReader struct {
-	ch     chan []byte
-	buffer []byte
-	err    error
-	ctx    context.Context
+func (c *Client) inbox() string {
+	return c.prefix + "/in"
 }
 
-func NewChannelReader(ctx context.Context, ch chan []byte) *ChannelReader {
-	return &ChannelReader{
-		ch:     ch,
-		buffer: nil,
-		err:    nil,
-		ctx:    ctx,
+func (c *Client) reliable(channel string) bool {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.peers[channel]
+}
+
+// track keeps the packets of a message around until it's acknowledged.
+func (c *Client) track(channel string, packet Packet) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	item, ok := c.outgoing[packet.ID]
+	if !ok {
+		item = &outgoing{channel: channel}
+		c.outgoing[packet.ID] = item
+	}
+	item.packets = append(item.packets, packet)
+	if packet.Final {
+		item.sent = true
+		item.deadline = time.Now().Add(ackTimeout)
 	}
 }
 
-func (r *ChannelReader) Read(p []byte) (n int, err error) {
-	if len(r.buffer) == 0 && r.err == nil {
-		select {
-		case chunk, ok := <-r.ch:
-			if !ok {
-				return 0, io.EOF
-			}
-			r.buffer = chunk
-		case <-r.ctx.Done():
-			return 0, io.EOF
+// retransmit sends the messages that haven't been acknowledged in time again.
+func (c *Client) retransmit(ctx context.Context) {
+	type retry struct {
+		channel string
+		packets []Packet
+	}
+	retries := []retry{}
+	now := time.Now()
+	c.mut.Lock()
+	for messageID, item := range c.outgoing {
+		if !item.sent || now.Before(item.deadline) {
+			continue
+		}
+		if item.attempts >= maxAttempts {
+			slog.Warn("message was never acknowledged", "id", messageID, "channel", item.channel)
+			delete(c.outgoing, messageID)
+			continue
 		}
+		item.attempts++
+		item.deadline = now.Add(ackTimeout << item.attempts)
+		retries = append(retries, retry{item.channel, item.packets})
 	}
-	if len(r.buffer) > 0 {
-		n = copy(p, r.buffer)
-		r.buffer = r.buffer[n:]
-		return n, nil
+	c.mut.Unlock()
+	for _, retry := range retries {
+		slog.Info("retransmitting", "id", retry.packets[0].ID, "packets", len(retry.packets))
+		for _, packet := range retry.packets {
+			c.conn.Publish(ctx, retry.channel, packet)
+		}
 	}
+}
 
-	if r.err != nil {
-		return 0, r.err
+func (c *Client) ack(ctx context.Context, packet Packet) {
+	if packet.Reply == "" {
+		return
 	}
-	return 0, io.EOF
-}
-
-func sorted(ctx context.Context, sub appsync.SubscriptionChannel) iter.Seq[Packet] {
-	return func(yield func(Packet) bool) {
-		history := make(map[string]map[int]Packet)
-		next := map[string]int{}
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case msg := <-sub:
-				var packet Packet
-				json.Unmarshal([]byte(msg), &packet)
-				slog.Info("got packet", "id", packet.ID, "type", packet.Type, "from", packet.Source)
-				unprocessed, ok := history[packet.ID]
-				if !ok {
-					unprocessed = map[int]Packet{}
-					history[packet.ID] = unprocessed
+	go c.conn.Publish(ctx, packet.Reply, Packet{
+		Type:   packet.Type,
+		Source: c.source,
+		ID:     packet.ID,
+		Ack:    true,
+		Final:  true,
+		Reply:  c.inbox(),
+	})
+}
+
+// receive puts messages back together from their packets, which can arrive
+// out of order and more than once. A message is only passed on once all of
+// its packets are in. If that doesn't happen in time, the waiting side gets a
+// MessageError with the same id instead.
+func (c *Client) receive(ctx context.Context, sub chan string) {
+	pending := map[string]*incoming{}
+	completed := map[string]time.Time{}
+	ticker := time.NewTicker(time.Millisecond * 500)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.retransmit(ctx)
+			now := time.Now()
+			for key, item := range pending {
+				if now.Sub(item.updated) < incompleteTimeout {
+					continue
+				}
+				slog.Warn("message is incomplete", "id", item.id, "from", item.source, "packets", len(item.packets))
+				delete(pending, key)
+				completed[key] = now
+				body, _ := json.Marshal(ErrorBody{
+					ErrorType:    "Bridge.Timeout",
+					ErrorMessage: fmt.Sprintf("Timed out waiting for the rest of the message from %s", item.source),
+				})
+				c.out <- Message{
+					ID:     item.id,
+					Type:   MessageError,
+					Source: item.source,
+					Body:   bytes.NewReader(body),
+				}
+			}
+			for key, at := range completed {
+				if now.Sub(at) > completedTTL {
+					delete(completed, key)
+				}
+			}
+		case msg := <-sub:
+			var packet Packet
+			if err := json.Unmarshal([]byte(msg), &packet); err != nil {
+				continue
+			}
+			slog.Info("got packet", "id", packet.ID, "type", packet.Type, "from", packet.Source, "index", packet.Index, "ack", packet.Ack)
+			if packet.Reply != "" {
+				c.mut.Lock()
+				c.peers[packet.Reply] = true
+				if packet.Ack {
+					delete(c.outgoing, packet.ID)
+				}
+				c.mut.Unlock()
+			}
+			if packet.Ack {
+				continue
+			}
+			key := packet.Source + "/" + packet.ID
+			if _, ok := completed[key]; ok {
+				// the ack was lost so the message was sent again
+				if packet.Final {
+					c.ack(ctx, packet)
+				}
+				continue
+			}
+			item, ok := pending[key]
+			if !ok {
+				messageID := packet.ID
+				if packet.RequestID != "" {
+					messageID = packet.RequestID
 				}
-				unprocessed[packet.Index] = packet
-				for {
-					index := next[packet.ID]
-					envelope, ok := unprocessed[index]
-					if !ok {
-						break
-					}
-					delete(unprocessed, index)
-					next[envelope.ID] = index + 1
-					if !yield(envelope) {
-						return
-					}
-					if envelope.Final {
-						delete(history, envelope.ID)
-					}
+				item = &incoming{
+					id:      messageID,
+					typ:     packet.Type,
+					source:  packet.Source,
+					packets: map[int]Packet{},
 				}
+				pending[key] = item
 			}
+			item.packets[packet.Index] = packet
+			item.updated = time.Now()
+			if packet.Final {
+				item.total = packet.Index + 1
+			}
+			if item.total == 0 || len(item.packets) < item.total {
+				continue
+			}
+			delete(pending, key)
+			completed[key] = time.Now()
+			body, err := item.assemble()
+			if err != nil {
+				slog.Error("failed to read message", "id", item.id, "err", err)
+				continue
+			}
+			c.ack(ctx, packet)
+			c.out <- Message{
+				ID:     item.id,
+				Type:   item.typ,
+				Source: item.source,
+				Body:   bytes.NewReader(body),
+			}
+		}
+	}
+}
 
+func (m *incoming) assemble() ([]byte, error) {
+	result := []byte{}
+	for index := 0; index < m.total; index++ {
+		packet, ok := m.packets[index]
+		if !ok {
+			return nil, fmt.Errorf("missing packet %d", index)
+		}
+		data, err := base64.StdEncoding.DecodeString(packet.Data)
+		if err != nil {
+			return nil, err
 		}
+		if packet.Encoding == "gzip" {
+			data, err = decompress(data)
+			if err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, data...)
+	}
+	return result, nil
+}
+
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decompress(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
 	}
+	defer reader.Close()
+	return io.ReadAll(reader)
 }
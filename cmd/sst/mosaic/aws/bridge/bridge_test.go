@@ -0,0 +1,300 @@
+package bridge_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sst/sst/v3/cmd/sst/mosaic/aws/bridge"
+	"github.com/sst/sst/v3/cmd/sst/mosaic/aws/relay"
+)
+
+func startRelay(t *testing.T, token string) string {
+	server := httptest.NewServer(relay.NewServer(token))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func TestRelayRoundTrip(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	url := startRelay(t, "secret")
+	prefix := "/sst/app/stage"
+
+	devConn, err := relay.Dial(ctx, url, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev, err := bridge.NewClient(ctx, devConn, "dev", prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	workerConn, err := relay.Dial(ctx, url, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	worker, err := bridge.NewClient(ctx, workerConn, "worker", prefix+"/worker")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// spans a few packets
+	body := bytes.Repeat([]byte("0123456789"), bridge.BUFFER_SIZE/4)
+	writer := worker.NewWriter(bridge.MessageInit, prefix+"/in")
+	if _, err := writer.Write(body); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg := <-dev.Read():
+		if msg.Type != bridge.MessageInit || msg.Source != "worker" {
+			t.Fatalf("got message %v from %q", msg.Type, msg.Source)
+		}
+		received, err := io.ReadAll(msg.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(received, body) {
+			t.Fatalf("got %d bytes, want %d", len(received), len(body))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+}
+
+// lossy drops the first packet it sees at an index and records the rest.
+type lossy struct {
+	bridge.Transport
+	drop    int
+	mut     sync.Mutex
+	dropped bool
+	sent    []bridge.Packet
+}
+
+func (l *lossy) Publish(ctx context.Context, channel string, event interface{}) error {
+	packet, ok := event.(bridge.Packet)
+	l.mut.Lock()
+	if ok && !packet.Ack && packet.Index == l.drop && !l.dropped {
+		l.dropped = true
+		l.mut.Unlock()
+		return nil
+	}
+	if ok {
+		l.sent = append(l.sent, packet)
+	}
+	l.mut.Unlock()
+	return l.Transport.Publish(ctx, channel, event)
+}
+
+// greet sends a message from one client to the other, which shows the other
+// side that it acknowledges messages.
+func greet(t *testing.T, from *bridge.Client, to *bridge.Client, channel string) {
+	t.Helper()
+	writer := from.NewWriter(bridge.MessagePing, channel)
+	writer.Write([]byte("{}"))
+	writer.Close()
+	select {
+	case <-to.Read():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for greeting")
+	}
+}
+
+func TestRetransmit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	url := startRelay(t, "")
+	prefix := "/sst/app/stage"
+
+	devConn, err := relay.Dial(ctx, url, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev, err := bridge.NewClient(ctx, devConn, "dev", prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	workerConn, err := relay.Dial(ctx, url, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := &lossy{Transport: workerConn, drop: 1}
+	worker, err := bridge.NewClient(ctx, transport, "worker", prefix+"/worker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	greet(t, dev, worker, prefix+"/worker/in")
+
+	body := bytes.Repeat([]byte("0123456789"), bridge.BUFFER_SIZE/4)
+	writer := worker.NewWriter(bridge.MessageNext, prefix+"/in")
+	writer.Write(body)
+	writer.Close()
+
+	select {
+	case msg := <-dev.Read():
+		received, _ := io.ReadAll(msg.Body)
+		if !bytes.Equal(received, body) {
+			t.Fatalf("got %d bytes, want %d", len(received), len(body))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+	select {
+	case msg := <-dev.Read():
+		t.Fatalf("got message %s twice", msg.ID)
+	case <-time.After(2 * time.Second):
+	}
+
+	transport.mut.Lock()
+	defer transport.mut.Unlock()
+	for _, packet := range transport.sent {
+		if len(packet.Data) > 0 && packet.Encoding != "gzip" {
+			t.Fatalf("packet %d was not compressed", packet.Index)
+		}
+	}
+}
+
+func TestRelayUnauthorized(t *testing.T) {
+	url := startRelay(t, "secret")
+	_, err := relay.Dial(context.Background(), url, "wrong")
+	if !errors.Is(err, relay.ErrUnauthorized) {
+		t.Fatalf("got %v, want %v", err, relay.ErrUnauthorized)
+	}
+}
+
+type unsubscribable struct {
+	bridge.Transport
+}
+
+func (unsubscribable) Subscribe(ctx context.Context, channel string) (chan string, error) {
+	return nil, relay.ErrSubscriptionFailed
+}
+
+func TestSubscribeFailed(t *testing.T) {
+	_, err := bridge.NewClient(context.Background(), unsubscribable{}, "dev", "/sst/app/stage")
+	if !errors.Is(err, relay.ErrSubscriptionFailed) {
+		t.Fatalf("got %v, want %v", err, relay.ErrSubscriptionFailed)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	url := startRelay(t, "")
+	prefix := "/sst/app/stage"
+
+	devConn, err := relay.Dial(ctx, url, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev, err := bridge.NewClient(ctx, devConn, "dev", prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	workerConn, err := relay.Dial(ctx, url, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := &lossy{Transport: workerConn, drop: -1}
+	worker, err := bridge.NewClient(ctx, transport, "worker", prefix+"/worker")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing has come from dev yet, so it might be on an older version
+	body := bytes.Repeat([]byte("0123456789"), bridge.COMPRESS_THRESHOLD)
+	writer := worker.NewWriter(bridge.MessageInit, prefix+"/in")
+	writer.SetID("init")
+	writer.Write(body)
+	writer.Close()
+	select {
+	case msg := <-dev.Read():
+		if msg.ID != "init" {
+			t.Fatalf("got id %s, want init", msg.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+	transport.mut.Lock()
+	for _, packet := range transport.sent {
+		if packet.Encoding != "" || packet.RequestID != "" || packet.ID != "init" {
+			t.Fatalf("packet %d was sent with %+v before dev replied", packet.Index, packet)
+		}
+	}
+	transport.sent = nil
+	transport.mut.Unlock()
+
+	greet(t, dev, worker, prefix+"/worker/in")
+	writer = worker.NewWriter(bridge.MessageNext, prefix+"/in")
+	writer.SetID("next")
+	writer.Write(body)
+	writer.Close()
+	select {
+	case msg := <-dev.Read():
+		if msg.ID != "next" {
+			t.Fatalf("got id %s, want next", msg.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+	transport.mut.Lock()
+	defer transport.mut.Unlock()
+	for _, packet := range transport.sent {
+		if packet.Ack {
+			continue
+		}
+		if packet.Encoding != "gzip" || packet.RequestID != "next" || packet.ID == "next" {
+			t.Fatalf("packet %d was sent with %+v after dev replied", packet.Index, packet)
+		}
+	}
+}
+
+func TestRepeatedRequestID(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	url := startRelay(t, "")
+	prefix := "/sst/app/stage"
+
+	devConn, err := relay.Dial(ctx, url, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev, err := bridge.NewClient(ctx, devConn, "dev", prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	workerConn, err := relay.Dial(ctx, url, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	worker, err := bridge.NewClient(ctx, workerConn, "worker", prefix+"/worker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	greet(t, worker, dev, prefix+"/in")
+
+	// a retried async invocation has the same request id
+	for attempt := 0; attempt < 2; attempt++ {
+		writer := dev.NewWriter(bridge.MessageResponse, prefix+"/worker/in")
+		writer.SetID("request")
+		writer.Write([]byte("{}"))
+		writer.Close()
+		select {
+		case msg := <-worker.Read():
+			if msg.ID != "request" {
+				t.Fatalf("got id %s, want request", msg.ID)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for attempt %d", attempt+1)
+		}
+	}
+}
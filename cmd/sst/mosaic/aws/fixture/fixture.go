@@ -0,0 +1,597 @@
+package fixture
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sst/sst/v3/pkg/id"
+)
+
+var ErrUnknownFixture = fmt.Errorf("unknown event")
+var ErrUnknownParam = fmt.Errorf("unknown param")
+var ErrInvalidParam = fmt.Errorf("invalid param")
+
+const (
+	region  = "us-east-1"
+	account = "123456789012"
+)
+
+// Builtin is an event that can be generated without capturing it first. The
+// params are used to fill in the parts of the event that a handler usually
+// looks at.
+type Builtin struct {
+	Name        string
+	Description string
+	Params      map[string]string
+	generate    func(p params) (any, error)
+}
+
+var builtins = []*Builtin{
+	{
+		Name:        "api-v1",
+		Description: "API Gateway REST API request",
+		Params:      httpParams,
+		generate:    apiV1,
+	},
+	{
+		Name:        "api-v2",
+		Description: "API Gateway HTTP API request",
+		Params:      httpParams,
+		generate:    apiV2,
+	},
+	{
+		Name:        "alb",
+		Description: "Application Load Balancer request",
+		Params:      httpParams,
+		generate:    alb,
+	},
+	{
+		Name:        "sqs",
+		Description: "SQS message",
+		Params: map[string]string{
+			"queue": "MyQueue",
+			"body":  "{}",
+		},
+		generate: sqs,
+	},
+	{
+		Name:        "sns",
+		Description: "SNS notification",
+		Params: map[string]string{
+			"topic":   "MyTopic",
+			"subject": "",
+			"message": "{}",
+		},
+		generate: sns,
+	},
+	{
+		Name:        "s3",
+		Description: "S3 object notification",
+		Params: map[string]string{
+			"bucket": "my-bucket",
+			"key":    "file.txt",
+			"event":  "ObjectCreated:Put",
+			"size":   "1024",
+		},
+		generate: s3,
+	},
+	{
+		Name:        "eventbridge",
+		Description: "EventBridge event",
+		Params: map[string]string{
+			"bus":        "default",
+			"source":     "my.app",
+			"detailType": "MyEvent",
+			"detail":     "{}",
+		},
+		generate: eventbridge,
+	},
+	{
+		Name:        "dynamodb",
+		Description: "DynamoDB Streams record",
+		Params: map[string]string{
+			"table": "MyTable",
+			"event": "INSERT",
+			"key":   "id",
+			"new":   `{"id":"1"}`,
+			"old":   "",
+		},
+		generate: dynamodb,
+	},
+	{
+		Name:        "kinesis",
+		Description: "Kinesis Data Streams record",
+		Params: map[string]string{
+			"stream":       "MyStream",
+			"partitionKey": "1",
+			"data":         "{}",
+		},
+		generate: kinesis,
+	},
+	{
+		Name:        "cognito",
+		Description: "Cognito user pool trigger",
+		Params: map[string]string{
+			"trigger":  "PreSignUp_SignUp",
+			"userPool": "us-east-1_example",
+			"username": "user",
+			"email":    "user@example.com",
+		},
+		generate: cognito,
+	},
+}
+
+var httpParams = map[string]string{
+	"method": "GET",
+	"path":   "/",
+	"query":  "",
+	"body":   "",
+}
+
+// Builtins returns the events that can be generated.
+func Builtins() []*Builtin {
+	return builtins
+}
+
+// Lookup returns the builtin event with the name.
+func Lookup(name string) (*Builtin, bool) {
+	for _, item := range builtins {
+		if item.Name == name {
+			return item, true
+		}
+	}
+	return nil, false
+}
+
+// ParseParams parses params passed in as a query string, like
+// `method=POST&path=/users`.
+func ParseParams(input string) (map[string]string, error) {
+	values, err := url.ParseQuery(input)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidParam, err)
+	}
+	result := map[string]string{}
+	for key, value := range values {
+		result[key] = value[len(value)-1]
+	}
+	return result, nil
+}
+
+// Generate returns the builtin event with the name, filled in with the params.
+// Params that are not passed in use their default.
+func Generate(name string, input map[string]string) ([]byte, error) {
+	builtin, ok := Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, ErrUnknownFixture)
+	}
+	p := params{}
+	for key, value := range builtin.Params {
+		p[key] = value
+	}
+	for key, value := range input {
+		if _, ok := builtin.Params[key]; !ok {
+			return nil, fmt.Errorf("%s for %s: %w", key, name, ErrUnknownParam)
+		}
+		p[key] = value
+	}
+	// the query can also be passed in with the path
+	if path, query, ok := strings.Cut(p["path"], "?"); ok {
+		p["path"] = path
+		p["query"] = query
+	}
+	event, err := builtin.generate(p)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(event, "", "  ")
+}
+
+type params map[string]string
+
+// json parses a param that has to be JSON, like an EventBridge detail.
+func (p params) json(key string) (any, error) {
+	if p[key] == "" {
+		return nil, nil
+	}
+	var result any
+	if err := json.Unmarshal([]byte(p[key]), &result); err != nil {
+		return nil, fmt.Errorf("%w: %s is not valid JSON: %v", ErrInvalidParam, key, err)
+	}
+	return result, nil
+}
+
+func (p params) query() map[string]any {
+	if p["query"] == "" {
+		return nil
+	}
+	values, _ := url.ParseQuery(p["query"])
+	result := map[string]any{}
+	for key, value := range values {
+		result[key] = value[len(value)-1]
+	}
+	return result
+}
+
+func (p params) method() string {
+	return strings.ToUpper(p["method"])
+}
+
+var httpHeaders = map[string]any{
+	"accept":            "*/*",
+	"content-type":      "application/json",
+	"host":              "localhost",
+	"user-agent":        "sst",
+	"x-forwarded-for":   "127.0.0.1",
+	"x-forwarded-port":  "443",
+	"x-forwarded-proto": "https",
+}
+
+func apiV1(p params) (any, error) {
+	now := time.Now()
+	return map[string]any{
+		"resource":                        p["path"],
+		"path":                            p["path"],
+		"httpMethod":                      p.method(),
+		"headers":                         httpHeaders,
+		"multiValueHeaders":               multiValue(httpHeaders),
+		"queryStringParameters":           p.query(),
+		"multiValueQueryStringParameters": multiValue(p.query()),
+		"pathParameters":                  nil,
+		"stageVariables":                  nil,
+		"requestContext": map[string]any{
+			"accountId":        account,
+			"apiId":            "api",
+			"domainName":       "localhost",
+			"httpMethod":       p.method(),
+			"path":             "/prod" + p["path"],
+			"protocol":         "HTTP/1.1",
+			"requestId":        id.Ascending(),
+			"requestTime":      now.UTC().Format("02/Jan/2006:15:04:05 -0700"),
+			"requestTimeEpoch": now.UnixMilli(),
+			"resourcePath":     p["path"],
+			"stage":            "prod",
+			"identity": map[string]any{
+				"sourceIp":  "127.0.0.1",
+				"userAgent": "sst",
+			},
+		},
+		"body":            p["body"],
+		"isBase64Encoded": false,
+	}, nil
+}
+
+func apiV2(p params) (any, error) {
+	now := time.Now()
+	return map[string]any{
+		"version":               "2.0",
+		"routeKey":              "$default",
+		"rawPath":               p["path"],
+		"rawQueryString":        p["query"],
+		"headers":               httpHeaders,
+		"queryStringParameters": p.query(),
+		"requestContext": map[string]any{
+			"accountId":    account,
+			"apiId":        "api",
+			"domainName":   "localhost",
+			"domainPrefix": "localhost",
+			"http": map[string]any{
+				"method":    p.method(),
+				"path":      p["path"],
+				"protocol":  "HTTP/1.1",
+				"sourceIp":  "127.0.0.1",
+				"userAgent": "sst",
+			},
+			"requestId": id.Ascending(),
+			"routeKey":  "$default",
+			"stage":     "$default",
+			"time":      now.UTC().Format("02/Jan/2006:15:04:05 -0700"),
+			"timeEpoch": now.UnixMilli(),
+		},
+		"body":            p["body"],
+		"isBase64Encoded": false,
+	}, nil
+}
+
+func alb(p params) (any, error) {
+	return map[string]any{
+		"requestContext": map[string]any{
+			"elb": map[string]any{
+				"targetGroupArn": arn("elasticloadbalancing", "targetgroup/my-target-group/0123456789abcdef"),
+			},
+		},
+		"httpMethod":            p.method(),
+		"path":                  p["path"],
+		"queryStringParameters": p.query(),
+		"headers":               httpHeaders,
+		"body":                  p["body"],
+		"isBase64Encoded":       false,
+	}, nil
+}
+
+func sqs(p params) (any, error) {
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	return map[string]any{
+		"Records": []any{
+			map[string]any{
+				"messageId":     id.Ascending(),
+				"receiptHandle": id.Ascending(),
+				"body":          p["body"],
+				"attributes": map[string]any{
+					"ApproximateReceiveCount":          "1",
+					"SentTimestamp":                    now,
+					"SenderId":                         account,
+					"ApproximateFirstReceiveTimestamp": now,
+				},
+				"messageAttributes": map[string]any{},
+				"md5OfBody":         "",
+				"eventSource":       "aws:sqs",
+				"eventSourceARN":    arn("sqs", p["queue"]),
+				"awsRegion":         region,
+			},
+		},
+	}, nil
+}
+
+func sns(p params) (any, error) {
+	topic := arn("sns", p["topic"])
+	var subject any
+	if p["subject"] != "" {
+		subject = p["subject"]
+	}
+	return map[string]any{
+		"Records": []any{
+			map[string]any{
+				"EventSource":          "aws:sns",
+				"EventVersion":         "1.0",
+				"EventSubscriptionArn": topic + ":" + id.Ascending(),
+				"Sns": map[string]any{
+					"Type":              "Notification",
+					"MessageId":         id.Ascending(),
+					"TopicArn":          topic,
+					"Subject":           subject,
+					"Message":           p["message"],
+					"Timestamp":         time.Now().UTC().Format(time.RFC3339Nano),
+					"MessageAttributes": map[string]any{},
+				},
+			},
+		},
+	}, nil
+}
+
+func s3(p params) (any, error) {
+	size, err := strconv.Atoi(p["size"])
+	if err != nil {
+		return nil, fmt.Errorf("%w: size has to be a number", ErrInvalidParam)
+	}
+	return map[string]any{
+		"Records": []any{
+			map[string]any{
+				"eventVersion": "2.1",
+				"eventSource":  "aws:s3",
+				"awsRegion":    region,
+				"eventTime":    time.Now().UTC().Format(time.RFC3339Nano),
+				"eventName":    p["event"],
+				"userIdentity": map[string]any{
+					"principalId": account,
+				},
+				"s3": map[string]any{
+					"s3SchemaVersion": "1.0",
+					"configurationId": "sst",
+					"bucket": map[string]any{
+						"name": p["bucket"],
+						"arn":  "arn:aws:s3:::" + p["bucket"],
+						"ownerIdentity": map[string]any{
+							"principalId": account,
+						},
+					},
+					"object": map[string]any{
+						// keys are url encoded in notifications
+						"key":       strings.ReplaceAll(url.QueryEscape(p["key"]), "%2F", "/"),
+						"size":      size,
+						"eTag":      "d41d8cd98f00b204e9800998ecf8427e",
+						"sequencer": strconv.FormatInt(time.Now().UnixNano(), 16),
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func eventbridge(p params) (any, error) {
+	detail, err := p.json("detail")
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"version":        "0",
+		"id":             id.Ascending(),
+		"detail-type":    p["detailType"],
+		"source":         p["source"],
+		"account":        account,
+		"time":           time.Now().UTC().Format(time.RFC3339),
+		"region":         region,
+		"resources":      []any{},
+		"detail":         detail,
+		"event-bus-name": p["bus"],
+	}, nil
+}
+
+func dynamodb(p params) (any, error) {
+	record := map[string]any{
+		"ApproximateCreationDateTime": time.Now().Unix(),
+		"SequenceNumber":              strconv.FormatInt(time.Now().UnixNano(), 10),
+		"SizeBytes":                   len(p["new"]) + len(p["old"]),
+	}
+	var item map[string]any
+	for _, image := range []string{"new", "old"} {
+		value, err := p.json(image)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			continue
+		}
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s has to be an object", ErrInvalidParam, image)
+		}
+		if item == nil {
+			item = object
+		}
+		record[map[string]string{"new": "NewImage", "old": "OldImage"}[image]] = attributes(object)
+	}
+	keys := map[string]any{}
+	for _, key := range strings.Split(p["key"], ",") {
+		if value, ok := item[key]; ok {
+			keys[key] = attribute(value)
+		}
+	}
+	record["Keys"] = keys
+	switch {
+	case record["NewImage"] != nil && record["OldImage"] != nil:
+		record["StreamViewType"] = "NEW_AND_OLD_IMAGES"
+	case record["OldImage"] != nil:
+		record["StreamViewType"] = "OLD_IMAGE"
+	default:
+		record["StreamViewType"] = "NEW_IMAGE"
+	}
+	table := arn("dynamodb", "table/"+p["table"])
+	return map[string]any{
+		"Records": []any{
+			map[string]any{
+				"eventID":        id.Ascending(),
+				"eventName":      strings.ToUpper(p["event"]),
+				"eventVersion":   "1.1",
+				"eventSource":    "aws:dynamodb",
+				"awsRegion":      region,
+				"dynamodb":       record,
+				"eventSourceARN": table + "/stream/" + time.Now().UTC().Format("2006-01-02T15:04:05.000"),
+			},
+		},
+	}, nil
+}
+
+// attributes converts a plain JSON object to the attribute values of a stream
+// record.
+func attributes(object map[string]any) map[string]any {
+	result := map[string]any{}
+	for key, value := range object {
+		result[key] = attribute(value)
+	}
+	return result
+}
+
+func attribute(value any) map[string]any {
+	switch value := value.(type) {
+	case string:
+		return map[string]any{"S": value}
+	case float64:
+		return map[string]any{"N": strconv.FormatFloat(value, 'f', -1, 64)}
+	case bool:
+		return map[string]any{"BOOL": value}
+	case []any:
+		list := make([]any, 0, len(value))
+		for _, item := range value {
+			list = append(list, attribute(item))
+		}
+		return map[string]any{"L": list}
+	case map[string]any:
+		return map[string]any{"M": attributes(value)}
+	}
+	return map[string]any{"NULL": true}
+}
+
+func kinesis(p params) (any, error) {
+	now := time.Now()
+	return map[string]any{
+		"Records": []any{
+			map[string]any{
+				"kinesis": map[string]any{
+					"kinesisSchemaVersion":        "1.0",
+					"partitionKey":                p["partitionKey"],
+					"sequenceNumber":              strconv.FormatInt(now.UnixNano(), 10),
+					"data":                        base64.StdEncoding.EncodeToString([]byte(p["data"])),
+					"approximateArrivalTimestamp": float64(now.UnixMilli()) / 1000,
+				},
+				"eventSource":       "aws:kinesis",
+				"eventVersion":      "1.0",
+				"eventID":           "shardId-000000000000:" + strconv.FormatInt(now.UnixNano(), 10),
+				"eventName":         "aws:kinesis:record",
+				"invokeIdentityArn": arn("iam", "role/MyRole"),
+				"awsRegion":         region,
+				"eventSourceARN":    arn("kinesis", "stream/"+p["stream"]),
+			},
+		},
+	}, nil
+}
+
+func cognito(p params) (any, error) {
+	request := map[string]any{
+		"userAttributes": map[string]any{
+			"sub":            id.Ascending(),
+			"email":          p["email"],
+			"email_verified": "true",
+		},
+	}
+	response := map[string]any{}
+	switch {
+	case strings.HasPrefix(p["trigger"], "PreSignUp_"):
+		request["validationData"] = nil
+		response["autoConfirmUser"] = false
+		response["autoVerifyEmail"] = false
+		response["autoVerifyPhone"] = false
+	case strings.HasPrefix(p["trigger"], "TokenGeneration_"):
+		request["groupConfiguration"] = map[string]any{
+			"groupsToOverride":   []any{},
+			"iamRolesToOverride": []any{},
+		}
+		response["claimsOverrideDetails"] = nil
+	}
+	return map[string]any{
+		"version":       "1",
+		"triggerSource": p["trigger"],
+		"region":        region,
+		"userPoolId":    p["userPool"],
+		"userName":      p["username"],
+		"callerContext": map[string]any{
+			"awsSdkVersion": "aws-sdk-unknown-unknown",
+			"clientId":      "client",
+		},
+		"request":  request,
+		"response": response,
+	}, nil
+}
+
+func arn(service string, resource string) string {
+	if service == "iam" {
+		return "arn:aws:iam::" + account + ":" + resource
+	}
+	return strings.Join([]string{"arn:aws", service, region, account, resource}, ":")
+}
+
+func multiValue(values map[string]any) map[string]any {
+	if values == nil {
+		return nil
+	}
+	result := map[string]any{}
+	for key, value := range values {
+		result[key] = []any{value}
+	}
+	return result
+}
+
+// ParamNames returns the params of a builtin in a stable order.
+func (b *Builtin) ParamNames() []string {
+	result := make([]string, 0, len(b.Params))
+	for key := range b.Params {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,88 @@
+package fixture
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sst/sst/v3/pkg/project/path"
+)
+
+var ErrInvalidName = fmt.Errorf("fixture names can only contain letters, numbers, dots, hyphens, and underscores")
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Dir is where saved fixtures are stored, in .sst/fixtures.
+func Dir(cfgPath string) string {
+	return filepath.Join(path.ResolveWorkingDir(cfgPath), "fixtures")
+}
+
+// Save stores an event as a named fixture, replacing the one with the same
+// name.
+func Save(dir string, name string, event []byte) error {
+	if !namePattern.MatchString(name) {
+		return ErrInvalidName
+	}
+	var formatted bytes.Buffer
+	if err := json.Indent(&formatted, event, "", "  "); err != nil {
+		return fmt.Errorf("event is not valid JSON: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, name+".json"), formatted.Bytes(), 0644)
+}
+
+// Load returns a saved fixture.
+func Load(dir string, name string) ([]byte, error) {
+	if !namePattern.MatchString(name) {
+		return nil, ErrInvalidName
+	}
+	data, err := os.ReadFile(filepath.Join(dir, name+".json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s: %w", name, ErrUnknownFixture)
+	}
+	return data, err
+}
+
+// List returns the names of the saved fixtures.
+func List(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := []string{}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// Resolve returns the event for a name, looking at the saved fixtures first so
+// they can take the place of a builtin. Params can only be used with builtins.
+func Resolve(dir string, name string, params map[string]string) ([]byte, error) {
+	data, err := Load(dir, name)
+	if err == nil {
+		if len(params) > 0 {
+			return nil, fmt.Errorf("%w: %s is a saved fixture and can't take params", ErrInvalidParam, name)
+		}
+		return data, nil
+	}
+	if _, ok := Lookup(name); !ok {
+		return nil, err
+	}
+	return Generate(name, params)
+}
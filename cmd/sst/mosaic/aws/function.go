@@ -2,7 +2,6 @@ package aws
 
 import (
 	"bufio"
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -16,6 +15,7 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/sst/sst/v3/cmd/sst/mosaic/aws/bridge"
+	"github.com/sst/sst/v3/cmd/sst/mosaic/aws/recording"
 	"github.com/sst/sst/v3/cmd/sst/mosaic/watcher"
 	"github.com/sst/sst/v3/pkg/bus"
 	"github.com/sst/sst/v3/pkg/project"
@@ -35,6 +35,8 @@ type FunctionResponseEvent struct {
 	WorkerID   string
 	RequestID  string
 	Output     []byte
+	// most memory used by the worker in MB, when it's tracked
+	Memory int64
 }
 
 type FunctionErrorEvent struct {
@@ -44,6 +46,9 @@ type FunctionErrorEvent struct {
 	ErrorType    string   `json:"errorType"`
 	ErrorMessage string   `json:"errorMessage"`
 	Trace        []string `json:"trace"`
+	// the trace mapped to the original source
+	Frames []runtime.Frame `json:"frames,omitempty"`
+	Memory int64           `json:"-"`
 }
 
 type FunctionBuildEvent struct {
@@ -51,6 +56,13 @@ type FunctionBuildEvent struct {
 	Errors     []string
 }
 
+type FunctionDebugEvent struct {
+	FunctionID string
+	WorkerID   string
+	Debugger   string
+	Port       int
+}
+
 type FunctionLogEvent struct {
 	FunctionID string
 	WorkerID   string
@@ -69,121 +81,66 @@ type input struct {
 
 func function(ctx context.Context, input input) {
 	log := slog.Default().With("service", "aws.function")
-	server := fmt.Sprintf("localhost:%d/lambda/", input.server.Port)
+	server := fmt.Sprintf("127.0.0.1:%d/lambda/", input.server.Port)
 	type WorkerInfo struct {
 		FunctionID       string
 		WorkerID         string
 		Worker           runtime.Worker
 		CurrentRequestID string
 		Env              []string
+		Debug            bool
+		Build            *runtime.BuildOutput
 	}
 	workerShutdownChan := make(chan *WorkerInfo, 1000)
 	nextChan := map[string]chan io.Reader{}
 	workers := map[string]*WorkerInfo{}
-	evts := bus.Subscribe(&watcher.FileChangedEvent{}, &project.CompleteEvent{}, &runtime.BuildInput{}, &FunctionInvokedEvent{})
+	evts := bus.Subscribe(&watcher.FileChangedEvent{}, &project.CompleteEvent{}, &runtime.BuildInput{}, &FunctionInvokedEvent{}, &FunctionResponseEvent{}, &FunctionErrorEvent{})
 	go fileLogger(input.project)
+	go recorder(input.project)
 
-	input.server.Mux.HandleFunc(`/lambda/{workerID}/2018-06-01/runtime/invocation/next`, func(w http.ResponseWriter, r *http.Request) {
-		log.Info("got next request", "workerID", r.PathValue("workerID"))
-		workerID := r.PathValue("workerID")
-		ch := nextChan[workerID]
-		select {
-		case <-r.Context().Done():
-			log.Info("worker disconnected", "workerID", workerID)
-			return
-		case reader := <-ch:
-			log.Info("worker got next request", "workerID", workerID)
-			writer := input.client.NewWriter(bridge.MessagePing, input.prefix+"/"+r.PathValue("workerID")+"/in")
-			json.NewEncoder(writer).Encode(bridge.PingBody{})
-			writer.Close()
-			resp, _ := http.ReadResponse(bufio.NewReader(reader), r)
-			requestID := resp.Header.Get("lambda-runtime-aws-request-id")
-			for key, values := range resp.Header {
-				for _, value := range values {
-					w.Header().Add(key, value)
+	// invocations that are still running, by worker
+	pending := map[string]string{}
+	deadlines := map[string]*time.Timer{}
+	timeoutChan := make(chan *FunctionInvokedEvent, 1000)
+
+	api := &lambdaAPI{
+		next: func(r *http.Request, workerID string) (*http.Response, bool) {
+			select {
+			case <-r.Context().Done():
+				return nil, false
+			case reader := <-nextChan[workerID]:
+				writer := input.client.NewWriter(bridge.MessagePing, input.prefix+"/"+workerID+"/in")
+				json.NewEncoder(writer).Encode(bridge.PingBody{})
+				writer.Close()
+				resp, err := http.ReadResponse(bufio.NewReader(reader), r)
+				if err != nil {
+					log.Error("failed to read invocation", "workerID", workerID, "err", err)
+					return nil, false
 				}
+				return resp, true
 			}
-			w.WriteHeader(resp.StatusCode)
-
-			var buf bytes.Buffer
-			tee := io.TeeReader(resp.Body, &buf)
-			io.Copy(w, tee)
-			workerInfo, ok := workers[workerID]
-			if ok {
-				bus.Publish(&FunctionInvokedEvent{
-					FunctionID: workerInfo.FunctionID,
-					WorkerID:   workerID,
-					RequestID:  requestID,
-					Input:      buf.Bytes(),
-				})
+		},
+		worker: func(workerID string) (*lambdaWorker, bool) {
+			info, ok := workers[workerID]
+			if !ok {
+				return nil, false
 			}
-		}
-	})
-
-	input.server.Mux.HandleFunc(`/lambda/{workerID}/2018-06-01/runtime/init/error`, func(w http.ResponseWriter, r *http.Request) {
-		workerID := r.PathValue("workerID")
-		log.Info("got init error", "workerID", workerID, "requestID", r.PathValue("requestID"))
-		writer := input.client.NewWriter(bridge.MessageInitError, input.prefix+"/"+workerID+"/in")
-		var buf bytes.Buffer
-		tee := io.TeeReader(r.Body, &buf)
-		io.Copy(writer, tee)
-		writer.Close()
-		w.WriteHeader(200)
-		info, ok := workers[workerID]
-		if ok {
-			fee := &FunctionErrorEvent{
+			return &lambdaWorker{
 				FunctionID: info.FunctionID,
-				WorkerID:   info.WorkerID,
+				Build:      info.Build,
+				Worker:     info.Worker,
+			}, true
+		},
+		result: func(result *lambdaResult) {
+			writer := input.client.NewWriter(result.Type, input.prefix+"/"+result.WorkerID+"/in")
+			if result.RequestID != "" {
+				writer.SetID(result.RequestID)
 			}
-			json.Unmarshal(buf.Bytes(), &fee)
-			bus.Publish(fee)
-		}
-	})
-
-	input.server.Mux.HandleFunc(`/lambda/{workerID}/2018-06-01/runtime/invocation/{requestID}/response`, func(w http.ResponseWriter, r *http.Request) {
-		workerID := r.PathValue("workerID")
-		requestID := r.PathValue("requestID")
-		log.Info("got response", "workerID", workerID, "requestID", r.PathValue("requestID"))
-		writer := input.client.NewWriter(bridge.MessageResponse, input.prefix+"/"+workerID+"/in")
-		writer.SetID(requestID)
-		var buf bytes.Buffer
-		tee := io.TeeReader(r.Body, &buf)
-		io.Copy(writer, tee)
-		writer.Close()
-		w.WriteHeader(202)
-		info, ok := workers[workerID]
-		if ok {
-			bus.Publish(&FunctionResponseEvent{
-				FunctionID: info.FunctionID,
-				WorkerID:   workerID,
-				RequestID:  requestID,
-				Output:     buf.Bytes(),
-			})
-		}
-	})
-
-	input.server.Mux.HandleFunc(`/lambda/{workerID}/2018-06-01/runtime/invocation/{requestID}/error`, func(w http.ResponseWriter, r *http.Request) {
-		workerID := r.PathValue("workerID")
-		requestID := r.PathValue("requestID")
-		log.Info("got error", "workerID", workerID, "requestID", r.PathValue("requestID"))
-		writer := input.client.NewWriter(bridge.MessageError, input.prefix+"/"+workerID+"/in")
-		writer.SetID(requestID)
-		var buf bytes.Buffer
-		tee := io.TeeReader(r.Body, &buf)
-		io.Copy(writer, tee)
-		writer.Close()
-		w.WriteHeader(202)
-		info, ok := workers[workerID]
-		if ok {
-			fee := &FunctionErrorEvent{
-				FunctionID: info.FunctionID,
-				WorkerID:   info.WorkerID,
-				RequestID:  requestID,
-			}
-			json.Unmarshal(buf.Bytes(), &fee)
-			bus.Publish(fee)
-		}
-	})
+			writer.Write(result.Body)
+			writer.Close()
+		},
+	}
+	api.register(input.server.Mux)
 
 	workerEnv := map[string][]string{}
 	builds := map[string]*runtime.BuildOutput{}
@@ -224,7 +181,7 @@ func function(ctx context.Context, input input) {
 		if !ok {
 			return false
 		}
-		worker, err := input.project.Runtime.Run(ctx, &runtime.RunInput{
+		runInput := &runtime.RunInput{
 			CfgPath:    input.project.PathConfig(),
 			Runtime:    target.Runtime,
 			Server:     server + workerID,
@@ -232,15 +189,27 @@ func function(ctx context.Context, input input) {
 			FunctionID: functionID,
 			Build:      build,
 			Env:        workerEnv[workerID],
-		})
+			Memory:     target.Memory,
+		}
+		worker, err := input.project.Runtime.Run(ctx, runInput)
 		if err != nil {
 			log.Error("failed to run worker", "error", err)
 			return false
 		}
+		if runInput.Debug != nil {
+			bus.Publish(&FunctionDebugEvent{
+				FunctionID: functionID,
+				WorkerID:   workerID,
+				Debugger:   runInput.Debug.Debugger,
+				Port:       runInput.Debug.Port,
+			})
+		}
 		info := &WorkerInfo{
 			FunctionID: functionID,
 			Worker:     worker,
 			WorkerID:   workerID,
+			Debug:      runInput.Debug != nil,
+			Build:      build,
 		}
 		go func() {
 			logs := worker.Logs()
@@ -261,10 +230,44 @@ func function(ctx context.Context, input input) {
 		return true
 	}
 
+	// fail reports an invocation that the worker couldn't finish to the bridge.
+	fail := func(info *WorkerInfo, requestID string, errorType string, message string) {
+		log.Info("failing invocation", "workerID", info.WorkerID, "requestID", requestID, "errorType", errorType)
+		writer := input.client.NewWriter(bridge.MessageError, input.prefix+"/"+info.WorkerID+"/in")
+		writer.SetID(requestID)
+		json.NewEncoder(writer).Encode(map[string]string{
+			"errorType":    errorType,
+			"errorMessage": message,
+		})
+		writer.Close()
+		bus.Publish(&FunctionErrorEvent{
+			FunctionID:   info.FunctionID,
+			WorkerID:     info.WorkerID,
+			RequestID:    requestID,
+			ErrorType:    errorType,
+			ErrorMessage: message,
+			Trace:        []string{},
+			Memory:       maxMemory(info.Worker),
+		})
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
+		case evt := <-timeoutChan:
+			if _, ok := deadlines[evt.RequestID]; !ok {
+				continue
+			}
+			delete(deadlines, evt.RequestID)
+			info, ok := workers[evt.WorkerID]
+			if !ok || pending[evt.WorkerID] != evt.RequestID {
+				continue
+			}
+			timeout := targets[evt.FunctionID].Timeout
+			fail(info, evt.RequestID, "Sandbox.Timedout", fmt.Sprintf("Task timed out after %.2f seconds", float64(timeout)))
+			// a new worker is started for the next invocation
+			go info.Worker.Stop()
 		case msg := <-input.msg:
 			switch msg.Type {
 			case bridge.MessageInit:
@@ -335,6 +338,15 @@ func function(ctx context.Context, input input) {
 
 		case info := <-workerShutdownChan:
 			log.Info("worker died", "workerID", info.WorkerID)
+			if usage, ok := info.Worker.(runtime.Usage); ok && usage.OutOfMemory() {
+				if requestID, ok := pending[info.WorkerID]; ok {
+					limit := 0
+					if target, ok := targets[info.FunctionID]; ok {
+						limit = target.Memory
+					}
+					fail(info, requestID, "Runtime.OutOfMemory", fmt.Sprintf("Runtime exited with error: ran out of memory, the limit is %d MB", limit))
+				}
+			}
 			existing, ok := workers[info.WorkerID]
 			if !ok {
 				continue
@@ -354,6 +366,17 @@ func function(ctx context.Context, input input) {
 					continue
 				}
 				info.CurrentRequestID = evt.RequestID
+				pending[evt.WorkerID] = evt.RequestID
+				// timeouts would stop a worker that's paused in a debugger
+				if target, ok := targets[evt.FunctionID]; ok && target.Timeout > 0 && !info.Debug {
+					deadlines[evt.RequestID] = time.AfterFunc(time.Duration(target.Timeout)*time.Second, func() {
+						timeoutChan <- evt
+					})
+				}
+			case *FunctionResponseEvent:
+				settle(pending, deadlines, evt.WorkerID, evt.RequestID)
+			case *FunctionErrorEvent:
+				settle(pending, deadlines, evt.WorkerID, evt.RequestID)
 			case *project.CompleteEvent:
 				if evt.Old {
 					continue
@@ -368,7 +391,7 @@ func function(ctx context.Context, input input) {
 			case *runtime.BuildInput:
 				targets[evt.FunctionID] = evt
 			case *watcher.FileChangedEvent:
-				log.Info("checking if code needs to be rebuilt", "file", evt.Path)
+				log.Info("checking if code needs to be rebuilt", "files", len(evt.Paths))
 				toBuild := map[string]bool{}
 
 				for functionID := range builds {
@@ -376,7 +399,9 @@ func function(ctx context.Context, input input) {
 					if !ok {
 						continue
 					}
-					if input.project.Runtime.ShouldRebuild(target.Runtime, target.FunctionID, evt.Path) {
+					if evt.Matches(func(path string) bool {
+						return input.project.Runtime.ShouldRebuild(target.Runtime, target.FunctionID, path)
+					}) {
 						for _, worker := range workers {
 							if worker.FunctionID == functionID {
 								log.Info("stopping", "workerID", worker.WorkerID, "functionID", worker.FunctionID)
@@ -406,6 +431,24 @@ func function(ctx context.Context, input input) {
 	}
 }
 
+// settle clears an invocation once the worker has responded to it.
+func settle(pending map[string]string, deadlines map[string]*time.Timer, workerID string, requestID string) {
+	if pending[workerID] == requestID {
+		delete(pending, workerID)
+	}
+	if timer, ok := deadlines[requestID]; ok {
+		timer.Stop()
+		delete(deadlines, requestID)
+	}
+}
+
+func maxMemory(worker runtime.Worker) int64 {
+	if usage, ok := worker.(runtime.Usage); ok {
+		return usage.MaxMemory()
+	}
+	return 0
+}
+
 func fileLogger(p *project.Project) {
 	evts := bus.Subscribe(&FunctionLogEvent{}, &FunctionInvokedEvent{}, &FunctionResponseEvent{}, &FunctionErrorEvent{}, &FunctionBuildEvent{})
 	logs := map[string]*os.File{}
@@ -444,3 +487,44 @@ func fileLogger(p *project.Project) {
 		}
 	}
 }
+
+// recorder saves every invocation so it can be replayed with `sst replay`.
+func recorder(p *project.Project) {
+	evts := bus.Subscribe(&FunctionInvokedEvent{}, &FunctionResponseEvent{}, &FunctionErrorEvent{})
+	dir := recording.Dir(p.PathConfig())
+	pending := map[string]*recording.Invocation{}
+
+	save := func(inv *recording.Invocation) {
+		delete(pending, inv.RequestID)
+		inv.Duration = time.Since(inv.Start)
+		if err := recording.Save(dir, inv); err != nil {
+			slog.Error("failed to record invocation", "requestID", inv.RequestID, "err", err)
+		}
+	}
+
+	for evt := range evts {
+		switch evt := evt.(type) {
+		case *FunctionInvokedEvent:
+			pending[evt.RequestID] = &recording.Invocation{
+				RequestID:  evt.RequestID,
+				FunctionID: evt.FunctionID,
+				Start:      time.Now(),
+				Input:      recording.Raw(evt.Input),
+			}
+		case *FunctionResponseEvent:
+			if inv, ok := pending[evt.RequestID]; ok {
+				inv.Output = recording.Raw(evt.Output)
+				save(inv)
+			}
+		case *FunctionErrorEvent:
+			if inv, ok := pending[evt.RequestID]; ok {
+				inv.Error = &recording.Error{
+					ErrorType:    evt.ErrorType,
+					ErrorMessage: evt.ErrorMessage,
+					Trace:        evt.Trace,
+				}
+				save(inv)
+			}
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package aws
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sst/sst/v3/pkg/bus"
+	"github.com/sst/sst/v3/pkg/id"
+	"github.com/sst/sst/v3/pkg/project"
+	"github.com/sst/sst/v3/pkg/runtime"
+)
+
+var ErrInvokeUnsupported = fmt.Errorf("only Lambda functions can be invoked")
+var ErrInvokeBuildFailed = fmt.Errorf("function failed to build")
+var ErrInvokeWorkerExited = fmt.Errorf("function exited before responding")
+
+type InvokeResult struct {
+	RequestID string
+	Output    []byte
+	Error     *FunctionErrorEvent
+}
+
+// Invoke builds a function and runs it once locally with the payload. It's fed
+// through the same Lambda runtime API that `sst dev` serves to its workers,
+// but without the bridge so it doesn't need AWS. The function is built from
+// the inputs of its last deploy or dev session.
+//
+// It publishes the same events as a function running in `sst dev`.
+func Invoke(ctx context.Context, p *project.Project, functionID string, payload []byte) (*InvokeResult, error) {
+	target, err := p.Runtime.LoadTarget(functionID)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.Runtime.Runtime(target.Runtime); !ok || target.Runtime == "worker" {
+		return nil, fmt.Errorf("%s: %w", target.Runtime, ErrInvokeUnsupported)
+	}
+	target.Dev = true
+	target.Invoke = true
+	timeout := 15 * time.Minute
+	if target.Timeout > 0 {
+		timeout = time.Duration(target.Timeout) * time.Second
+	}
+
+	build, err := p.Runtime.Build(ctx, target)
+	if err != nil {
+		bus.Publish(&FunctionBuildEvent{
+			FunctionID: functionID,
+			Errors:     []string{err.Error()},
+		})
+		return nil, ErrInvokeBuildFailed
+	}
+	bus.Publish(&FunctionBuildEvent{
+		FunctionID: functionID,
+		Errors:     build.Errors,
+	})
+	if len(build.Errors) > 0 {
+		return nil, ErrInvokeBuildFailed
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	defer listener.Close()
+
+	workerID := "invoke-" + id.Ascending()
+	requestID := id.Ascending()
+	result := &InvokeResult{RequestID: requestID}
+	done := make(chan struct{}, 1)
+	finish := func() {
+		select {
+		case done <- struct{}{}:
+		default:
+		}
+	}
+	// the worker is looked up by the runtime API while it's being started
+	var worker runtime.Worker
+	var workerMut sync.Mutex
+	next := make(chan struct{}, 1)
+	next <- struct{}{}
+	api := &lambdaAPI{
+		next: func(r *http.Request, _ string) (*http.Response, bool) {
+			select {
+			case <-next:
+			case <-r.Context().Done():
+				// the worker is stopped once it responds
+				return nil, false
+			}
+			header := http.Header{}
+			header.Set("Lambda-Runtime-Aws-Request-Id", requestID)
+			header.Set("Lambda-Runtime-Deadline-Ms", strconv.FormatInt(time.Now().Add(timeout).UnixMilli(), 10))
+			header.Set("Lambda-Runtime-Invoked-Function-Arn", "arn:aws:lambda:us-east-1:000000000000:function:"+functionID)
+			header.Set("Lambda-Runtime-Trace-Id", "Root=1-00000000-000000000000000000000000;Sampled=0")
+			header.Set("Content-Type", "application/json")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     header,
+				Body:       io.NopCloser(bytes.NewReader(payload)),
+			}, true
+		},
+		worker: func(requested string) (*lambdaWorker, bool) {
+			if requested != workerID {
+				return nil, false
+			}
+			workerMut.Lock()
+			defer workerMut.Unlock()
+			return &lambdaWorker{
+				FunctionID: functionID,
+				Build:      build,
+				Worker:     worker,
+			}, true
+		},
+		result: func(evt *lambdaResult) {
+			switch evt := evt.Event.(type) {
+			case *FunctionResponseEvent:
+				result.Output = evt.Output
+			case *FunctionErrorEvent:
+				result.Error = evt
+			}
+			finish()
+		},
+	}
+	mux := http.NewServeMux()
+	api.register(mux)
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	defer server.Close()
+
+	workerMut.Lock()
+	worker, err = p.Runtime.Run(ctx, &runtime.RunInput{
+		CfgPath:    p.PathConfig(),
+		Runtime:    target.Runtime,
+		Server:     listener.Addr().String() + "/lambda/" + workerID,
+		WorkerID:   workerID,
+		FunctionID: functionID,
+		Build:      build,
+		Env:        invokeEnv(p, target),
+		Memory:     target.Memory,
+	})
+	workerMut.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	defer worker.Stop()
+
+	exited := make(chan struct{})
+	go func() {
+		scanner := bufio.NewScanner(worker.Logs())
+		for scanner.Scan() {
+			bus.Publish(&FunctionLogEvent{
+				FunctionID: functionID,
+				WorkerID:   workerID,
+				RequestID:  requestID,
+				Line:       scanner.Text(),
+			})
+		}
+		close(exited)
+	}()
+
+	fail := func(errorType string, message string) (*InvokeResult, error) {
+		result.Error = &FunctionErrorEvent{
+			FunctionID:   functionID,
+			WorkerID:     workerID,
+			RequestID:    requestID,
+			ErrorType:    errorType,
+			ErrorMessage: message,
+			Trace:        []string{},
+		}
+		bus.Publish(result.Error)
+		return result, nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-done:
+		return result, nil
+	case <-time.After(timeout):
+		return fail("Sandbox.Timedout", fmt.Sprintf("Task timed out after %.2f seconds", timeout.Seconds()))
+	case <-exited:
+		// the worker might have reported an error before exiting
+		select {
+		case <-done:
+			return result, nil
+		default:
+		}
+		if usage, ok := worker.(runtime.Usage); ok && usage.OutOfMemory() {
+			return fail("Runtime.OutOfMemory", fmt.Sprintf("Runtime exited with error: ran out of memory, the limit is %d MB", target.Memory))
+		}
+		return nil, ErrInvokeWorkerExited
+	}
+}
+
+// invokeEnv passes in the linked resources the same way the deployed function
+// gets them, along with the environment of the shell so the function can use
+// the local credentials.
+func invokeEnv(p *project.Project, target *runtime.BuildInput) []string {
+	env := os.Environ()
+	app, _ := json.Marshal(map[string]string{
+		"name":  p.App().Name,
+		"stage": p.App().Stage,
+	})
+	env = append(env,
+		"SST_RESOURCE_App="+string(app),
+		"AWS_LAMBDA_FUNCTION_NAME="+target.FunctionID,
+	)
+	for name, value := range target.Links {
+		env = append(env, "SST_RESOURCE_"+name+"="+string(value))
+	}
+	slog.Info("invoke env", "functionID", target.FunctionID, "links", len(target.Links))
+	return env
+}
@@ -0,0 +1,139 @@
+package aws
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/sst/sst/v3/cmd/sst/mosaic/aws/bridge"
+	"github.com/sst/sst/v3/pkg/bus"
+	"github.com/sst/sst/v3/pkg/runtime"
+)
+
+// lambdaWorker is a worker that's being served the runtime API.
+type lambdaWorker struct {
+	FunctionID string
+	Build      *runtime.BuildOutput
+	Worker     runtime.Worker
+}
+
+// lambdaResult is what a worker posted for an invocation, or for its init when
+// there's no RequestID. Event is the FunctionResponseEvent or
+// FunctionErrorEvent that was published for it, it's nil if the worker isn't
+// known.
+type lambdaResult struct {
+	Type      bridge.MessageType
+	WorkerID  string
+	RequestID string
+	Body      []byte
+	Event     any
+}
+
+// lambdaAPI serves the Lambda runtime API to local workers, under
+// /lambda/{workerID}/2018-06-01/runtime. It's used by both `sst dev` and
+// `sst invoke`, they decide where the invocations come from and where the
+// results go while it publishes the function events.
+type lambdaAPI struct {
+	// next blocks until there's an invocation for the worker. It returns false
+	// if the worker went away first.
+	next func(r *http.Request, workerID string) (*http.Response, bool)
+	// worker looks up a running worker.
+	worker func(workerID string) (*lambdaWorker, bool)
+	// result is called with every response and error.
+	result func(result *lambdaResult)
+}
+
+func (api *lambdaAPI) register(mux *http.ServeMux) {
+	log := slog.Default().With("service", "aws.lambda")
+	prefix := "/lambda/{workerID}/2018-06-01/runtime"
+
+	mux.HandleFunc(prefix+"/invocation/next", func(w http.ResponseWriter, r *http.Request) {
+		workerID := r.PathValue("workerID")
+		log.Info("got next request", "workerID", workerID)
+		resp, ok := api.next(r, workerID)
+		if !ok {
+			log.Info("worker disconnected", "workerID", workerID)
+			return
+		}
+		defer resp.Body.Close()
+		log.Info("worker got next request", "workerID", workerID)
+		requestID := resp.Header.Get("lambda-runtime-aws-request-id")
+		for key, values := range resp.Header {
+			for _, value := range values {
+				w.Header().Add(key, value)
+			}
+		}
+		w.WriteHeader(resp.StatusCode)
+
+		var buf bytes.Buffer
+		tee := io.TeeReader(resp.Body, &buf)
+		io.Copy(w, tee)
+		if info, ok := api.worker(workerID); ok {
+			bus.Publish(&FunctionInvokedEvent{
+				FunctionID: info.FunctionID,
+				WorkerID:   workerID,
+				RequestID:  requestID,
+				Input:      buf.Bytes(),
+			})
+		}
+	})
+
+	mux.HandleFunc(prefix+"/init/error", func(w http.ResponseWriter, r *http.Request) {
+		workerID := r.PathValue("workerID")
+		log.Info("got init error", "workerID", workerID)
+		api.handle(bridge.MessageInitError, workerID, "", r)
+		w.WriteHeader(200)
+	})
+
+	mux.HandleFunc(prefix+"/invocation/{requestID}/response", func(w http.ResponseWriter, r *http.Request) {
+		workerID := r.PathValue("workerID")
+		requestID := r.PathValue("requestID")
+		log.Info("got response", "workerID", workerID, "requestID", requestID)
+		api.handle(bridge.MessageResponse, workerID, requestID, r)
+		w.WriteHeader(202)
+	})
+
+	mux.HandleFunc(prefix+"/invocation/{requestID}/error", func(w http.ResponseWriter, r *http.Request) {
+		workerID := r.PathValue("workerID")
+		requestID := r.PathValue("requestID")
+		log.Info("got error", "workerID", workerID, "requestID", requestID)
+		api.handle(bridge.MessageError, workerID, requestID, r)
+		w.WriteHeader(202)
+	})
+}
+
+// handle publishes the event for a response or error and passes it on.
+func (api *lambdaAPI) handle(typ bridge.MessageType, workerID string, requestID string, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	result := &lambdaResult{
+		Type:      typ,
+		WorkerID:  workerID,
+		RequestID: requestID,
+		Body:      body,
+	}
+	if info, ok := api.worker(workerID); ok {
+		if typ == bridge.MessageResponse {
+			result.Event = &FunctionResponseEvent{
+				FunctionID: info.FunctionID,
+				WorkerID:   workerID,
+				RequestID:  requestID,
+				Output:     body,
+				Memory:     maxMemory(info.Worker),
+			}
+		} else {
+			fee := &FunctionErrorEvent{
+				FunctionID: info.FunctionID,
+				WorkerID:   workerID,
+				RequestID:  requestID,
+				Memory:     maxMemory(info.Worker),
+			}
+			json.Unmarshal(body, &fee)
+			fee.Frames = info.Build.ResolveTrace(fee.Trace)
+			result.Event = fee
+		}
+		bus.Publish(result.Event)
+	}
+	api.result(result)
+}
@@ -0,0 +1,237 @@
+package recording
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/sst/sst/v3/pkg/project/path"
+)
+
+var ErrNotFound = fmt.Errorf("no recorded invocations found")
+
+// how many invocations are kept for each function
+const KEEP = 100
+
+// Invocation is a function invocation recorded in sst dev, with what it was
+// called with and how it finished.
+type Invocation struct {
+	RequestID  string          `json:"requestID"`
+	FunctionID string          `json:"functionID"`
+	Start      time.Time       `json:"start"`
+	Duration   time.Duration   `json:"duration"`
+	Input      json.RawMessage `json:"input"`
+	Output     json.RawMessage `json:"output,omitempty"`
+	Error      *Error          `json:"error,omitempty"`
+}
+
+type Error struct {
+	ErrorType    string   `json:"errorType"`
+	ErrorMessage string   `json:"errorMessage"`
+	Trace        []string `json:"trace,omitempty"`
+}
+
+// Query narrows down the recorded invocations. The zero value matches all of
+// them.
+type Query struct {
+	FunctionID string
+	// only invocations that failed
+	Errors bool
+	Since  time.Time
+	// the most recent ones, 0 for no limit
+	Limit int
+}
+
+// Dir is where invocations are recorded, in .sst/recordings.
+func Dir(cfgPath string) string {
+	return filepath.Join(path.ResolveWorkingDir(cfgPath), "recordings")
+}
+
+// Raw turns data that might not be JSON into something that can be stored as
+// it, like the output of a function that returns a plain string.
+func Raw(data []byte) json.RawMessage {
+	if len(data) == 0 {
+		return nil
+	}
+	if json.Valid(data) {
+		return json.RawMessage(bytes.Clone(data))
+	}
+	encoded, _ := json.Marshal(string(data))
+	return encoded
+}
+
+// Save stores an invocation and drops the oldest ones of the function past
+// KEEP. Files are named by start time so they sort in the order they ran.
+func Save(dir string, inv *Invocation) error {
+	functionDir := filepath.Join(dir, inv.FunctionID)
+	if err := os.MkdirAll(functionDir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(inv, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d-%s.json", inv.Start.UnixMilli(), inv.RequestID)
+	if err := os.WriteFile(filepath.Join(functionDir, name), data, 0644); err != nil {
+		return err
+	}
+	files, err := files(functionDir)
+	if err != nil {
+		return err
+	}
+	for len(files) > KEEP {
+		os.Remove(filepath.Join(functionDir, files[0]))
+		files = files[1:]
+	}
+	return nil
+}
+
+// Find returns the invocation with the request id.
+func Find(dir string, requestID string) (*Invocation, error) {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*", "*-"+requestID+".json"))
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("%s: %w", requestID, ErrNotFound)
+	}
+	return read(matches[0])
+}
+
+// List returns the invocations that match the query, oldest first.
+func List(dir string, query Query) ([]*Invocation, error) {
+	functions := []string{query.FunctionID}
+	if query.FunctionID == "" {
+		entries, err := os.ReadDir(dir)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		functions = []string{}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				functions = append(functions, entry.Name())
+			}
+		}
+	}
+	type item struct {
+		path  string
+		start int64
+	}
+	items := []item{}
+	for _, functionID := range functions {
+		names, err := files(filepath.Join(dir, functionID))
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			start, _ := strconv.ParseInt(strings.SplitN(name, "-", 2)[0], 10, 64)
+			if start < query.Since.UnixMilli() {
+				continue
+			}
+			items = append(items, item{filepath.Join(dir, functionID, name), start})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].start < items[j].start
+	})
+	result := []*Invocation{}
+	// newest first so the limit keeps the most recent ones
+	for i := len(items) - 1; i >= 0; i-- {
+		inv, err := read(items[i].path)
+		if err != nil {
+			continue
+		}
+		if query.Errors && inv.Error == nil {
+			continue
+		}
+		result = append(result, inv)
+		if query.Limit > 0 && len(result) == query.Limit {
+			break
+		}
+	}
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result, nil
+}
+
+// files returns the recordings in a function's directory in the order they
+// ran.
+func files(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			result = append(result, entry.Name())
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, _ := strconv.ParseInt(strings.SplitN(result[i], "-", 2)[0], 10, 64)
+		b, _ := strconv.ParseInt(strings.SplitN(result[j], "-", 2)[0], 10, 64)
+		return a < b
+	})
+	return result, nil
+}
+
+func read(path string) (*Invocation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var inv Invocation
+	if err := json.Unmarshal(data, &inv); err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// Result is what the invocation returned, its output or its error. The trace
+// is left out since it changes with the code even when the behavior doesn't.
+func (inv *Invocation) Result() json.RawMessage {
+	if inv.Error == nil {
+		return inv.Output
+	}
+	data, _ := json.Marshal(map[string]string{
+		"errorType":    inv.Error.ErrorType,
+		"errorMessage": inv.Error.ErrorMessage,
+	})
+	return data
+}
+
+// Diff compares two results of an invocation as indented JSON. It returns an
+// empty string when they're the same.
+func Diff(recorded json.RawMessage, replayed json.RawMessage) string {
+	a, b := indent(recorded), indent(replayed)
+	if a == b {
+		return ""
+	}
+	result, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(a),
+		B:        difflib.SplitLines(b),
+		FromFile: "recorded",
+		ToFile:   "replayed",
+		Context:  3,
+	})
+	return result
+}
+
+func indent(data json.RawMessage) string {
+	if len(data) == 0 {
+		return ""
+	}
+	var formatted bytes.Buffer
+	if err := json.Indent(&formatted, data, "", "  "); err != nil {
+		return string(data) + "\n"
+	}
+	return formatted.String() + "\n"
+}
@@ -0,0 +1,293 @@
+package relay
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sst/sst/v3/pkg/id"
+)
+
+var log = slog.Default().WithGroup("relay")
+
+var ErrUnauthorized = fmt.Errorf("relay token is invalid")
+var ErrSubscriptionFailed = fmt.Errorf("relay subscription failed")
+
+// message is sent both ways over the websocket. It follows the messages of
+// AppSync Events, so the relay can take its place in the bridge.
+type message struct {
+	Type    string `json:"type"`
+	ID      string `json:"id,omitempty"`
+	Channel string `json:"channel,omitempty"`
+	Event   string `json:"event,omitempty"`
+}
+
+const keepAlive = 30 * time.Second
+
+// the server sends a keepalive at least this often, so a connection that's
+// quiet for longer is gone
+const readTimeout = keepAlive * 2
+
+// Server is a websocket relay that forwards events published on a channel to
+// everyone subscribed to it. It can be run anywhere the bridge function and
+// sst dev can both reach, with `sst relay`.
+type Server struct {
+	token    string
+	upgrader websocket.Upgrader
+	mut      sync.Mutex
+	// channel -> peer -> subscription ids
+	channels map[string]map[*peer][]string
+}
+
+type peer struct {
+	conn *websocket.Conn
+	mut  sync.Mutex
+}
+
+func (p *peer) write(msg message) error {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	return p.conn.WriteJSON(msg)
+}
+
+func NewServer(token string) *Server {
+	return &Server{
+		token:    token,
+		channels: map[string]map[*peer][]string{},
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if s.token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+		http.Error(w, ErrUnauthorized.Error(), http.StatusUnauthorized)
+		return
+	}
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	p := &peer{conn: conn}
+	log.Info("peer connected", "addr", r.RemoteAddr)
+	defer func() {
+		s.remove(p)
+		conn.Close()
+		log.Info("peer disconnected", "addr", r.RemoteAddr)
+	}()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(keepAlive)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := p.write(message{Type: "ka"}); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		var msg message
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		switch msg.Type {
+		case "subscribe":
+			s.subscribe(p, msg.Channel, msg.ID)
+			p.write(message{Type: "subscribe_success", ID: msg.ID})
+		case "publish":
+			s.publish(msg.Channel, msg.Event)
+		}
+	}
+}
+
+func (s *Server) subscribe(p *peer, channel string, subscriptionID string) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	peers, ok := s.channels[channel]
+	if !ok {
+		peers = map[*peer][]string{}
+		s.channels[channel] = peers
+	}
+	peers[p] = append(peers[p], subscriptionID)
+}
+
+func (s *Server) publish(channel string, event string) {
+	s.mut.Lock()
+	type target struct {
+		peer *peer
+		id   string
+	}
+	targets := []target{}
+	for p, ids := range s.channels[channel] {
+		for _, id := range ids {
+			targets = append(targets, target{p, id})
+		}
+	}
+	s.mut.Unlock()
+	for _, target := range targets {
+		target.peer.write(message{Type: "data", ID: target.id, Event: event})
+	}
+}
+
+func (s *Server) remove(p *peer) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	for channel, peers := range s.channels {
+		delete(peers, p)
+		if len(peers) == 0 {
+			delete(s.channels, channel)
+		}
+	}
+}
+
+// Connection is a client of the relay. It reconnects when the connection
+// drops and subscribes to its channels again.
+type Connection struct {
+	url           string
+	token         string
+	conn          *websocket.Conn
+	lock          sync.Mutex
+	write         sync.Mutex
+	subscriptions map[string]*subscription
+}
+
+type subscription struct {
+	channel string
+	out     chan string
+	ready   chan struct{}
+	once    sync.Once
+}
+
+func Dial(ctx context.Context, url string, token string) (*Connection, error) {
+	result := &Connection{
+		url:           url,
+		token:         token,
+		subscriptions: map[string]*subscription{},
+	}
+	if err := result.connect(ctx); err != nil {
+		return nil, err
+	}
+	go func() {
+		<-ctx.Done()
+		result.lock.Lock()
+		defer result.lock.Unlock()
+		if result.conn != nil {
+			result.conn.Close()
+		}
+	}()
+	return result, nil
+}
+
+func (c *Connection) connect(ctx context.Context) error {
+	header := http.Header{}
+	if c.token != "" {
+		header.Set("Authorization", "Bearer "+c.token)
+	}
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, c.url, header)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+			return ErrUnauthorized
+		}
+		return err
+	}
+	c.lock.Lock()
+	c.conn = conn
+	existing := make(map[string]*subscription, len(c.subscriptions))
+	for id, sub := range c.subscriptions {
+		existing[id] = sub
+	}
+	c.lock.Unlock()
+	for subscriptionID, sub := range existing {
+		c.send(message{Type: "subscribe", ID: subscriptionID, Channel: sub.channel})
+	}
+	go c.read(ctx, conn)
+	return nil
+}
+
+func (c *Connection) read(ctx context.Context, conn *websocket.Conn) {
+	for {
+		var msg message
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+		if err := conn.ReadJSON(&msg); err != nil {
+			conn.Close()
+			if ctx.Err() != nil {
+				return
+			}
+			for {
+				log.Info("trying to reconnect", "err", err)
+				err = c.connect(ctx)
+				if err == nil || ctx.Err() != nil {
+					return
+				}
+				time.Sleep(time.Second * 3)
+			}
+		}
+		c.lock.Lock()
+		sub, ok := c.subscriptions[msg.ID]
+		c.lock.Unlock()
+		if !ok {
+			continue
+		}
+		switch msg.Type {
+		case "subscribe_success":
+			sub.once.Do(func() { close(sub.ready) })
+		case "data":
+			sub.out <- msg.Event
+		}
+	}
+}
+
+func (c *Connection) send(msg message) error {
+	c.write.Lock()
+	defer c.write.Unlock()
+	c.lock.Lock()
+	conn := c.conn
+	c.lock.Unlock()
+	return conn.WriteJSON(msg)
+}
+
+func (c *Connection) Subscribe(ctx context.Context, channel string) (chan string, error) {
+	subscriptionID := id.Ascending()
+	sub := &subscription{
+		channel: channel,
+		out:     make(chan string, 1000),
+		ready:   make(chan struct{}),
+	}
+	c.lock.Lock()
+	c.subscriptions[subscriptionID] = sub
+	c.lock.Unlock()
+	if err := c.send(message{Type: "subscribe", ID: subscriptionID, Channel: channel}); err != nil {
+		return nil, err
+	}
+	select {
+	case <-sub.ready:
+		return sub.out, nil
+	case <-time.After(time.Second * 3):
+		return nil, ErrSubscriptionFailed
+	}
+}
+
+func (c *Connection) Publish(ctx context.Context, channel string, event interface{}) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return c.send(message{Type: "publish", Channel: channel, Event: string(data)})
+}
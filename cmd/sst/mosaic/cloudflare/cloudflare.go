@@ -1,6 +1,7 @@
 package cloudflare
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"log/slog"
@@ -26,6 +27,8 @@ type WorkerBuildEvent struct {
 
 type WorkerUpdatedEvent struct {
 	WorkerID string
+	// set when the worker is running locally
+	URL string
 }
 
 type WorkerInvokedEvent struct {
@@ -48,6 +51,33 @@ func Start(ctx context.Context, proj *project.Project, args map[string]interface
 		Account    *cloudflare.ResourceContainer
 	}
 	tails := map[string]tailRef{}
+	locals := map[string]*localWorker{}
+
+	// runLocal (re)starts a worker that runs locally instead of updating the
+	// deployed script
+	runLocal := func(functionID string, output *runtime.BuildOutput) {
+		local, ok := locals[functionID]
+		if !ok {
+			var err error
+			local, err = startLocal(functionID)
+			if err != nil {
+				slog.Error("error starting local worker", "error", err)
+				return
+			}
+			locals[functionID] = local
+		}
+		if err := local.run(ctx, proj, output); err != nil {
+			bus.Publish(&WorkerBuildEvent{
+				WorkerID: functionID,
+				Errors:   []string{err.Error()},
+			})
+			return
+		}
+		bus.Publish(&WorkerUpdatedEvent{
+			WorkerID: functionID,
+			URL:      local.url,
+		})
+	}
 
 exit:
 	for {
@@ -61,9 +91,30 @@ exit:
 				if target.Runtime != "worker" {
 					continue
 				}
+				previous := targets[target.FunctionID]
 				targets[target.FunctionID] = target
 				var properties worker.Properties
 				json.Unmarshal(target.Properties, &properties)
+				if properties.Local {
+					// restart if the bindings changed in the last deploy
+					if _, ok := builds[target.FunctionID]; ok && previous != nil && bytes.Equal(previous.Properties, target.Properties) {
+						continue
+					}
+					output, err := proj.Runtime.Build(ctx, target)
+					if err != nil {
+						continue
+					}
+					bus.Publish(&WorkerBuildEvent{
+						WorkerID: target.FunctionID,
+						Errors:   output.Errors,
+					})
+					if len(output.Errors) > 0 {
+						continue
+					}
+					builds[target.FunctionID] = output
+					runLocal(target.FunctionID, output)
+					continue
+				}
 				account := cloudflare.AccountIdentifier(properties.AccountID)
 				if _, ok := tails[target.FunctionID]; !ok {
 					slog.Info("cloudflare tail creating", "functionID", target.FunctionID)
@@ -110,7 +161,9 @@ exit:
 				builds[target.FunctionID] = output
 			case *watcher.FileChangedEvent:
 				for workerID, target := range targets {
-					if proj.Runtime.ShouldRebuild(target.Runtime, workerID, evt.Path) {
+					if evt.Matches(func(path string) bool {
+						return proj.Runtime.ShouldRebuild(target.Runtime, workerID, path)
+					}) {
 						output, err := proj.Runtime.Build(ctx, target)
 						if err != nil {
 							continue
@@ -122,6 +175,12 @@ exit:
 						builds[target.FunctionID] = output
 						var properties worker.Properties
 						json.Unmarshal(target.Properties, &properties)
+						if properties.Local {
+							if len(output.Errors) == 0 {
+								runLocal(target.FunctionID, output)
+							}
+							continue
+						}
 						account := cloudflare.AccountIdentifier(properties.AccountID)
 
 						content, err := os.ReadFile(filepath.Join(output.Out, output.Handler))
@@ -153,6 +212,9 @@ exit:
 	for _, tail := range tails {
 		api.DeleteWorkersTail(ctx, tail.Account, tail.ScriptName, tail.ID)
 	}
+	for _, local := range locals {
+		local.stop()
+	}
 
 	return nil
 }
@@ -0,0 +1,177 @@
+package cloudflare
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sst/sst/v3/pkg/bus"
+	"github.com/sst/sst/v3/pkg/project"
+	"github.com/sst/sst/v3/pkg/runtime"
+)
+
+// WorkerLogEvent is a line logged by a local worker that can't be tied to a
+// single request.
+type WorkerLogEvent struct {
+	WorkerID string
+	Line     string
+}
+
+// localWorker runs a worker locally and proxies requests to it, so they show
+// up as WorkerInvokedEvent the same way the ones from a tail do. The proxy
+// keeps its port when the worker is restarted.
+type localWorker struct {
+	functionID string
+	url        string
+	address    string
+	proxy      *httputil.ReverseProxy
+	server     *http.Server
+	worker     runtime.Worker
+	mut        sync.Mutex
+	requests   []*TailEvent
+}
+
+func startLocal(functionID string) (*localWorker, error) {
+	listener, err := listen(8787)
+	if err != nil {
+		return nil, err
+	}
+	internal, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+	address := internal.Addr().String()
+	internal.Close()
+	target, _ := url.Parse("http://" + address)
+	result := &localWorker{
+		functionID: functionID,
+		url:        "http://" + listener.Addr().String(),
+		address:    address,
+		proxy:      httputil.NewSingleHostReverseProxy(target),
+	}
+	result.server = &http.Server{Handler: result}
+	go result.server.Serve(listener)
+	slog.Info("local worker listening", "functionID", functionID, "url", result.url, "address", address)
+	return result, nil
+}
+
+func listen(start int) (net.Listener, error) {
+	for port := start; port < start+100; port++ {
+		listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+		if err == nil {
+			return listener, nil
+		}
+	}
+	return net.Listen("tcp", "127.0.0.1:0")
+}
+
+func (l *localWorker) run(ctx context.Context, proj *project.Project, build *runtime.BuildOutput) error {
+	if l.worker != nil {
+		l.worker.Stop()
+	}
+	worker, err := proj.Runtime.Run(ctx, &runtime.RunInput{
+		CfgPath:    proj.PathConfig(),
+		Runtime:    "worker",
+		Server:     l.address,
+		FunctionID: l.functionID,
+		WorkerID:   l.functionID,
+		Build:      build,
+	})
+	if err != nil {
+		return err
+	}
+	l.worker = worker
+	go func() {
+		scanner := bufio.NewScanner(worker.Logs())
+		for scanner.Scan() {
+			l.log(scanner.Text())
+		}
+	}()
+	return nil
+}
+
+func (l *localWorker) log(line string) {
+	// skip the output of wrangler itself
+	if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "[wrangler:") {
+		return
+	}
+	l.mut.Lock()
+	// lines can't be matched to a request, so they're only attached to one when
+	// it's the only one in flight, and logged on their own otherwise
+	if len(l.requests) == 1 {
+		request := l.requests[0]
+		request.Logs = append(request.Logs, TailLog{
+			Level:     "log",
+			Message:   []interface{}{line},
+			Timestamp: time.Now().UnixMilli(),
+		})
+		l.mut.Unlock()
+		return
+	}
+	l.mut.Unlock()
+	bus.Publish(&WorkerLogEvent{
+		WorkerID: l.functionID,
+		Line:     line,
+	})
+}
+
+func (l *localWorker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	evt := &TailEvent{
+		EventTimestamp: time.Now().UnixMilli(),
+		Outcome:        "ok",
+	}
+	evt.Event.Request.Method = r.Method
+	evt.Event.Request.URL = "http://" + r.Host + r.URL.RequestURI()
+	l.mut.Lock()
+	l.requests = append(l.requests, evt)
+	l.mut.Unlock()
+
+	writer := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+	l.proxy.ServeHTTP(writer, r)
+
+	l.mut.Lock()
+	l.requests = slices.DeleteFunc(l.requests, func(item *TailEvent) bool {
+		return item == evt
+	})
+	l.mut.Unlock()
+	evt.Event.Response.Status = writer.status
+	if writer.status >= 500 {
+		evt.Outcome = "exception"
+	}
+	bus.Publish(&WorkerInvokedEvent{
+		WorkerID:  l.functionID,
+		TailEvent: evt,
+	})
+}
+
+func (l *localWorker) stop() {
+	if l.worker != nil {
+		l.worker.Stop()
+	}
+	l.server.Close()
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets the proxy flush and hijack the connection for websockets
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -84,16 +84,18 @@ type TailEvent struct {
 			Status int `json:"status"`
 		} `json:"response"`
 	} `json:"event"`
-	EventTimestamp int64 `json:"eventTimestamp"`
-	Exceptions     []any `json:"exceptions"`
-	Logs           []struct {
-		Level     string        `json:"level"`
-		Message   []interface{} `json:"message"`
-		Timestamp int64         `json:"timestamp"`
-	} `json:"logs"`
-	Outcome       string `json:"outcome"`
-	ScriptName    string `json:"scriptName"`
-	ScriptVersion struct {
+	EventTimestamp int64     `json:"eventTimestamp"`
+	Exceptions     []any     `json:"exceptions"`
+	Logs           []TailLog `json:"logs"`
+	Outcome        string    `json:"outcome"`
+	ScriptName     string    `json:"scriptName"`
+	ScriptVersion  struct {
 		ID string `json:"id"`
 	} `json:"scriptVersion"`
 }
+
+type TailLog struct {
+	Level     string        `json:"level"`
+	Message   []interface{} `json:"message"`
+	Timestamp int64         `json:"timestamp"`
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"log/slog"
 	"reflect"
+	"time"
 
 	"github.com/sst/sst/v3/cmd/sst/mosaic/errors"
 	"github.com/sst/sst/v3/cmd/sst/mosaic/watcher"
 	"github.com/sst/sst/v3/internal/util"
 	"github.com/sst/sst/v3/pkg/bus"
+	"github.com/sst/sst/v3/pkg/flag"
 	"github.com/sst/sst/v3/pkg/project"
 	"github.com/sst/sst/v3/pkg/server"
 )
@@ -18,18 +20,139 @@ type DeployFailedEvent struct {
 	Error string
 }
 
+// DeployQueuedEvent is published when a change comes in that needs a deploy.
+// Running is set when it waits for the deploy in progress to finish.
+type DeployQueuedEvent struct {
+	Paths   []string
+	Running bool
+}
+
+type DeployStartedEvent struct {
+	ID int
+}
+
+// DeploySupersededEvent is published when a deploy in progress is cancelled
+// because the config changed again.
+type DeploySupersededEvent struct {
+	ID int
+}
+
+type DeployFinishedEvent struct {
+	ID         int
+	Error      string
+	Superseded bool
+	Duration   time.Duration
+}
+
+// how long file changes have to settle before they're deployed
+const DEBOUNCE = 300 * time.Millisecond
+
+type deploy struct {
+	id         int
+	ctx        context.Context
+	cancel     context.CancelCauseFunc
+	started    time.Time
+	superseded bool
+}
+
+// Start deploys the app when it's requested or its config changes. Changes that
+// come in during a deploy are collapsed into a single one after it, and with
+// SST_DEV_SUPERSEDE they cancel it instead.
 func Start(ctx context.Context, p *project.Project, server *server.Server) error {
 	log := slog.Default().With("service", "deployer")
 	log.Info("starting")
 	defer log.Info("done")
 	watchedFiles := make(map[string]bool)
-	events := bus.Subscribe(ctx, &watcher.FileChangedEvent{}, &DeployRequestedEvent{}, &project.BuildSuccessEvent{})
+	events := bus.Subscribe(&watcher.FileChangedEvent{}, &DeployRequestedEvent{}, &project.BuildSuccessEvent{})
 	lastBuildHash := ""
+
+	var running *deploy
+	var debounce <-chan time.Time
+	// whether another deploy is needed once the one running is done
+	queued := false
+	done := make(chan error, 1)
+	count := 0
+
+	start := func() {
+		count++
+		runCtx, cancel := context.WithCancelCause(ctx)
+		running = &deploy{
+			id:      count,
+			ctx:     runCtx,
+			cancel:  cancel,
+			started: time.Now(),
+		}
+		queued = false
+		debounce = nil
+		log.Info("deploying", "id", count)
+		bus.Publish(&DeployStartedEvent{ID: count})
+		input := &project.StackInput{
+			Command:   "deploy",
+			Dev:       true,
+			ServerURL: server.URL(),
+			SkipHash:  lastBuildHash,
+		}
+		go func() {
+			done <- p.Run(runCtx, input)
+		}()
+	}
+
+	queue := func(paths []string, wait bool) {
+		queued = true
+		bus.Publish(&DeployQueuedEvent{Paths: paths, Running: running != nil})
+		if running != nil {
+			return
+		}
+		if !wait {
+			start()
+			return
+		}
+		debounce = time.After(DEBOUNCE)
+	}
+
 	for {
 		log.Info("waiting for trigger")
 		select {
 		case <-ctx.Done():
+			// let the deploy wind down so it can release the lock
+			if running != nil {
+				<-done
+			}
 			return nil
+
+		case <-debounce:
+			debounce = nil
+			if queued && running == nil {
+				start()
+			}
+
+		case err := <-done:
+			superseded := context.Cause(running.ctx) == project.ErrSuperseded
+			running.cancel(nil)
+			finished := &DeployFinishedEvent{
+				ID:         running.id,
+				Superseded: superseded,
+				Duration:   time.Since(running.started),
+			}
+			if superseded {
+				// the build it skips against was never deployed
+				lastBuildHash = ""
+			}
+			if err != nil && !superseded {
+				log.Error("stack deploy error", "error", err)
+				finished.Error = err.Error()
+				transformed := errors.Transform(err)
+				if _, ok := transformed.(*util.ReadableError); ok {
+					bus.Publish(&DeployFailedEvent{Error: transformed.Error()})
+				}
+			}
+			log.Info("deploy finished", "id", running.id, "superseded", superseded)
+			bus.Publish(finished)
+			running = nil
+			if queued {
+				start()
+			}
+
 		case evt := <-events:
 			switch evt := evt.(type) {
 			case *project.BuildSuccessEvent:
@@ -38,25 +161,26 @@ func Start(ctx context.Context, p *project.Project, server *server.Server) error
 				for _, file := range evt.Files {
 					watchedFiles[file] = true
 				}
-			case *watcher.FileChangedEvent, *DeployRequestedEvent:
-				if evt, ok := evt.(*watcher.FileChangedEvent); !ok || watchedFiles[evt.Path] {
-					log.Info("deploying")
-					err := p.Run(ctx, &project.StackInput{
-						Command:    "deploy",
-						Dev:        true,
-						ServerPort: server.Port,
-						SkipHash:   lastBuildHash,
-					})
-					if err != nil {
-						log.Error("stack deploy error", "error", err)
-						transformed := errors.Transform(err)
-						if _, ok := transformed.(*util.ReadableError); ok {
-							bus.Publish(&DeployFailedEvent{Error: transformed.Error()})
-						}
+			case *DeployRequestedEvent:
+				queue(nil, false)
+			case *watcher.FileChangedEvent:
+				paths := []string{}
+				for _, path := range evt.Paths {
+					if watchedFiles[path] {
+						paths = append(paths, path)
 					}
 				}
+				if len(paths) == 0 {
+					continue
+				}
+				queue(paths, true)
+				if flag.SST_DEV_SUPERSEDE && running != nil && !running.superseded {
+					log.Info("superseding deploy", "id", running.id)
+					running.superseded = true
+					bus.Publish(&DeploySupersededEvent{ID: running.id})
+					running.cancel(project.ErrSuperseded)
+				}
 			}
-			continue
 		}
 	}
 }
@@ -0,0 +1,363 @@
+package dev
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sst/sst/v3/cmd/sst/mosaic/aws"
+	"github.com/sst/sst/v3/pkg/bus"
+	"github.com/sst/sst/v3/pkg/project"
+	"github.com/sst/sst/v3/pkg/runtime"
+	"github.com/sst/sst/v3/pkg/server"
+)
+
+// API_PREFIX is where the query api is served. The version changes when a
+// response changes in a way that breaks clients.
+const API_PREFIX = "/api/v1"
+
+// ProcessEvent is published by the supervisor when a dev process changes
+// status.
+type ProcessEvent struct {
+	Name   string
+	Status string
+}
+
+const (
+	ProcessStopped = "stopped"
+	// waiting for the processes it depends on to be ready
+	ProcessWaiting = "waiting"
+	// started but its readiness checks haven't passed yet
+	ProcessStarting = "starting"
+	ProcessRunning  = "running"
+	// its readiness checks didn't pass in time
+	ProcessUnhealthy  = "unhealthy"
+	ProcessRestarting = "restarting"
+	ProcessExited     = "exited"
+)
+
+// Page is a response of a list endpoint. Next is passed back as the cursor to
+// get the page after it, and is empty on the last one.
+type Page[T any] struct {
+	Data []T    `json:"data"`
+	Next string `json:"next,omitempty"`
+}
+
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+// Paginate returns the page of items asked for with the limit and cursor
+// query params.
+func Paginate[T any](r *http.Request, items []T) (*Page[T], error) {
+	limit := defaultLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			return nil, errInvalidParam("limit")
+		}
+		limit = min(parsed, maxLimit)
+	}
+	offset := 0
+	if value := r.URL.Query().Get("cursor"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return nil, errInvalidParam("cursor")
+		}
+		offset = min(parsed, len(items))
+	}
+	end := min(offset+limit, len(items))
+	result := &Page[T]{Data: items[offset:end]}
+	if end < len(items) {
+		result.Next = strconv.Itoa(end)
+	}
+	return result, nil
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+type errInvalidParam string
+
+func (e errInvalidParam) Error() string {
+	return "invalid value for " + string(e)
+}
+
+func WriteJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func WriteError(w http.ResponseWriter, status int, err error) {
+	WriteJSON(w, status, apiError{Error: err.Error()})
+}
+
+// WritePage paginates the items and writes the page.
+func WritePage[T any](w http.ResponseWriter, r *http.Request, items []T) {
+	page, err := Paginate(r, items)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, page)
+}
+
+type Resource struct {
+	URN     string                 `json:"urn"`
+	Type    string                 `json:"type"`
+	Name    string                 `json:"name"`
+	Parent  string                 `json:"parent,omitempty"`
+	Outputs map[string]interface{} `json:"outputs,omitempty"`
+}
+
+type Function struct {
+	ID      string    `json:"id"`
+	Runtime string    `json:"runtime"`
+	Handler string    `json:"handler"`
+	Status  string    `json:"status"`
+	Errors  []string  `json:"errors,omitempty"`
+	Updated time.Time `json:"updated"`
+}
+
+const (
+	FunctionPending = "pending"
+	FunctionBuilt   = "built"
+	FunctionFailed  = "failed"
+)
+
+type Process struct {
+	Name      string `json:"name"`
+	Title     string `json:"title"`
+	Command   string `json:"command"`
+	Directory string `json:"directory"`
+	Autostart bool   `json:"autostart"`
+	Status    string `json:"status"`
+}
+
+type LogFile struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// state is what the query api serves, kept up to date from the bus.
+type state struct {
+	mut       sync.Mutex
+	complete  *project.CompleteEvent
+	functions map[string]*Function
+	processes map[string]string
+}
+
+func (s *state) run(ctx context.Context) {
+	evts := bus.Subscribe(&project.CompleteEvent{}, &runtime.BuildInput{}, &aws.FunctionBuildEvent{}, &ProcessEvent{})
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case unknown := <-evts:
+			s.mut.Lock()
+			switch evt := unknown.(type) {
+			case *project.CompleteEvent:
+				s.complete = evt
+			case *runtime.BuildInput:
+				s.functions[evt.FunctionID] = &Function{
+					ID:      evt.FunctionID,
+					Runtime: evt.Runtime,
+					Handler: evt.Handler,
+					Status:  FunctionPending,
+					Updated: time.Now(),
+				}
+			case *aws.FunctionBuildEvent:
+				fn, ok := s.functions[evt.FunctionID]
+				if !ok {
+					fn = &Function{ID: evt.FunctionID}
+					s.functions[evt.FunctionID] = fn
+				}
+				fn.Status = FunctionBuilt
+				fn.Errors = evt.Errors
+				if len(evt.Errors) > 0 {
+					fn.Status = FunctionFailed
+				}
+				fn.Updated = time.Now()
+			case *ProcessEvent:
+				s.processes[evt.Name] = evt.Status
+			}
+			s.mut.Unlock()
+		}
+	}
+}
+
+func serveAPI(ctx context.Context, p *project.Project, server *server.Server) {
+	s := &state{
+		functions: map[string]*Function{},
+		processes: map[string]string{},
+	}
+	go s.run(ctx)
+
+	server.Mux.HandleFunc("GET "+API_PREFIX+"/resources", func(w http.ResponseWriter, r *http.Request) {
+		typ := r.URL.Query().Get("type")
+		name := r.URL.Query().Get("name")
+		result := []Resource{}
+		s.mut.Lock()
+		if s.complete != nil {
+			for _, resource := range s.complete.Resources {
+				if typ != "" && string(resource.Type) != typ {
+					continue
+				}
+				if name != "" && resource.URN.Name() != name {
+					continue
+				}
+				result = append(result, Resource{
+					URN:     string(resource.URN),
+					Type:    string(resource.Type),
+					Name:    resource.URN.Name(),
+					Parent:  string(resource.Parent),
+					Outputs: resource.Outputs,
+				})
+			}
+		}
+		s.mut.Unlock()
+		WritePage(w, r, result)
+	})
+
+	server.Mux.HandleFunc("GET "+API_PREFIX+"/outputs", func(w http.ResponseWriter, r *http.Request) {
+		s.mut.Lock()
+		outputs := map[string]interface{}{}
+		if s.complete != nil && s.complete.Outputs != nil {
+			outputs = s.complete.Outputs
+		}
+		s.mut.Unlock()
+		WriteJSON(w, http.StatusOK, map[string]interface{}{"data": outputs})
+	})
+
+	server.Mux.HandleFunc("GET "+API_PREFIX+"/functions", func(w http.ResponseWriter, r *http.Request) {
+		status := r.URL.Query().Get("status")
+		result := []Function{}
+		s.mut.Lock()
+		for _, fn := range s.functions {
+			if status != "" && fn.Status != status {
+				continue
+			}
+			result = append(result, *fn)
+		}
+		s.mut.Unlock()
+		sort.Slice(result, func(i, j int) bool {
+			return result[i].ID < result[j].ID
+		})
+		WritePage(w, r, result)
+	})
+
+	server.Mux.HandleFunc("GET "+API_PREFIX+"/processes", func(w http.ResponseWriter, r *http.Request) {
+		status := r.URL.Query().Get("status")
+		result := []Process{}
+		s.mut.Lock()
+		if s.complete != nil {
+			for _, d := range s.complete.Devs {
+				process := Process{
+					Name:      d.Name,
+					Title:     d.Title,
+					Command:   d.Command,
+					Directory: d.Directory,
+					Autostart: d.Autostart,
+					Status:    s.processes[d.Name],
+				}
+				if process.Status == "" {
+					process.Status = ProcessStopped
+				}
+				if status != "" && process.Status != status {
+					continue
+				}
+				result = append(result, process)
+			}
+		}
+		s.mut.Unlock()
+		sort.Slice(result, func(i, j int) bool {
+			return result[i].Name < result[j].Name
+		})
+		WritePage(w, r, result)
+	})
+
+	logs := p.PathLog("")
+	server.Mux.HandleFunc("GET "+API_PREFIX+"/logs", func(w http.ResponseWriter, r *http.Request) {
+		prefix := r.URL.Query().Get("prefix")
+		result := []LogFile{}
+		filepath.WalkDir(logs, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			name, _ := filepath.Rel(logs, path)
+			name = filepath.ToSlash(name)
+			if !strings.HasPrefix(name, prefix) {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			result = append(result, LogFile{
+				Name:     name,
+				Size:     info.Size(),
+				Modified: info.ModTime(),
+			})
+			return nil
+		})
+		sort.Slice(result, func(i, j int) bool {
+			return result[i].Modified.After(result[j].Modified)
+		})
+		WritePage(w, r, result)
+	})
+
+	server.Mux.HandleFunc("GET "+API_PREFIX+"/logs/{name...}", func(w http.ResponseWriter, r *http.Request) {
+		lines := 100
+		if value := r.URL.Query().Get("lines"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 {
+				WriteError(w, http.StatusBadRequest, errInvalidParam("lines"))
+				return
+			}
+			lines = min(parsed, 10000)
+		}
+		path := filepath.Join(logs, filepath.FromSlash(r.PathValue("name")))
+		if rel, err := filepath.Rel(logs, path); err != nil || strings.HasPrefix(rel, "..") {
+			WriteError(w, http.StatusNotFound, os.ErrNotExist)
+			return
+		}
+		tail, err := tail(path, lines)
+		if err != nil {
+			WriteError(w, http.StatusNotFound, err)
+			return
+		}
+		WriteJSON(w, http.StatusOK, map[string]interface{}{"data": tail})
+	})
+}
+
+// tail returns the last lines of a file.
+func tail(path string, lines int) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	result := make([]string, 0, lines)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(result) == lines {
+			result = result[1:]
+		}
+		result = append(result, scanner.Text())
+	}
+	return result, scanner.Err()
+}
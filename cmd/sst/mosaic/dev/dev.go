@@ -69,6 +69,8 @@ func Start(ctx context.Context, p *project.Project, server *server.Server) error
 		}
 	})
 
+	serveAPI(ctx, p, server)
+
 	server.Mux.HandleFunc(("/api/deploy"), func(w http.ResponseWriter, r *http.Request) {
 		slog.Info("deploy requested")
 		bus.Publish(&deployer.DeployRequestedEvent{})
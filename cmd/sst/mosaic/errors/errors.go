@@ -7,9 +7,14 @@ import (
 
 	"github.com/sst/sst/v3/cmd/sst/mosaic/aws"
 	"github.com/sst/sst/v3/cmd/sst/mosaic/aws/appsync"
+	"github.com/sst/sst/v3/cmd/sst/mosaic/aws/fixture"
+	"github.com/sst/sst/v3/cmd/sst/mosaic/aws/recording"
+	"github.com/sst/sst/v3/cmd/sst/mosaic/aws/relay"
 	"github.com/sst/sst/v3/internal/util"
+	"github.com/sst/sst/v3/pkg/global"
 	"github.com/sst/sst/v3/pkg/project"
 	"github.com/sst/sst/v3/pkg/project/provider"
+	"github.com/sst/sst/v3/pkg/runtime"
 	"github.com/sst/sst/v3/pkg/server"
 )
 
@@ -35,6 +40,21 @@ var transformers = []ErrorTransformer{
 	exact(project.ErrProtectedStage, "Cannot remove protected stage. To remove a protected stage edit your sst.config.ts and remove the `protect` property."),
 	exact(provider.ErrLockNotFound, "This app / stage is not locked"),
 	exact(aws.ErrAppsyncNotReady, "SST creates an appsync event api to power live lambda. After 10 seconds of waiting this cli could not connect to it."),
+	exact(relay.ErrUnauthorized, "The relay rejected the token. Check that `SST_RELAY_TOKEN` matches the one `sst relay` was started with."),
+	exact(relay.ErrSubscriptionFailed, "Could not subscribe to the relay. Check that `SST_RELAY_URL` points to a running `sst relay`."),
+	exact(aws.ErrInvokeBuildFailed, "The function failed to build."),
+	exact(aws.ErrInvokeWorkerExited, "The function exited before it responded. Check the logs above for what went wrong."),
+	hinted(aws.ErrInvokeUnsupported, "Only Lambda functions can be invoked with `sst invoke`. Use `sst dev` to run workers locally."),
+	hinted(runtime.ErrFunctionNotFound, "The function has not been built on this machine yet. Run `sst deploy` or `sst dev` first, and check that the name matches the function component."),
+	exact(fixture.ErrInvalidName, "Fixture names can only contain letters, numbers, dots, hyphens, and underscores."),
+	hinted(recording.ErrNotFound, "Invocations are recorded while `sst dev` is running. Check the request id or the name of the function."),
+	hinted(fixture.ErrUnknownFixture, "Run `sst fixture list` to see the built-in events and the fixtures you've saved."),
+	hinted(fixture.ErrUnknownParam, "Run `sst fixture list` to see the params each built-in event takes."),
+	hinted(fixture.ErrInvalidParam, "Params are passed in as a query string, like `method=POST&path=/users`."),
+	hinted(project.ErrConfigNotFound, "Could not find the config file passed in with --config."),
+	hinted(global.ErrOfflineArtifactMissing, "The offline bundle is missing something this install needs. Create a new bundle with `sst install --bundle` using the same config and SST version."),
+	hinted(project.ErrTargetNotFound, "Check the selectors passed in to --target or --exclude. They can be resource names, globs like `Api*`, `type=<type>`, or URNs."),
+	hinted(project.ErrIntegrityMismatch, "A provider installed from the offline bundle does not match its integrity hash. The bundle might be corrupted or tampered with."),
 	match(func(err *project.ErrProviderVersionTooLow) string {
 		return fmt.Sprintf("You specified version %s of the \"%s\" provider. SST needs %s or higher.", err.Version, err.Name, err.Needed)
 	}),
@@ -73,6 +93,15 @@ func match[T error](transformer func(T) string) ErrorTransformer {
 	}
 }
 
+func hinted(compare error, msg string) ErrorTransformer {
+	return func(err error) (bool, error) {
+		if errors.Is(err, compare) {
+			return true, util.NewHintedError(err, msg)
+		}
+		return false, nil
+	}
+}
+
 func exact(compare error, msg string) ErrorTransformer {
 	return func(err error) (bool, error) {
 		if errors.Is(err, compare) {
@@ -6,37 +6,45 @@ import (
 	"fmt"
 	"io"
 	"os/exec"
+	"slices"
+	"sync"
 	"syscall"
 
+	"github.com/sst/sst/v3/cmd/sst/mosaic/dev"
+	"github.com/sst/sst/v3/cmd/sst/mosaic/supervisor"
 	"github.com/sst/sst/v3/pkg/process"
+	"github.com/sst/sst/v3/pkg/project"
 )
 
 type Monoplexer struct {
-	processes map[string]*Process
-	lines     chan Line
+	mut        sync.Mutex
+	processes  map[string]*Process
+	lines      chan Line
+	supervisor *supervisor.Supervisor
 }
 
 type Line struct {
 	process string
 	line    string
+	// set when the command exited instead of printing a line
+	exited *exec.Cmd
+	err    error
 }
 
 type Process struct {
-	name  string
-	title string
-	cmd   *exec.Cmd
-	dir   string
+	name    string
+	title   string
+	command []string
+	cmd     *exec.Cmd
+	dir     string
+	// started, restarted and waited on by the supervisor
+	supervised bool
 }
 
 func (p *Process) IsDifferent(title string, command []string, directory string) bool {
-	if len(command) != len(p.cmd.Args) {
+	if !slices.Equal(command, p.command) {
 		return true
 	}
-	for i := range command {
-		if command[i] != p.cmd.Args[i] {
-			return true
-		}
-	}
 	if title != p.title {
 		return true
 	}
@@ -46,38 +54,85 @@ func (p *Process) IsDifferent(title string, command []string, directory string)
 	return false
 }
 
-func New() *Monoplexer {
-	return &Monoplexer{
+func New(ctx context.Context) *Monoplexer {
+	result := &Monoplexer{
 		processes: map[string]*Process{},
 		lines:     make(chan Line),
 	}
+	result.supervisor = supervisor.New(ctx, result.run, nil)
+	return result
 }
 
 func (m *Monoplexer) AddProcess(name string, command []string, directory string, title string) {
+	if added, _ := m.add(name, command, directory, title, false); added {
+		m.run(name)
+	}
+}
+
+// AddDev adds a dev process that's started, restarted and waited on by the
+// supervisor, following its settings.
+func (m *Monoplexer) AddDev(d project.Dev, command []string, directory string, title string) {
+	_, replaced := m.add(d.Name, command, directory, title, true)
+	m.supervisor.Add(d)
+	if replaced {
+		m.run(d.Name)
+	}
+}
+
+// add registers a process and kills the one it replaces if it changed. It
+// reports whether it was added and whether a running process was replaced.
+func (m *Monoplexer) add(name string, command []string, directory string, title string, supervised bool) (bool, bool) {
+	m.mut.Lock()
 	exists, ok := m.processes[name]
-	if ok {
-		if !exists.IsDifferent(title, command, directory) {
-			return
-		}
-		m.lines <- Line{
-			line:    "dev config changed, restarting...",
-			process: name,
-		}
-		process.Kill(exists.cmd.Process)
-		delete(m.processes, name)
+	if ok && !exists.IsDifferent(title, command, directory) {
+		m.mut.Unlock()
+		return false, false
+	}
+	m.processes[name] = &Process{
+		name:       name,
+		title:      title,
+		command:    command,
+		dir:        directory,
+		supervised: supervised,
+	}
+	m.mut.Unlock()
+	if !ok || exists.cmd == nil {
+		return true, false
+	}
+	m.lines <- Line{
+		line:    "dev config changed, restarting...",
+		process: name,
 	}
+	if supervised {
+		m.supervisor.Stop(name)
+	}
+	process.Kill(exists.cmd.Process)
+	return true, true
+}
 
+func (m *Monoplexer) run(name string) {
+	m.mut.Lock()
+	match, ok := m.processes[name]
+	if !ok {
+		m.mut.Unlock()
+		return
+	}
 	r, w := io.Pipe()
-	cmd := process.Command(command[0], command[1:]...)
+	cmd := process.Command(match.command[0], match.command[1:]...)
 	cmd.SysProcAttr = &syscall.SysProcAttr{
 		Setpgid: true,
 		Pgid:    0,
 	}
 	cmd.Stdout = w
 	cmd.Stderr = w
-	if directory != "" {
-		cmd.Dir = directory
+	if match.dir != "" {
+		cmd.Dir = match.dir
 	}
+	err := cmd.Start()
+	match.cmd = cmd
+	supervised := match.supervised
+	m.mut.Unlock()
+
 	go func() {
 		// read r line by line
 		scanner := bufio.NewScanner(r)
@@ -88,23 +143,54 @@ func (m *Monoplexer) AddProcess(name string, command []string, directory string,
 			}
 		}
 	}()
-	cmd.Start()
-	m.processes[name] = &Process{
-		name:  name,
-		title: title,
-		cmd:   cmd,
-		dir:   directory,
+	if err != nil {
+		w.Close()
+		if supervised {
+			m.supervisor.Exited(name, true)
+		}
+		return
+	}
+	if supervised {
+		m.supervisor.Started(name)
 	}
+	go func() {
+		err := cmd.Wait()
+		w.Close()
+		m.lines <- Line{process: name, exited: cmd, err: err}
+	}()
 }
+
 func (m *Monoplexer) Start(ctx context.Context) error {
 	for {
 		select {
 		case line := <-m.lines:
+			m.mut.Lock()
 			match, ok := m.processes[line.process]
+			var title string
+			var supervised, current bool
+			if ok {
+				title = match.title
+				supervised = match.supervised
+				current = line.exited == match.cmd
+			}
+			m.mut.Unlock()
 			if !ok {
 				continue
 			}
-			fmt.Println("["+match.title+"]", line.line)
+			if line.exited != nil {
+				// a restarted process is replaced before the old one exits
+				if current && supervised {
+					m.supervisor.Exited(line.process, line.err != nil)
+					if m.supervisor.Status(line.process) == dev.ProcessRestarting {
+						fmt.Println("["+title+"]", "process exited, restarting...")
+					}
+				}
+				continue
+			}
+			if supervised {
+				m.supervisor.Line(line.process, line.line)
+			}
+			fmt.Println("["+title+"]", line.line)
 		case <-ctx.Done():
 			return nil
 		}
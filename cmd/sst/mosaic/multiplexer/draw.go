@@ -8,6 +8,7 @@ import (
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/gdamore/tcell/v2/views"
+	"github.com/sst/sst/v3/cmd/sst/mosaic/dev"
 )
 
 func (s *Multiplexer) draw() {
@@ -36,6 +37,10 @@ func (s *Multiplexer) draw() {
 		title := views.NewTextBar()
 		title.SetStyle(style)
 		title.SetLeft(" "+item.icon+" "+item.title, tcell.StyleDefault)
+		if item.supervised {
+			icon, color := statusIcon(s.supervisor.Status(item.key))
+			title.SetRight(icon+" ", tcell.StyleDefault.Foreground(color))
+		}
 		s.stack.AddWidget(title, 0)
 	}
 	s.stack.AddWidget(views.NewSpacer(), 1)
@@ -104,6 +109,24 @@ func (s *Multiplexer) draw() {
 	}
 }
 
+func statusIcon(status string) (string, tcell.Color) {
+	switch status {
+	case dev.ProcessWaiting:
+		return "…", tcell.ColorGray
+	case dev.ProcessStarting:
+		return "◌", tcell.ColorYellow
+	case dev.ProcessRunning:
+		return "●", tcell.ColorGreen
+	case dev.ProcessUnhealthy:
+		return "!", tcell.ColorYellow
+	case dev.ProcessRestarting:
+		return "↻", tcell.ColorYellow
+	case dev.ProcessExited:
+		return "✕", tcell.ColorRed
+	}
+	return "", tcell.ColorGray
+}
+
 func (s *Multiplexer) move(offset int) {
 	index := s.selected + offset
 	if index < 0 {
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"os/exec"
 	"runtime/debug"
 	"strings"
 	"syscall"
@@ -14,6 +15,7 @@ import (
 	"github.com/gdamore/tcell/v2"
 	"github.com/gdamore/tcell/v2/views"
 	tcellterm "github.com/sst/sst/v3/cmd/sst/mosaic/multiplexer/tcell-term"
+	"github.com/sst/sst/v3/cmd/sst/mosaic/supervisor"
 	"github.com/sst/sst/v3/pkg/process"
 )
 
@@ -35,6 +37,8 @@ type Multiplexer struct {
 
 	dragging bool
 	click    *tcell.EventMouse
+
+	supervisor *supervisor.Supervisor
 }
 
 func New(ctx context.Context) (*Multiplexer, error) {
@@ -56,6 +60,11 @@ func New(ctx context.Context) (*Multiplexer, error) {
 	result.main = views.NewViewPort(result.screen, 0, 0, 0, 0)
 	result.stack = views.NewBoxLayout(views.Vertical)
 	result.stack.SetView(result.root)
+	result.supervisor = supervisor.New(ctx, func(name string) {
+		result.screen.PostEvent(&EventStart{Key: name})
+	}, func() {
+		result.screen.PostEvent(&EventStatus{})
+	})
 	if os.Getenv("TMUX") != "" {
 		process.Command("tmux", "set-option", "-p", "set-clipboard", "on").Run()
 	}
@@ -111,6 +120,12 @@ func (s *Multiplexer) Start() {
 				case *EventProcess:
 					for _, p := range s.processes {
 						if p.key == evt.Key {
+							if evt.Dev != nil && p.pending != nil {
+								p.pending = evt.Dev
+							}
+							if evt.Dev != nil && p.pending == nil {
+								s.supervisor.Add(*evt.Dev)
+							}
 							return
 						}
 					}
@@ -130,18 +145,55 @@ func (s *Multiplexer) Start() {
 						s.screen.PostEvent(ev)
 					})
 					proc.vt = term
-					if evt.Autostart {
+					if evt.Autostart && evt.Dev == nil {
 						proc.start()
 					}
 					if !evt.Autostart {
 						proc.vt.Start(process.Command("echo", evt.Key+" has auto-start disabled, press enter to start."))
 						proc.dead = true
 					}
+					if evt.Dev != nil {
+						// the supervisor starts it once what it depends on is
+						// ready, after the message is done so it isn't mistaken
+						// for the process exiting
+						proc.supervised = true
+						proc.pending = evt.Dev
+						if evt.Autostart {
+							proc.vt.Start(process.Command("echo", evt.Key+" is waiting to start."))
+							proc.dead = true
+						}
+						key := evt.Key
+						term.OnLine = func(line string) {
+							s.supervisor.Line(key, line)
+						}
+					}
 					s.processes = append(s.processes, proc)
 					s.sort()
 					s.draw()
 					break
 
+				case *EventStart:
+					for _, proc := range s.processes {
+						if proc.key == evt.Key && proc.dead {
+							s.startProcess(proc)
+							s.sort()
+						}
+					}
+					s.draw()
+					return
+
+				case *EventStatus:
+					s.draw()
+					return
+
+				case *EventExited:
+					for _, proc := range s.processes {
+						if proc.key == evt.Key && proc.cmd == evt.Cmd {
+							s.supervisor.Exited(proc.key, evt.Err != nil)
+						}
+					}
+					return
+
 				case *tcell.EventMouse:
 					if evt.Buttons()&tcell.WheelUp != 0 {
 						s.scrollUp(3)
@@ -225,7 +277,17 @@ func (s *Multiplexer) Start() {
 				case *tcellterm.EventClosed:
 					for index, proc := range s.processes {
 						if proc.vt == evt.VT() {
+							if proc.pending != nil {
+								s.supervisor.Add(*proc.pending)
+								proc.pending = nil
+							}
 							if !proc.dead {
+								if proc.supervised {
+									go func(key string, cmd *exec.Cmd) {
+										err := cmd.Wait()
+										s.screen.PostEvent(&EventExited{Key: key, Cmd: cmd, Err: err})
+									}(proc.key, proc.cmd)
+								}
 								proc.vt.Start(process.Command("echo", "\n[process exited]"))
 								proc.dead = true
 								s.sort()
@@ -254,6 +316,9 @@ func (s *Multiplexer) Start() {
 							}
 						case 'x':
 							if selected.killable && !selected.dead && !s.focused {
+								if selected.supervised {
+									s.supervisor.Stop(selected.key)
+								}
 								selected.Kill()
 							}
 						}
@@ -293,7 +358,7 @@ func (s *Multiplexer) Start() {
 						if !s.focused {
 							if selected.killable {
 								if selected.dead {
-									selected.start()
+									s.startProcess(selected)
 									s.sort()
 									s.draw()
 									return
@@ -6,6 +6,7 @@ import (
 	"github.com/gdamore/tcell/v2"
 	tcellterm "github.com/sst/sst/v3/cmd/sst/mosaic/multiplexer/tcell-term"
 	"github.com/sst/sst/v3/pkg/process"
+	"github.com/sst/sst/v3/pkg/project"
 )
 
 type vterm struct {
@@ -24,6 +25,10 @@ type pane struct {
 	vt       *tcellterm.VT
 	dead     bool
 	cmd      *exec.Cmd
+	// started, restarted and waited on by the supervisor
+	supervised bool
+	// added to the supervisor once its first message is printed
+	pending *project.Dev
 }
 
 type EventProcess struct {
@@ -36,6 +41,27 @@ type EventProcess struct {
 	Killable  bool
 	Autostart bool
 	Env       []string
+	Dev       *project.Dev
+}
+
+// EventStart is posted by the supervisor when a dev process should start.
+type EventStart struct {
+	tcell.EventTime
+	Key string
+}
+
+// EventStatus is posted when the status of a dev process changes.
+type EventStatus struct {
+	tcell.EventTime
+}
+
+// EventExited is posted once the command of a supervised pane has been waited
+// on.
+type EventExited struct {
+	tcell.EventTime
+	Key string
+	Cmd *exec.Cmd
+	Err error
 }
 
 func (s *Multiplexer) AddProcess(key string, args []string, icon string, title string, cwd string, killable bool, autostart bool, env ...string) {
@@ -51,6 +77,32 @@ func (s *Multiplexer) AddProcess(key string, args []string, icon string, title s
 	})
 }
 
+// AddDev adds a dev process that's started, restarted and waited on by the
+// supervisor, following its settings.
+func (s *Multiplexer) AddDev(d project.Dev, args []string, title string, cwd string, env ...string) {
+	s.screen.PostEvent(&EventProcess{
+		Key:       d.Name,
+		Args:      args,
+		Icon:      "→",
+		Title:     title,
+		Cwd:       cwd,
+		Killable:  true,
+		Autostart: d.Autostart,
+		Env:       env,
+		Dev:       &d,
+	})
+}
+
+func (s *Multiplexer) startProcess(p *pane) error {
+	if err := p.start(); err != nil {
+		return err
+	}
+	if p.supervised {
+		s.supervisor.Started(p.key)
+	}
+	return nil
+}
+
 func (p *pane) start() error {
 	p.cmd = process.Command(p.args[0], p.args[1:]...)
 	p.cmd.Env = p.env
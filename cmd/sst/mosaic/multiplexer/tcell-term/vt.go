@@ -32,6 +32,9 @@ type VT struct {
 	// Set the TERM environment variable to be passed to the command's
 	// environment. If not set, xterm-256color will be used
 	TERM string
+	// If set, OnLine is called with each line the command prints, without
+	// escape sequences. It's called with the terminal locked
+	OnLine func(line string)
 
 	mu sync.Mutex
 
@@ -65,6 +68,8 @@ type VT struct {
 	mouseBtn tcell.ButtonMask
 
 	selection *selection
+
+	line strings.Builder
 }
 
 type selection struct {
@@ -209,8 +214,15 @@ func (vt *VT) update(seq Sequence) {
 	switch seq := seq.(type) {
 	case Print:
 		vt.print(rune(seq))
+		if vt.OnLine != nil {
+			vt.line.WriteRune(rune(seq))
+		}
 	case C0:
 		vt.c0(rune(seq))
+		if vt.OnLine != nil && rune(seq) == '\n' {
+			vt.OnLine(vt.line.String())
+			vt.line.Reset()
+		}
 	case ESC:
 		esc := append(seq.Intermediate, seq.Final)
 		vt.esc(string(esc))
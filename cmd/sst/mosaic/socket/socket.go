@@ -5,20 +5,20 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"sort"
 	"time"
 
 	"github.com/charmbracelet/x/ansi"
 	"github.com/gorilla/websocket"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/sst/sst/v3/cmd/sst/mosaic/aws"
+	"github.com/sst/sst/v3/cmd/sst/mosaic/dev"
 	"github.com/sst/sst/v3/pkg/bus"
 	"github.com/sst/sst/v3/pkg/project"
+	"github.com/sst/sst/v3/pkg/runtime"
 	"github.com/sst/sst/v3/pkg/server"
 )
 
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool { return true },
-}
-
 type CliDevEvent struct {
 	App    string `json:"app"`
 	Stage  string `json:"stage"`
@@ -60,14 +60,15 @@ type InvocationError struct {
 	Failed  bool    `json:"failed"`
 }
 
-type Frame struct {
-	Raw string `json:"raw"`
-}
+type Frame = runtime.Frame
 
 func Start(ctx context.Context, p *project.Project, server *server.Server) error {
 	connected := make(chan *websocket.Conn)
 	disconnected := make(chan *websocket.Conn)
 	invocationClear := make(chan string)
+	upgrader := websocket.Upgrader{
+		CheckOrigin: server.CheckOrigin,
+	}
 	server.Mux.HandleFunc("/socket", func(w http.ResponseWriter, r *http.Request) {
 		slog.Info("socket upgrading", "addr", r.RemoteAddr)
 		ws, err := upgrader.Upgrade(w, r, nil)
@@ -102,6 +103,30 @@ func Start(ctx context.Context, p *project.Project, server *server.Server) error
 
 		}
 	})
+	// the invocations are owned by the loop below, so the query api asks it for
+	// a copy of them
+	invocationQuery := make(chan chan []*Invocation)
+	server.Mux.HandleFunc("GET "+dev.API_PREFIX+"/invocations", func(w http.ResponseWriter, r *http.Request) {
+		function := r.URL.Query().Get("function")
+		errors := r.URL.Query().Get("errors") == "true"
+		reply := make(chan []*Invocation, 1)
+		select {
+		case invocationQuery <- reply:
+		case <-r.Context().Done():
+			return
+		}
+		result := []*Invocation{}
+		for _, invocation := range <-reply {
+			if function != "" && resource.URN(invocation.Source).Name() != function {
+				continue
+			}
+			if errors && len(invocation.Errors) == 0 {
+				continue
+			}
+			result = append(result, invocation)
+		}
+		dev.WritePage(w, r, result)
+	})
 	sockets := make(map[*websocket.Conn]struct{})
 	invocations := make(map[string]*Invocation)
 
@@ -172,6 +197,7 @@ func Start(ctx context.Context, p *project.Project, server *server.Server) error
 					invocation.End = time.Now().UnixMilli()
 					invocation.Report = &InvocationReport{
 						Duration: invocation.End - invocation.Start,
+						Memory:   evt.Memory,
 					}
 					publishInvocation(invocation)
 				}
@@ -182,6 +208,7 @@ func Start(ctx context.Context, p *project.Project, server *server.Server) error
 					invocation.End = time.Now().UnixMilli()
 					invocation.Report = &InvocationReport{
 						Duration: invocation.End - invocation.Start,
+						Memory:   evt.Memory,
 					}
 					error := InvocationError{
 						Message: evt.ErrorMessage,
@@ -189,10 +216,13 @@ func Start(ctx context.Context, p *project.Project, server *server.Server) error
 						Failed:  true,
 						Stack:   []Frame{},
 					}
-					for _, frame := range evt.Trace {
-						error.Stack = append(error.Stack, Frame{
-							Raw: frame,
-						})
+					error.Stack = append(error.Stack, evt.Frames...)
+					if len(evt.Frames) == 0 {
+						for _, frame := range evt.Trace {
+							error.Stack = append(error.Stack, Frame{
+								Raw: frame,
+							})
+						}
 					}
 					invocation.Errors = append(invocation.Errors, error)
 					publishInvocation(invocation)
@@ -230,6 +260,19 @@ func Start(ctx context.Context, p *project.Project, server *server.Server) error
 				"properties": all,
 			})
 			break
+		case reply := <-invocationQuery:
+			all := []*Invocation{}
+			for _, invocation := range invocations {
+				copied := *invocation
+				copied.Errors = append([]InvocationError{}, invocation.Errors...)
+				copied.Logs = append([]InvocationLog{}, invocation.Logs...)
+				all = append(all, &copied)
+			}
+			sort.Slice(all, func(i, j int) bool {
+				return all[i].Start > all[j].Start
+			})
+			reply <- all
+			break
 		case ws := <-disconnected:
 			slog.Info("socket disconnected", "addr", ws.RemoteAddr())
 			delete(sockets, ws)
@@ -0,0 +1,380 @@
+package supervisor
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sst/sst/v3/cmd/sst/mosaic/dev"
+	"github.com/sst/sst/v3/pkg/bus"
+	"github.com/sst/sst/v3/pkg/project"
+)
+
+const (
+	RestartNever     = "never"
+	RestartOnFailure = "on-failure"
+	RestartAlways    = "always"
+)
+
+// restarts wait BACKOFF_MIN, doubling up to BACKOFF_MAX. A process that stays up
+// for STABLE starts over from BACKOFF_MIN the next time it exits.
+const BACKOFF_MIN = time.Second
+const BACKOFF_MAX = 30 * time.Second
+const STABLE = 10 * time.Second
+
+// how long readiness checks have by default before dependents are started
+// anyway
+const READY_TIMEOUT = 60 * time.Second
+
+// Supervisor decides when the dev processes of the multiplexer and monoplexer
+// start. They tell it when a process starts, prints and exits, and it calls
+// back when one should be started, once the processes it depends on are ready
+// or when it's time to restart it.
+type Supervisor struct {
+	ctx      context.Context
+	mut      sync.Mutex
+	procs    map[string]*proc
+	start    func(name string)
+	onChange func()
+}
+
+type proc struct {
+	dev    project.Dev
+	status string
+	// whether the processes that depend on it can start
+	ready bool
+	// counts runs, so checks and restarts of an old run can tell
+	run      int
+	started  time.Time
+	attempts int
+	// set when the process is being stopped on purpose, so it isn't restarted
+	stopping bool
+	log      *regexp.Regexp
+	logged   bool
+	cancel   context.CancelFunc
+}
+
+func New(ctx context.Context, start func(name string), onChange func()) *Supervisor {
+	return &Supervisor{
+		ctx:      ctx,
+		procs:    map[string]*proc{},
+		start:    start,
+		onChange: onChange,
+	}
+}
+
+// Resolve drops dependencies on processes that don't exist and ones that form
+// a cycle, since they would never start.
+func Resolve(devs project.Devs) project.Devs {
+	result := project.Devs{}
+	for name, d := range devs {
+		deps := []string{}
+		for _, dep := range d.DependsOn {
+			if _, ok := devs[dep]; !ok {
+				slog.Warn("dev process depends on one that doesn't exist", "name", name, "dependsOn", dep)
+				continue
+			}
+			if dependsOn(devs, dep, name, map[string]bool{}) {
+				slog.Warn("dev processes depend on each other", "name", name, "dependsOn", dep)
+				continue
+			}
+			deps = append(deps, dep)
+		}
+		d.DependsOn = deps
+		result[name] = d
+	}
+	return result
+}
+
+func dependsOn(devs project.Devs, from string, to string, seen map[string]bool) bool {
+	if from == to {
+		return true
+	}
+	if seen[from] {
+		return false
+	}
+	seen[from] = true
+	for _, dep := range devs[from].DependsOn {
+		if dependsOn(devs, dep, to, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// Add registers a dev process, or updates its settings if it already is. It's
+// started once the processes it depends on are ready, unless autostart is off.
+func (s *Supervisor) Add(d project.Dev) {
+	s.mut.Lock()
+	p, ok := s.procs[d.Name]
+	if ok {
+		p.dev = d
+		p.log = compile(d)
+	}
+	if !ok {
+		p = &proc{dev: d, log: compile(d), status: dev.ProcessStopped}
+		s.procs[d.Name] = p
+		if d.Autostart {
+			s.setStatus(d.Name, p, dev.ProcessWaiting)
+		}
+	}
+	starts := s.evaluate()
+	s.mut.Unlock()
+	s.notify(starts)
+}
+
+func compile(d project.Dev) *regexp.Regexp {
+	if d.Ready == nil || d.Ready.Log == "" {
+		return nil
+	}
+	result, err := regexp.Compile(d.Ready.Log)
+	if err != nil {
+		slog.Warn("invalid dev ready log pattern", "name", d.Name, "err", err)
+		return nil
+	}
+	return result
+}
+
+// Has reports whether the process is supervised.
+func (s *Supervisor) Has(name string) bool {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	_, ok := s.procs[name]
+	return ok
+}
+
+func (s *Supervisor) Status(name string) string {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if p, ok := s.procs[name]; ok {
+		return p.status
+	}
+	return ""
+}
+
+// Waiting returns the processes that a waiting process is waiting on.
+func (s *Supervisor) Waiting(name string) []string {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	result := []string{}
+	p, ok := s.procs[name]
+	if !ok {
+		return result
+	}
+	for _, dep := range p.dev.DependsOn {
+		if match, ok := s.procs[dep]; !ok || !match.ready {
+			result = append(result, dep)
+		}
+	}
+	return result
+}
+
+// Started is called when the process was started, whether by the supervisor
+// or by hand.
+func (s *Supervisor) Started(name string) {
+	s.mut.Lock()
+	p, ok := s.procs[name]
+	if !ok {
+		s.mut.Unlock()
+		return
+	}
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.run++
+	p.started = time.Now()
+	p.stopping = false
+	p.logged = false
+	p.ready = false
+	ctx, cancel := context.WithCancel(s.ctx)
+	p.cancel = cancel
+	var starts []string
+	if p.dev.Ready == nil || (p.dev.Ready.Port == 0 && p.dev.Ready.URL == "" && p.log == nil) {
+		p.ready = true
+		s.setStatus(name, p, dev.ProcessRunning)
+		starts = s.evaluate()
+	} else {
+		s.setStatus(name, p, dev.ProcessStarting)
+		go s.check(ctx, name, p.run, *p.dev.Ready)
+	}
+	s.mut.Unlock()
+	s.notify(starts)
+}
+
+// Line is called with each line the process prints, for the log readiness
+// check.
+func (s *Supervisor) Line(name string, line string) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	p, ok := s.procs[name]
+	if !ok || p.log == nil || p.logged || p.status != dev.ProcessStarting {
+		return
+	}
+	p.logged = p.log.MatchString(line)
+}
+
+// Stop is called before the process is stopped on purpose, so it isn't
+// restarted when it exits.
+func (s *Supervisor) Stop(name string) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if p, ok := s.procs[name]; ok {
+		p.stopping = true
+	}
+}
+
+// Exited is called when the process exits, and restarts it if its policy says
+// to.
+func (s *Supervisor) Exited(name string, failed bool) {
+	s.mut.Lock()
+	p, ok := s.procs[name]
+	if !ok {
+		s.mut.Unlock()
+		return
+	}
+	if p.cancel != nil {
+		p.cancel()
+		p.cancel = nil
+	}
+	p.ready = false
+	restart := !p.stopping && (p.dev.Restart == RestartAlways || (p.dev.Restart == RestartOnFailure && failed))
+	p.stopping = false
+	if !restart {
+		s.setStatus(name, p, dev.ProcessExited)
+		s.mut.Unlock()
+		s.notify(nil)
+		return
+	}
+	if time.Since(p.started) >= STABLE {
+		p.attempts = 0
+	}
+	delay := backoff(p.attempts)
+	// once it's at BACKOFF_MAX it stays there
+	if delay < BACKOFF_MAX {
+		p.attempts++
+	}
+	run := p.run
+	slog.Info("restarting dev process", "name", name, "delay", delay, "failed", failed)
+	s.setStatus(name, p, dev.ProcessRestarting)
+	s.mut.Unlock()
+	s.notify(nil)
+
+	go func() {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		s.mut.Lock()
+		// started by hand in the meantime
+		current := p.run == run && p.status == dev.ProcessRestarting
+		s.mut.Unlock()
+		if current {
+			s.start(name)
+		}
+	}()
+}
+
+// backoff is how long to wait before a restart, after a number of restarts in
+// a row.
+func backoff(attempts int) time.Duration {
+	delay := BACKOFF_MIN
+	for i := 0; i < attempts && delay < BACKOFF_MAX; i++ {
+		delay *= 2
+	}
+	return min(delay, BACKOFF_MAX)
+}
+
+// check waits for the port and url of the process to respond, and for the
+// log line to show up, then marks it ready.
+func (s *Supervisor) check(ctx context.Context, name string, run int, ready project.DevReady) {
+	timeout := READY_TIMEOUT
+	if ready.Timeout > 0 {
+		timeout = time.Duration(ready.Timeout) * time.Second
+	}
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline:
+			slog.Warn("dev process not ready in time", "name", name, "timeout", timeout)
+			s.markReady(name, run, dev.ProcessUnhealthy)
+			return
+		case <-ticker.C:
+			if ready.Port != 0 && !portOpen(ready.Port) {
+				continue
+			}
+			if ready.URL != "" && !urlHealthy(ctx, ready.URL) {
+				continue
+			}
+			s.mut.Lock()
+			p := s.procs[name]
+			logged := p.log == nil || p.logged
+			s.mut.Unlock()
+			if !logged {
+				continue
+			}
+			s.markReady(name, run, dev.ProcessRunning)
+			return
+		}
+	}
+}
+
+func (s *Supervisor) markReady(name string, run int, status string) {
+	s.mut.Lock()
+	p := s.procs[name]
+	if p.run != run || p.status != dev.ProcessStarting {
+		s.mut.Unlock()
+		return
+	}
+	p.ready = true
+	s.setStatus(name, p, status)
+	starts := s.evaluate()
+	s.mut.Unlock()
+	s.notify(starts)
+}
+
+// evaluate finds the waiting processes that can start now. It's called with
+// the lock held, and the processes are started after it's released.
+func (s *Supervisor) evaluate() []string {
+	result := []string{}
+	for name, p := range s.procs {
+		if p.status != dev.ProcessWaiting {
+			continue
+		}
+		blocked := slices.ContainsFunc(p.dev.DependsOn, func(dep string) bool {
+			match, ok := s.procs[dep]
+			return !ok || !match.ready
+		})
+		if blocked {
+			continue
+		}
+		// so it isn't started twice before the process reports back
+		s.setStatus(name, p, dev.ProcessStarting)
+		result = append(result, name)
+	}
+	return result
+}
+
+func (s *Supervisor) setStatus(name string, p *proc, status string) {
+	if p.status == status {
+		return
+	}
+	p.status = status
+	bus.Publish(&dev.ProcessEvent{Name: name, Status: status})
+}
+
+func (s *Supervisor) notify(starts []string) {
+	for _, name := range starts {
+		s.start(name)
+	}
+	if s.onChange != nil {
+		s.onChange()
+	}
+}
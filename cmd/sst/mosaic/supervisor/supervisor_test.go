@@ -0,0 +1,203 @@
+package supervisor
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sst/sst/v3/cmd/sst/mosaic/dev"
+	"github.com/sst/sst/v3/pkg/project"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{4, 16 * time.Second},
+		{5, BACKOFF_MAX},
+		{6, BACKOFF_MAX},
+		// would overflow if shifted
+		{64, BACKOFF_MAX},
+		{1000, BACKOFF_MAX},
+	}
+	for _, test := range tests {
+		if got := backoff(test.attempts); got != test.expected {
+			t.Errorf("backoff(%d): expected %v, got %v", test.attempts, test.expected, got)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name     string
+		devs     project.Devs
+		expected map[string][]string
+	}{
+		{
+			"chain",
+			project.Devs{
+				"web": {DependsOn: []string{"api"}},
+				"api": {DependsOn: []string{"db"}},
+				"db":  {},
+			},
+			map[string][]string{"web": {"api"}, "api": {"db"}, "db": {}},
+		},
+		{
+			"missing",
+			project.Devs{
+				"api": {DependsOn: []string{"db", "queue"}},
+				"db":  {},
+			},
+			map[string][]string{"api": {"db"}, "db": {}},
+		},
+		{
+			"itself",
+			project.Devs{
+				"api": {DependsOn: []string{"api"}},
+			},
+			map[string][]string{"api": {}},
+		},
+		{
+			"cycle",
+			project.Devs{
+				"api":    {DependsOn: []string{"worker"}},
+				"worker": {DependsOn: []string{"api"}},
+			},
+			map[string][]string{"api": {}, "worker": {}},
+		},
+		{
+			"longer cycle",
+			project.Devs{
+				"web":    {DependsOn: []string{"api"}},
+				"api":    {DependsOn: []string{"worker", "db"}},
+				"worker": {DependsOn: []string{"web"}},
+				"db":     {},
+			},
+			map[string][]string{"web": {}, "api": {"db"}, "worker": {}, "db": {}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := Resolve(test.devs)
+			if len(result) != len(test.expected) {
+				t.Fatalf("expected %d processes, got %d", len(test.expected), len(result))
+			}
+			for name, deps := range test.expected {
+				if got := result[name].DependsOn; !slices.Equal(got, deps) {
+					t.Errorf("%s: expected %v, got %v", name, deps, got)
+				}
+			}
+		})
+	}
+}
+
+func newSupervisor(t *testing.T) (*Supervisor, chan string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	started := make(chan string, 10)
+	return New(ctx, func(name string) { started <- name }, nil), started
+}
+
+func expectStart(t *testing.T, started chan string, name string, timeout time.Duration) {
+	t.Helper()
+	select {
+	case got := <-started:
+		if got != name {
+			t.Fatalf("expected %s to start, got %s", name, got)
+		}
+	case <-time.After(timeout):
+		t.Fatalf("timed out waiting for %s to start", name)
+	}
+}
+
+func expectStatus(t *testing.T, s *Supervisor, name string, status string) {
+	t.Helper()
+	if got := s.Status(name); got != status {
+		t.Fatalf("expected %s to be %s, got %s", name, status, got)
+	}
+}
+
+func TestTransitions(t *testing.T) {
+	s, started := newSupervisor(t)
+	s.Add(project.Dev{Name: "db", Autostart: true})
+	expectStart(t, started, "db", time.Second)
+	expectStatus(t, s, "db", dev.ProcessStarting)
+
+	s.Add(project.Dev{Name: "api", Autostart: true, DependsOn: []string{"db"}, Ready: &project.DevReady{Log: "listening"}})
+	s.Add(project.Dev{Name: "web", Autostart: true, DependsOn: []string{"api"}})
+	s.Add(project.Dev{Name: "scripts", DependsOn: []string{"db"}})
+	expectStatus(t, s, "api", dev.ProcessWaiting)
+	expectStatus(t, s, "scripts", dev.ProcessStopped)
+	if got := s.Waiting("api"); !slices.Equal(got, []string{"db"}) {
+		t.Fatalf("expected api to wait on db, got %v", got)
+	}
+
+	// nothing to check, so it's ready as soon as it starts
+	s.Started("db")
+	expectStatus(t, s, "db", dev.ProcessRunning)
+	expectStart(t, started, "api", time.Second)
+	if got := s.Waiting("api"); len(got) != 0 {
+		t.Fatalf("expected api to not wait, got %v", got)
+	}
+
+	s.Started("api")
+	expectStatus(t, s, "api", dev.ProcessStarting)
+	s.Line("api", "compiling")
+	time.Sleep(500 * time.Millisecond)
+	expectStatus(t, s, "api", dev.ProcessStarting)
+	expectStatus(t, s, "web", dev.ProcessWaiting)
+	s.Line("api", "listening on 3000")
+	expectStart(t, started, "web", 2*time.Second)
+	expectStatus(t, s, "api", dev.ProcessRunning)
+
+	// not restarted by default
+	s.Exited("db", true)
+	expectStatus(t, s, "db", dev.ProcessExited)
+	if got := s.Waiting("api"); !slices.Equal(got, []string{"db"}) {
+		t.Fatalf("expected api to wait on db, got %v", got)
+	}
+	select {
+	case name := <-started:
+		t.Fatalf("expected nothing to start, got %s", name)
+	default:
+	}
+}
+
+func TestRestart(t *testing.T) {
+	s, started := newSupervisor(t)
+	s.Add(project.Dev{Name: "api", Restart: RestartOnFailure})
+	s.Started("api")
+	s.Exited("api", false)
+	expectStatus(t, s, "api", dev.ProcessExited)
+
+	s.Started("api")
+	s.Exited("api", true)
+	expectStatus(t, s, "api", dev.ProcessRestarting)
+	expectStart(t, started, "api", BACKOFF_MIN+time.Second)
+
+	// stopped on purpose
+	s.Started("api")
+	s.Stop("api")
+	s.Exited("api", true)
+	expectStatus(t, s, "api", dev.ProcessExited)
+}
+
+func TestRestartAttempts(t *testing.T) {
+	s, _ := newSupervisor(t)
+	s.Add(project.Dev{Name: "api", Restart: RestartAlways})
+	s.Started("api")
+	for i := 0; i < 100; i++ {
+		s.Exited("api", true)
+	}
+	s.mut.Lock()
+	attempts := s.procs["api"].attempts
+	s.mut.Unlock()
+	if backoff(attempts) != BACKOFF_MAX || backoff(attempts-1) == BACKOFF_MAX {
+		t.Fatalf("expected attempts to stop at BACKOFF_MAX, got %d", attempts)
+	}
+}
@@ -30,6 +30,7 @@ type footer struct {
 	downloading map[string]*apitype.ProgressEvent
 	skipped     int
 	cancelled   bool
+	queued      bool
 
 	spinner int
 
@@ -150,6 +151,7 @@ func (m *footer) Reset() {
 	m.complete = nil
 	m.summary = false
 	m.cancelled = false
+	m.queued = false
 }
 
 func (m *footer) Update(msg any) {
@@ -182,6 +184,14 @@ func (m *footer) Update(msg any) {
 	case *deployer.DeployFailedEvent:
 		m.Reset()
 		break
+	case *deployer.DeployQueuedEvent:
+		m.queued = msg.Running
+	case *deployer.DeploySupersededEvent:
+		m.cancelled = true
+	case *deployer.DeployFinishedEvent:
+		if msg.Superseded {
+			m.Reset()
+		}
 	case *project.SkipEvent:
 		m.Reset()
 		break
@@ -303,6 +313,10 @@ func (m *footer) View(width int) string {
 		label = fmt.Sprintf("%-11s", label)
 		label += TEXT_DIM.Render(fmt.Sprintf(" %d skipped", m.skipped))
 	}
+	if m.queued && !m.cancelled {
+		label = fmt.Sprintf("%-11s", label)
+		label += TEXT_DIM.Render(" changes queued")
+	}
 	result = append(result, spinner+"  "+label)
 	return lipgloss.NewStyle().MaxWidth(width).Render(lipgloss.JoinVertical(lipgloss.Top, result...))
 }
@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"net/url"
 	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 	"time"
@@ -22,6 +23,7 @@ import (
 	"github.com/sst/sst/v3/cmd/sst/mosaic/ui/common"
 	"github.com/sst/sst/v3/pkg/flag"
 	"github.com/sst/sst/v3/pkg/project"
+	"github.com/sst/sst/v3/pkg/runtime"
 
 	"golang.org/x/crypto/ssh/terminal"
 )
@@ -62,6 +64,7 @@ type Options struct {
 	Silent bool
 	Log    *os.File
 	Dev    bool
+	Stderr bool
 }
 
 type Option func(*Options)
@@ -74,6 +77,12 @@ func WithDev(u *Options) {
 	u.Dev = true
 }
 
+// WithStderr prints to stderr without the footer, so the command can write its
+// own output to stdout.
+func WithStderr(u *Options) {
+	u.Stderr = true
+}
+
 func WithLog(file *os.File) Option {
 	return func(opts *Options) {
 		opts.Log = file
@@ -96,7 +105,7 @@ func New(ctx context.Context, options ...Option) *UI {
 	if opts.Log != nil {
 		result.log = opts.Log
 	}
-	if isTTY && !opts.Silent {
+	if isTTY && !opts.Silent && !opts.Stderr {
 		result.footer = NewFooter()
 		go result.footer.Start(ctx)
 	}
@@ -115,7 +124,10 @@ func (u *UI) printf(tmpl string, args ...interface{}) {
 func (u *UI) println(args ...interface{}) {
 	u.buffer = append(u.buffer, args...)
 	line := fmt.Sprint(u.buffer...)
-	if u.footer == nil {
+	if u.footer == nil && u.options.Stderr {
+		fmt.Fprintln(os.Stderr, line)
+	}
+	if u.footer == nil && !u.options.Stderr {
 		fmt.Println(line)
 	}
 	if u.footer != nil {
@@ -129,6 +141,41 @@ func (u *UI) println(args ...interface{}) {
 	u.hasBlank = false
 }
 
+// printFrames shows the frames of a stack trace as file:line:column, relative
+// to the current directory so they can be opened from the terminal. Frames in
+// dependencies are collapsed.
+func (u *UI) printFrames(color lipgloss.Style, frames []runtime.Frame) {
+	cwd, _ := os.Getwd()
+	collapsed := 0
+	flush := func() {
+		if collapsed > 0 {
+			u.printEvent(color, "", TEXT_DIM.Render(fmt.Sprintf("↳ %d more in dependencies", collapsed)))
+			collapsed = 0
+		}
+	}
+	for _, frame := range frames {
+		// the first line is the error itself
+		if frame.File == "" {
+			continue
+		}
+		if !frame.Important {
+			collapsed++
+			continue
+		}
+		flush()
+		file := frame.File
+		if rel, err := filepath.Rel(cwd, file); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+		location := fmt.Sprintf("%s:%d:%d", file, frame.Line, frame.Column)
+		if frame.Function != "" {
+			location = frame.Function + " " + TEXT_DIM.Render(location)
+		}
+		u.printEvent(color, "", "↳ "+location)
+	}
+	flush()
+}
+
 func (u *UI) blank() {
 	if u.hasBlank {
 		return
@@ -195,9 +242,17 @@ func (u *UI) Event(unknown interface{}) {
 		}
 		u.printEvent(TEXT_SUCCESS, "Build", u.functionName(evt.FunctionID))
 
+	case *aws.FunctionDebugEvent:
+		u.printEvent(u.getColor(evt.WorkerID), TEXT_NORMAL_BOLD.Render(fmt.Sprintf("%-11s", "Debug")), u.functionName(evt.FunctionID))
+		u.printEvent(u.getColor(evt.WorkerID), "", fmt.Sprintf("↳ %s listening on 127.0.0.1:%d", evt.Debugger, evt.Port))
+
 	case *aws.FunctionErrorEvent:
 		u.printEvent(u.getColor(evt.WorkerID), TEXT_DANGER.Render(fmt.Sprintf("%-11s", "Error")), u.functionName(evt.FunctionID))
 		u.printEvent(u.getColor(evt.WorkerID), "", evt.ErrorMessage)
+		if len(evt.Frames) > 0 {
+			u.printFrames(u.getColor(evt.WorkerID), evt.Frames)
+			break
+		}
 		for _, item := range evt.Trace {
 			if strings.Contains(item, "Error:") {
 				continue
@@ -215,15 +270,27 @@ func (u *UI) Event(unknown interface{}) {
 			u.printEvent(TEXT_DANGER, "Error", evt.Error)
 		}
 
+	case *deployer.DeploySupersededEvent:
+		u.printEvent(TEXT_WARNING, "Superseded", "Config changed, deploying again once this is cancelled")
+
+	case *deployer.DeployFinishedEvent:
+		if evt.Superseded {
+			u.reset()
+		}
+
 	case *project.StackCommandEvent:
 		u.reset()
 		u.header(evt.Version, evt.App, evt.Stage)
 		u.blank()
 		if evt.Command == "deploy" {
 			u.mode = ProgressModeDeploy
+			label := "  Deploy"
+			if evt.Attempt > 1 {
+				label = "  Retry"
+			}
 			u.println(
 				TEXT_WARNING_BOLD.Render("~"),
-				TEXT_NORMAL_BOLD.Render("  Deploy"),
+				TEXT_NORMAL_BOLD.Render(label),
 			)
 		}
 		if evt.Command == "remove" {
@@ -247,7 +314,28 @@ func (u *UI) Event(unknown interface{}) {
 				TEXT_NORMAL_BOLD.Render("  Diff"),
 			)
 		}
+		if len(evt.Target) > 0 && evt.Attempt <= 1 {
+			u.println(TEXT_DIM.Render(fmt.Sprintf("   Targeting %d resources", len(evt.Target))))
+			for _, urn := range evt.Target {
+				u.println(TEXT_DIM.Render("   ↳ " + urn))
+			}
+		}
+		u.blank()
+
+	case *project.RetryEvent:
 		u.blank()
+		message := []string{fmt.Sprintf("Retrying %d failed resources in %s (attempt %d/%d)", len(evt.Targets), evt.Delay, evt.Attempt, evt.Max)}
+		for _, urn := range evt.Targets {
+			message = append(message, "↳ "+u.FormatURN(urn))
+		}
+		u.printEvent(TEXT_WARNING, "Retry", message...)
+
+	case *runtime.BuildCacheEvent:
+		if evt.Hit {
+			u.printEvent(TEXT_SUCCESS, "Cache Hit", u.functionName(evt.FunctionID))
+			break
+		}
+		u.printEvent(TEXT_DIM, "Cache Miss", u.functionName(evt.FunctionID))
 
 	case *project.BuildFailedEvent:
 		u.reset()
@@ -489,7 +577,13 @@ func (u *UI) Event(unknown interface{}) {
 		}
 		u.printEvent(TEXT_INFO, "Build", u.functionName(evt.WorkerID))
 	case *cloudflare.WorkerUpdatedEvent:
+		if evt.URL != "" {
+			u.printEvent(TEXT_INFO, "Reload", u.functionName(evt.WorkerID)+" "+evt.URL)
+			return
+		}
 		u.printEvent(TEXT_INFO, "Reload", u.functionName(evt.WorkerID))
+	case *cloudflare.WorkerLogEvent:
+		u.printEvent(u.getColor(evt.WorkerID), "", evt.Line)
 	case *cloudflare.WorkerInvokedEvent:
 		url, _ := url.Parse(evt.TailEvent.Event.Request.URL)
 		u.printEvent(
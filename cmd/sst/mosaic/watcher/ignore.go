@@ -0,0 +1,97 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	ignore "github.com/sabhiram/go-gitignore"
+)
+
+// IGNORE_FILE lists paths that sst dev shouldn't watch, on top of the
+// .gitignore files. It uses the same syntax.
+const IGNORE_FILE = ".sstignore"
+
+// always skipped, whatever the ignore files say
+var ignoredDirs = map[string]bool{
+	"node_modules": true,
+}
+
+// ignorer matches paths against the .gitignore file of every directory above
+// them, up to the root, and the .sstignore file in the root.
+type ignorer struct {
+	root  string
+	rules map[string][]*ignore.GitIgnore
+}
+
+func newIgnorer(root string) *ignorer {
+	result := &ignorer{
+		root:  root,
+		rules: map[string][]*ignore.GitIgnore{},
+	}
+	result.load(root)
+	return result
+}
+
+// load reads the ignore files in a directory, again if they changed.
+func (i *ignorer) load(dir string) {
+	names := []string{".gitignore"}
+	if dir == i.root {
+		names = append(names, IGNORE_FILE)
+	}
+	rules := []*ignore.GitIgnore{}
+	for _, name := range names {
+		compiled, err := ignore.CompileIgnoreFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		rules = append(rules, compiled)
+	}
+	if len(rules) == 0 {
+		delete(i.rules, dir)
+		return
+	}
+	i.rules[dir] = rules
+}
+
+// isIgnoreFile reports whether a change to the path changes what is ignored.
+func (i *ignorer) isIgnoreFile(path string) bool {
+	name := filepath.Base(path)
+	return name == ".gitignore" || (name == IGNORE_FILE && filepath.Dir(path) == i.root)
+}
+
+func (i *ignorer) ignored(path string, dir bool) bool {
+	rel, err := filepath.Rel(i.root, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	parts := strings.Split(rel, string(filepath.Separator))
+	for index, part := range parts {
+		last := index == len(parts)-1
+		if (!last || dir) && (strings.HasPrefix(part, ".") || ignoredDirs[part]) {
+			return true
+		}
+	}
+	// check against the rules of each directory above the path, with the
+	// path relative to it
+	current := i.root
+	for index := range parts {
+		for _, rules := range i.rules[current] {
+			match := filepath.ToSlash(filepath.Join(parts[index:]...))
+			if dir {
+				match += "/"
+			}
+			if rules.MatchesPath(match) {
+				return true
+			}
+		}
+		current = filepath.Join(current, parts[index])
+	}
+	return false
+}
+
+// isDir reports whether the path is a directory, false if it's gone.
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
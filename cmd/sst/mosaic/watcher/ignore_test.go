@@ -0,0 +1,98 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func write(t *testing.T, file string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIgnored(t *testing.T) {
+	root := t.TempDir()
+	write(t, filepath.Join(root, ".gitignore"), "*.log\nbuild/\n/dist\n")
+	write(t, filepath.Join(root, IGNORE_FILE), "fixtures\n")
+	write(t, filepath.Join(root, "packages", "api", ".gitignore"), "generated/\n*.tmp\n!keep.tmp\n")
+	write(t, filepath.Join(root, "packages", "web", ".gitignore"), "out\n")
+	// only the one in the root is read
+	write(t, filepath.Join(root, "packages", "api", IGNORE_FILE), "src\n")
+	ignore := newIgnorer(root)
+	for _, dir := range []string{"packages", "packages/api", "packages/web"} {
+		ignore.load(filepath.Join(root, dir))
+	}
+
+	tests := []struct {
+		path     string
+		dir      bool
+		expected bool
+	}{
+		{".", true, false},
+		{"index.ts", false, false},
+		{"debug.log", false, true},
+		{"packages/api/debug.log", false, true},
+		// a trailing / only matches directories
+		{"build", true, true},
+		{"build", false, false},
+		{"build/index.js", false, true},
+		{"packages/web/build", true, true},
+		// a leading / only matches in the directory of the ignore file
+		{"dist", true, true},
+		{"packages/web/dist", true, false},
+		{"fixtures", true, true},
+		{"packages/api/src/index.ts", false, false},
+		// nested ignore files only apply below them, relative to them
+		{"packages/api/generated", true, true},
+		{"packages/api/generated/types.ts", false, true},
+		{"packages/web/generated", true, false},
+		{"packages/api/scratch.tmp", false, true},
+		{"packages/api/keep.tmp", false, false},
+		{"packages/web/scratch.tmp", false, false},
+		{"packages/web/out", true, true},
+		{"packages/web/out/index.html", false, true},
+		{"packages/api/out", true, false},
+		// directories that start with a dot are skipped, files aren't
+		{".git", true, true},
+		{".git/HEAD", false, true},
+		{"packages/api/.turbo", true, true},
+		{".env", false, false},
+		{"packages/api/.env", false, false},
+		{"node_modules", true, true},
+		{"packages/web/node_modules/react/index.js", false, true},
+	}
+	for _, test := range tests {
+		if got := ignore.ignored(filepath.Join(root, test.path), test.dir); got != test.expected {
+			t.Errorf("ignored(%s, dir=%v): expected %v, got %v", test.path, test.dir, test.expected, got)
+		}
+	}
+	if ignore.ignored(filepath.Join(filepath.Dir(root), "other", "debug.log"), false) {
+		t.Error("expected paths outside the root to not be ignored")
+	}
+}
+
+func TestIsIgnoreFile(t *testing.T) {
+	root := t.TempDir()
+	ignore := newIgnorer(root)
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{".gitignore", true},
+		{"packages/api/.gitignore", true},
+		{IGNORE_FILE, true},
+		{"packages/api/" + IGNORE_FILE, false},
+		{"index.ts", false},
+	}
+	for _, test := range tests {
+		if got := ignore.isIgnoreFile(filepath.Join(root, test.path)); got != test.expected {
+			t.Errorf("isIgnoreFile(%s): expected %v, got %v", test.path, test.expected, got)
+		}
+	}
+}
@@ -2,9 +2,10 @@ package watcher
 
 import (
 	"context"
+	"io/fs"
 	"log/slog"
-	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -12,71 +13,191 @@ import (
 	"github.com/sst/sst/v3/pkg/bus"
 )
 
+// FileChangedEvent is a set of files that were created, changed, removed or
+// renamed together, like in a save or a checkout.
 type FileChangedEvent struct {
-	Path string
+	Paths []string
+}
+
+// Matches reports whether any of the changed files matches.
+func (e *FileChangedEvent) Matches(match func(path string) bool) bool {
+	for _, path := range e.Paths {
+		if match(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// how long the watcher waits for things to settle before publishing changes,
+// and the longest it holds on to them while they keep coming
+const DEBOUNCE = 100 * time.Millisecond
+const MAX_WAIT = time.Second
+
+type watcher struct {
+	fs      *fsnotify.Watcher
+	root    string
+	ignore  *ignorer
+	watched map[string]bool
+	pending map[string]bool
 }
 
 func Start(ctx context.Context, root string) error {
 	defer slog.Info("watcher done")
 	slog.Info("starting watcher", "root", root)
-	watcher, err := fsnotify.NewWatcher()
+	fsw, err := fsnotify.NewWatcher()
 	if err != nil {
 		return err
 	}
-	err = watcher.AddWith(root)
-	if err != nil {
-		return err
+	defer fsw.Close()
+	w := &watcher{
+		fs:      fsw,
+		root:    root,
+		ignore:  newIgnorer(root),
+		watched: map[string]bool{},
+		pending: map[string]bool{},
 	}
-	ignoreSubstrings := []string{"node_modules"}
-
-	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if info.IsDir() {
-			if strings.HasPrefix(info.Name(), ".") {
-				return filepath.SkipDir
-			}
-			for _, substring := range ignoreSubstrings {
-				if strings.Contains(path, substring) {
-					return filepath.SkipDir
-				}
-			}
-			slog.Info("watching", "path", path)
-			err = watcher.Add(path)
-			if err != nil {
-				return err
-			}
-		}
-		return nil
-	})
-	if err != nil {
+	if err := w.add(root, false); err != nil {
 		return err
 	}
+	// nothing has changed yet
+	w.pending = map[string]bool{}
 
 	headFile := filepath.Join(root, ".git/HEAD")
-	watcher.Add(headFile)
-	limiter := map[string]time.Time{}
+	fsw.Add(headFile)
+
+	var flush <-chan time.Time
+	var first time.Time
 	for {
 		select {
-		case event, ok := <-watcher.Events:
+		case event, ok := <-fsw.Events:
 			if !ok {
 				return nil
 			}
-			// if event.Name == headFile {
-			// 	return nil
-			// }
-			if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+			if event.Name != headFile && !w.handle(event) {
 				slog.Info("ignoring file event", "path", event.Name, "op", event.Op)
 				continue
 			}
 			slog.Info("file event", "path", event.Name, "op", event.Op)
-			if time.Since(limiter[event.Name]) > 500*time.Millisecond {
-				limiter[event.Name] = time.Now()
-				bus.Publish(&FileChangedEvent{Path: event.Name})
+			w.pending[event.Name] = true
+			if flush == nil {
+				first = time.Now()
 			}
+			flush = time.After(min(DEBOUNCE, MAX_WAIT-time.Since(first)))
+		case <-flush:
+			flush = nil
+			paths := make([]string, 0, len(w.pending))
+			for path := range w.pending {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+			w.pending = map[string]bool{}
+			slog.Info("files changed", "count", len(paths))
+			bus.Publish(&FileChangedEvent{Paths: paths})
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return nil
+			}
+			// usually the event queue overflowing, so some changes were missed
+			slog.Error("watcher error", "err", err)
 		case <-ctx.Done():
 			return nil
 		}
 	}
 }
+
+// handle keeps the watches in line with the event and reports whether it's a
+// change worth publishing.
+func (w *watcher) handle(event fsnotify.Event) bool {
+	// a directory that moved after its watch was dropped is reported without
+	// a name
+	if event.Name == "" || event.Op == fsnotify.Chmod {
+		return false
+	}
+	if event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+		// a renamed file shows up again as a create under its new name
+		dir := w.watched[event.Name]
+		w.remove(event.Name)
+		if w.ignore.isIgnoreFile(event.Name) {
+			w.rewalk(filepath.Dir(event.Name))
+		}
+		return !w.ignore.ignored(event.Name, dir)
+	}
+	dir := isDir(event.Name)
+	if w.ignore.ignored(event.Name, dir) {
+		return false
+	}
+	if w.ignore.isIgnoreFile(event.Name) {
+		w.rewalk(filepath.Dir(event.Name))
+	}
+	if dir && event.Op&fsnotify.Create != 0 {
+		// files can be created in the directory before it's watched, so they
+		// are added to the changes as it's walked
+		if err := w.add(event.Name, true); err != nil {
+			slog.Error("failed to watch", "path", event.Name, "err", err)
+		}
+	}
+	return true
+}
+
+// add watches a directory and the ones in it that aren't ignored.
+func (w *watcher) add(root string, changed bool) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// removed while walking
+			if path != root {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			if changed && !w.ignore.ignored(path, false) {
+				w.pending[path] = true
+			}
+			return nil
+		}
+		if path != w.root && w.ignore.ignored(path, true) {
+			return filepath.SkipDir
+		}
+		w.ignore.load(path)
+		if w.watched[path] {
+			return nil
+		}
+		slog.Info("watching", "path", path)
+		if err := w.fs.Add(path); err != nil {
+			return err
+		}
+		w.watched[path] = true
+		return nil
+	})
+}
+
+// rewalk reloads the ignore files of a directory after one of them changed,
+// and drops or adds the watches on the directories in it to match.
+func (w *watcher) rewalk(dir string) {
+	w.ignore.load(dir)
+	prefix := dir + string(filepath.Separator)
+	for watched := range w.watched {
+		if strings.HasPrefix(watched, prefix) && w.ignore.ignored(watched, true) {
+			w.remove(watched)
+		}
+	}
+	if err := w.add(dir, false); err != nil {
+		slog.Error("failed to watch", "path", dir, "err", err)
+	}
+}
+
+// remove drops the watches on a directory that's gone and the ones in it.
+func (w *watcher) remove(path string) {
+	prefix := path + string(filepath.Separator)
+	for watched := range w.watched {
+		if watched != path && !strings.HasPrefix(watched, prefix) {
+			continue
+		}
+		slog.Info("unwatching", "path", watched)
+		// fails if it was already removed along with the directory
+		w.fs.Remove(watched)
+		delete(w.watched, watched)
+		delete(w.ignore.rules, watched)
+	}
+}
@@ -0,0 +1,68 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestIgnoreFileChanged(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"src", "build/assets", "packages/api/generated"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsw.Close()
+	w := &watcher{
+		fs:      fsw,
+		root:    root,
+		ignore:  newIgnorer(root),
+		watched: map[string]bool{},
+		pending: map[string]bool{},
+	}
+	if err := w.add(root, false); err != nil {
+		t.Fatal(err)
+	}
+	expect := func(step string, watched map[string]bool) {
+		t.Helper()
+		for dir, expected := range watched {
+			if w.watched[filepath.Join(root, dir)] != expected {
+				t.Errorf("%s: expected %s to be watched: %v", step, dir, expected)
+			}
+		}
+	}
+	change := func(file string, content string, op fsnotify.Op) {
+		t.Helper()
+		path := filepath.Join(root, file)
+		if op == fsnotify.Remove {
+			os.Remove(path)
+		} else {
+			write(t, path, content)
+		}
+		w.handle(fsnotify.Event{Name: path, Op: op})
+	}
+	expect("start", map[string]bool{"src": true, "build": true, "build/assets": true, "packages/api/generated": true})
+
+	change(".gitignore", "build/\n", fsnotify.Create)
+	expect("ignored", map[string]bool{"src": true, "build": false, "build/assets": false})
+
+	change(".gitignore", "", fsnotify.Write)
+	expect("unignored", map[string]bool{"src": true, "build": true, "build/assets": true})
+
+	change("packages/api/.gitignore", "generated\n", fsnotify.Create)
+	expect("nested", map[string]bool{"packages/api": true, "packages/api/generated": false})
+
+	change("packages/api/.gitignore", "", fsnotify.Remove)
+	expect("removed", map[string]bool{"packages/api": true, "packages/api/generated": true})
+
+	if len(w.pending) != 0 {
+		t.Errorf("expected no files to be changed, got %v", w.pending)
+	}
+}
@@ -1,8 +1,6 @@
 package main
 
 import (
-	"strings"
-
 	"github.com/sst/sst/v3/cmd/sst/cli"
 	"github.com/sst/sst/v3/cmd/sst/mosaic/ui"
 	"github.com/sst/sst/v3/pkg/bus"
@@ -18,9 +16,9 @@ func CmdRefresh(c *cli.Cli) error {
 	}
 	defer p.Cleanup()
 
-	target := []string{}
-	if c.String("target") != "" {
-		target = strings.Split(c.String("target"), ",")
+	target, err := c.Targets(p, false)
+	if err != nil {
+		return err
 	}
 
 	var wg errgroup.Group
@@ -45,10 +43,10 @@ func CmdRefresh(c *cli.Cli) error {
 	defer ui.Destroy()
 	defer c.Cancel()
 	err = p.Run(c.Context, &project.StackInput{
-		Command:    "refresh",
-		Target:     target,
-		ServerPort: s.Port,
-		Verbose:    c.Bool("verbose"),
+		Command:   "refresh",
+		Target:    target,
+		ServerURL: s.URL(),
+		Verbose:   c.Bool("verbose"),
 	})
 	if err != nil {
 		return err
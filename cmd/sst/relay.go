@@ -0,0 +1,85 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/sst/sst/v3/cmd/sst/cli"
+	"github.com/sst/sst/v3/cmd/sst/mosaic/aws/relay"
+	"github.com/sst/sst/v3/cmd/sst/mosaic/ui"
+	"github.com/sst/sst/v3/pkg/flag"
+)
+
+var CmdRelay = &cli.Command{
+	Name: "relay",
+	Description: cli.Description{
+		Short: "Run a relay for live Lambda",
+		Long: strings.Join([]string{
+			"Run a relay that your functions and `sst dev` can connect to, instead of AppSync Events.",
+			"",
+			"In `sst dev`, the functions in your app are replaced with a bridge that forwards their",
+			"invocations to your machine. By default these go through AppSync Events in your account.",
+			"The relay is a WebSocket server you can run anywhere both your functions and your machine",
+			"can reach.",
+			"",
+			"```bash frame=\"none\"",
+			"sst relay --port 8080",
+			"```",
+			"",
+			"Connections to the relay need to pass a token. It's read from `SST_RELAY_TOKEN`, or one",
+			"is generated and printed when it's not set.",
+			"",
+			"Then point `sst dev` at it with the `SST_RELAY_URL` and `SST_RELAY_TOKEN` environment",
+			"variables. They are passed on to your functions when they are deployed.",
+			"",
+			"```bash frame=\"none\"",
+			"SST_RELAY_URL=wss://relay.example.com SST_RELAY_TOKEN=<token> sst dev",
+			"```",
+		}, "\n"),
+	},
+	Flags: []cli.Flag{
+		{
+			Name: "port",
+			Type: "string",
+			Description: cli.Description{
+				Short: "The port to listen on",
+				Long:  "The port to listen on. Defaults to `8080`.",
+			},
+		},
+	},
+	Run: func(c *cli.Cli) error {
+		port := c.String("port")
+		if port == "" {
+			port = "8080"
+		}
+		token := flag.SST_RELAY_TOKEN
+		if token == "" {
+			bytes := make([]byte, 24)
+			if _, err := rand.Read(bytes); err != nil {
+				return err
+			}
+			token = hex.EncodeToString(bytes)
+		}
+		listener, err := net.Listen("tcp", ":"+port)
+		if err != nil {
+			return err
+		}
+		server := &http.Server{Handler: relay.NewServer(token)}
+		go func() {
+			<-c.Context.Done()
+			server.Close()
+		}()
+		ui.Success(fmt.Sprintf("Relay listening on port %s", port))
+		fmt.Println("SST_RELAY_TOKEN=" + token)
+		err = server.Serve(listener)
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	},
+}
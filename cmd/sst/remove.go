@@ -1,8 +1,6 @@
 package main
 
 import (
-	"strings"
-
 	"github.com/sst/sst/v3/cmd/sst/cli"
 	"github.com/sst/sst/v3/cmd/sst/mosaic/ui"
 	"github.com/sst/sst/v3/pkg/bus"
@@ -18,9 +16,9 @@ func CmdRemove(c *cli.Cli) error {
 	}
 	defer p.Cleanup()
 
-	target := []string{}
-	if c.String("target") != "" {
-		target = strings.Split(c.String("target"), ",")
+	target, err := c.Targets(p, !c.Bool("no-dependents"))
+	if err != nil {
+		return err
 	}
 
 	var wg errgroup.Group
@@ -45,10 +43,11 @@ func CmdRemove(c *cli.Cli) error {
 	defer ui.Destroy()
 	defer c.Cancel()
 	err = p.Run(c.Context, &project.StackInput{
-		Command:    "remove",
-		Target:     target,
-		ServerPort: s.Port,
-		Verbose:    c.Bool("verbose"),
+		Command:          "remove",
+		Target:           target,
+		TargetDependents: c.TargetDependents(),
+		ServerURL:        s.URL(),
+		Verbose:          c.Bool("verbose"),
 	})
 	if err != nil {
 		return err
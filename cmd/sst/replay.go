@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/sst/sst/v3/cmd/sst/cli"
+	"github.com/sst/sst/v3/cmd/sst/mosaic/aws"
+	"github.com/sst/sst/v3/cmd/sst/mosaic/aws/recording"
+	"github.com/sst/sst/v3/cmd/sst/mosaic/ui"
+	"github.com/sst/sst/v3/internal/util"
+)
+
+var CmdReplay = &cli.Command{
+	Name: "replay",
+	Description: cli.Description{
+		Short: "Replay recorded invocations locally",
+		Long: strings.Join([]string{
+			"Runs invocations that were recorded in `sst dev` again on your machine, and compares",
+			"the result to the recorded one.",
+			"",
+			"While `sst dev` is running, every invocation of your functions is recorded with its",
+			"event, its response or error, and how long it took. The last 100 for each function",
+			"are kept in `.sst/recordings`.",
+			"",
+			"Pass in the request id of an invocation to replay it.",
+			"",
+			"```bash frame=\"none\"",
+			"sst replay 8f7f4d6c-55b5-4d2f-a6d5-2c1e8a0b2a1e",
+			"```",
+			"",
+			"Or the name of a function to replay its last invocations.",
+			"",
+			"```bash frame=\"none\"",
+			"sst replay MyFunction --last 5",
+			"```",
+			"",
+			"The function is run the same way as in `sst invoke`. Its logs are printed, followed by",
+			"a diff of the new result against the recorded one. It exits with an error if any of",
+			"them differ.",
+		}, "\n"),
+	},
+	Args: []cli.Argument{
+		{
+			Name:     "target",
+			Required: true,
+			Description: cli.Description{
+				Short: "A request id or a function",
+				Long:  "The request id of a recorded invocation, or the name of a function to replay its last invocations.",
+			},
+		},
+	},
+	Flags: []cli.Flag{
+		{
+			Name: "last",
+			Type: "string",
+			Description: cli.Description{
+				Short: "How many invocations to replay",
+				Long:  "The number of the most recent invocations of the function to replay. Defaults to `1`.",
+			},
+		},
+	},
+	Examples: []cli.Example{
+		{
+			Content: "sst replay MyFunction --last 5",
+			Description: cli.Description{
+				Short: "Replay the last 5 invocations of MyFunction",
+			},
+		},
+	},
+	Run: func(c *cli.Cli) error {
+		target := c.Positional(0)
+		p, err := c.InitProjectLocal()
+		if err != nil {
+			return err
+		}
+		defer p.Cleanup()
+
+		dir := recording.Dir(p.PathConfig())
+		invocations := []*recording.Invocation{}
+		if inv, err := recording.Find(dir, target); err == nil {
+			invocations = append(invocations, inv)
+		} else {
+			last := 1
+			if c.String("last") != "" {
+				last, err = strconv.Atoi(c.String("last"))
+				if err != nil || last < 1 {
+					return util.NewReadableError(nil, "--last needs to be a positive number")
+				}
+			}
+			invocations, err = recording.List(dir, recording.Query{FunctionID: target, Limit: last})
+			if err != nil {
+				return err
+			}
+			if len(invocations) == 0 {
+				return fmt.Errorf("%s: %w", target, recording.ErrNotFound)
+			}
+		}
+
+		u := ui.New(c.Context, ui.WithStderr)
+		defer u.Destroy()
+		flush := printFunctionEvents(u)
+
+		changed := 0
+		for _, inv := range invocations {
+			result, err := aws.Invoke(c.Context, p, inv.FunctionID, inv.Input)
+			flush()
+			if err != nil {
+				return err
+			}
+			replayed := &recording.Invocation{Output: recording.Raw(result.Output)}
+			if result.Error != nil {
+				replayed.Error = &recording.Error{
+					ErrorType:    result.Error.ErrorType,
+					ErrorMessage: result.Error.ErrorMessage,
+				}
+			}
+			diff := recording.Diff(inv.Result(), replayed.Result())
+			if diff == "" {
+				ui.Success(fmt.Sprintf("%s %s returned the recorded result", inv.FunctionID, inv.RequestID))
+				continue
+			}
+			changed++
+			ui.Error(fmt.Sprintf("%s %s returned a different result", inv.FunctionID, inv.RequestID))
+			for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+				switch {
+				case strings.HasPrefix(line, "+"):
+					color.Green(line)
+				case strings.HasPrefix(line, "-"):
+					color.Red(line)
+				default:
+					fmt.Println(line)
+				}
+			}
+		}
+		if changed > 0 {
+			return util.NewReadableError(nil, fmt.Sprintf("%d of %d replays returned a different result", changed, len(invocations)))
+		}
+		return nil
+	},
+}
@@ -38,12 +38,14 @@ func CmdUI(c *cli.Cli) error {
 			cloudflare.WorkerBuildEvent{},
 			cloudflare.WorkerUpdatedEvent{},
 			cloudflare.WorkerInvokedEvent{},
+			cloudflare.WorkerLogEvent{},
 			project.CompleteEvent{},
 			aws.FunctionInvokedEvent{},
 			aws.FunctionResponseEvent{},
 			aws.FunctionErrorEvent{},
 			aws.FunctionLogEvent{},
 			aws.FunctionBuildEvent{},
+			aws.FunctionDebugEvent{},
 		)
 	}
 	if filter == "task" || filter == "" {
@@ -65,6 +67,9 @@ func CmdUI(c *cli.Cli) error {
 		types = append(types,
 			common.StdoutEvent{},
 			deployer.DeployFailedEvent{},
+			deployer.DeployQueuedEvent{},
+			deployer.DeploySupersededEvent{},
+			deployer.DeployFinishedEvent{},
 			project.StackCommandEvent{},
 			project.ConcurrentUpdateEvent{},
 			project.StackCommandEvent{},
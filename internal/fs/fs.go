@@ -2,6 +2,7 @@ package fs
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -28,6 +29,26 @@ func FindUp(initialPath, fileName string) (string, error) {
 	}
 }
 
+// FindUpAny is like FindUp but matches any of the given file names. Names
+// earlier in the list win when a directory contains more than one.
+func FindUpAny(initialPath string, fileNames ...string) (string, error) {
+	currentDir := initialPath
+	for {
+		for _, fileName := range fileNames {
+			filePath := filepath.Join(currentDir, fileName)
+			if _, err := os.Stat(filePath); err == nil {
+				return filePath, nil
+			}
+		}
+
+		if currentDir == filepath.Dir(currentDir) {
+			return "", fmt.Errorf("None of '%s' found", strings.Join(fileNames, "', '"))
+		}
+
+		currentDir = filepath.Dir(currentDir)
+	}
+}
+
 func Exists(path string) bool {
 	_, err := os.Stat(path)
 	if os.IsNotExist(err) {
@@ -57,3 +78,48 @@ func FindDown(dir, filename string) []string {
 
 	return result
 }
+
+// CopyDir recursively copies src into dst, preserving file modes and symlinks.
+func CopyDir(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			os.Remove(target)
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			return CopyFile(path, target, info.Mode().Perm())
+		}
+		return nil
+	})
+}
+
+func CopyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -19,5 +19,19 @@ var SST_TELEMETRY_DISABLED = os.Getenv("SST_TELEMETRY_DISABLED") == "1" || os.Ge
 var SST_BUN_VERSION = os.Getenv("SST_BUN_VERSION")
 var SST_VERBOSE = os.Getenv("SST_VERBOSE") != ""
 var SST_EXPERIMENTAL_RUN = os.Getenv("SST_EXPERIMENTAL_RUN") != ""
+var SST_CONFIG = os.Getenv("SST_CONFIG")
+var SST_CONFIG_RUNTIME = os.Getenv("SST_CONFIG_RUNTIME")
+var SST_OFFLINE = os.Getenv("SST_OFFLINE")
+var SST_DEBUG = os.Getenv("SST_DEBUG")
+var SST_BUILD_CACHE_DIR = os.Getenv("SST_BUILD_CACHE_DIR")
+var SST_NO_BUILD_CACHE = os.Getenv("SST_NO_BUILD_CACHE") != ""
+
+// SST_SERVER_HOST opts in to serving sst dev on an address other than loopback
+var SST_SERVER_HOST = os.Getenv("SST_SERVER_HOST")
+
+// SST_DEV_SUPERSEDE cancels a deploy in sst dev when the config changes again
+var SST_DEV_SUPERSEDE = os.Getenv("SST_DEV_SUPERSEDE") != ""
+var SST_RELAY_URL = os.Getenv("SST_RELAY_URL")
+var SST_RELAY_TOKEN = os.Getenv("SST_RELAY_TOKEN")
 
 var NO_BUN = os.Getenv("NO_BUN") != ""
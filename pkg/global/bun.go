@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -43,10 +42,7 @@ func NeedsBun() bool {
 	return version != BUN_VERSION
 }
 
-func InstallBun(ctx context.Context) error {
-	slog.Info("bun install")
-	bunPath := BunPath()
-
+func bunURL() (string, error) {
 	goos := runtime.GOOS
 	arch := runtime.GOARCH
 
@@ -96,22 +92,28 @@ func InstallBun(ctx context.Context) error {
 	default:
 	}
 	if filename == "" {
-		return fmt.Errorf("unsupported platform: %s %s", goos, arch)
+		return "", fmt.Errorf("unsupported platform: %s %s", goos, arch)
 	}
 	slog.Info("bun selected", "filename", filename)
+	return "https://github.com/oven-sh/bun/releases//download/bun-v" + BUN_VERSION + "/" + filename, nil
+}
+
+func InstallBun(ctx context.Context) error {
+	slog.Info("bun install")
+	bunPath := BunPath()
+	url, err := bunURL()
+	if err != nil {
+		return err
+	}
 
-	_, err := task.Run(ctx, func() (any, error) {
-		url := "https://github.com/oven-sh/bun/releases//download/bun-v" + BUN_VERSION + "/" + filename
+	_, err = task.Run(ctx, func() (any, error) {
 		slog.Info("bun downloading", "url", url)
-		response, err := http.Get(url)
+		body, err := openArtifact(url)
 		if err != nil {
 			return nil, err
 		}
-		defer response.Body.Close()
-		if response.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("bad status: %s", response.Status)
-		}
-		bodyBytes, err := io.ReadAll(response.Body)
+		defer body.Close()
+		bodyBytes, err := io.ReadAll(body)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,286 @@
+package global
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/sst/sst/v3/pkg/flag"
+	"github.com/zeebo/xxh3"
+)
+
+// An offline mirror is a directory, or a tarball of one, that is created with
+// `sst install --bundle`. It looks like:
+//
+//	manifest.json
+//	bin/<pulumi and bun release archives>
+//	platform/package.json
+//	platform/node_modules/...
+//	plugins/<pulumi plugin directories>
+//
+// Set SST_OFFLINE to its path to install everything from it instead of the
+// internet.
+
+var ErrOfflineArtifactMissing = fmt.Errorf("not found in offline bundle")
+
+func IsOffline() bool {
+	return flag.SST_OFFLINE != ""
+}
+
+var offlineDir = struct {
+	once sync.Once
+	path string
+	err  error
+}{}
+
+// OfflineDir returns the mirror directory, extracting the bundle the first
+// time if SST_OFFLINE points to a tarball.
+func OfflineDir() (string, error) {
+	offlineDir.once.Do(func() {
+		offlineDir.path, offlineDir.err = resolveOfflineDir(flag.SST_OFFLINE)
+	})
+	return offlineDir.path, offlineDir.err
+}
+
+func resolveOfflineDir(input string) (string, error) {
+	if input == "" {
+		return "", fmt.Errorf("SST_OFFLINE is not set")
+	}
+	input, err := filepath.Abs(input)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(input)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return input, nil
+	}
+	key := xxh3.HashString(fmt.Sprintf("%v:%v:%v", input, info.Size(), info.ModTime().UnixNano()))
+	target := filepath.Join(ConfigDir(), "offline", fmt.Sprintf("%x", key))
+	if _, err := os.Stat(filepath.Join(target, "manifest.json")); err == nil {
+		return target, nil
+	}
+	slog.Info("extracting offline bundle", "from", input, "to", target)
+	os.RemoveAll(target)
+	err = os.MkdirAll(filepath.Dir(target), 0755)
+	if err != nil {
+		return "", err
+	}
+	// extracted next to the target and moved into place once it's complete,
+	// so an interrupted extract isn't mistaken for a finished one
+	staging, err := os.MkdirTemp(filepath.Dir(target), filepath.Base(target)+".tmp-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(staging)
+	file, err := os.Open(input)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	var tarReader io.Reader = reader
+	magic, err := reader.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzr, err := gzip.NewReader(reader)
+		if err != nil {
+			return "", err
+		}
+		defer gzr.Close()
+		tarReader = gzr
+	}
+	err = ExtractTar(tarReader, staging)
+	if err != nil {
+		return "", err
+	}
+	err = os.Rename(staging, target)
+	if err != nil {
+		// extracted by another process in the meantime
+		if _, statErr := os.Stat(filepath.Join(target, "manifest.json")); statErr == nil {
+			return target, nil
+		}
+		return "", err
+	}
+	return target, nil
+}
+
+var ErrInvalidTarPath = fmt.Errorf("invalid path in tarball")
+
+// ExtractTar extracts a tarball into target, keeping its directory structure.
+// Entries and symlinks that point outside of target are rejected.
+func ExtractTar(reader io.Reader, target string) error {
+	target, err := filepath.Abs(target)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(target, 0755)
+	if err != nil {
+		return err
+	}
+	root, err := filepath.EvalSymlinks(target)
+	if err != nil {
+		return err
+	}
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		outPath := filepath.Join(target, filepath.FromSlash(header.Name))
+		if !within(target, outPath) {
+			return fmt.Errorf("%w: %s", ErrInvalidTarPath, header.Name)
+		}
+		// a symlink extracted earlier can't be used to write outside of target
+		resolved, err := resolveExisting(filepath.Dir(outPath))
+		if err != nil {
+			return err
+		}
+		if !within(root, resolved) {
+			return fmt.Errorf("%w: %s", ErrInvalidTarPath, header.Name)
+		}
+		if header.Typeflag == tar.TypeSymlink {
+			link := filepath.FromSlash(header.Linkname)
+			if filepath.IsAbs(link) || !within(target, filepath.Join(filepath.Dir(outPath), link)) {
+				return fmt.Errorf("%w: %s -> %s", ErrInvalidTarPath, header.Name, header.Linkname)
+			}
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(outPath, 0755); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+				return err
+			}
+			if err := os.Symlink(header.Linkname, outPath); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+				return err
+			}
+			outFile, err := os.OpenFile(outPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode)&0777)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(outFile, tarReader); err != nil {
+				outFile.Close()
+				return err
+			}
+			outFile.Close()
+		}
+	}
+}
+
+func within(root string, path string) bool {
+	return path == root || strings.HasPrefix(path, root+string(os.PathSeparator))
+}
+
+// resolveExisting resolves the symlinks in the part of path that exists.
+func resolveExisting(path string) (string, error) {
+	rest := ""
+	for {
+		resolved, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			return filepath.Join(resolved, rest), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return "", err
+		}
+		rest = filepath.Join(filepath.Base(path), rest)
+		path = parent
+	}
+}
+
+// openArtifact downloads a release archive, or reads it from the bin
+// directory of the offline mirror.
+func openArtifact(url string) (io.ReadCloser, error) {
+	if IsOffline() {
+		dir, err := OfflineDir()
+		if err != nil {
+			return nil, err
+		}
+		file, err := os.Open(filepath.Join(dir, "bin", path.Base(url)))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("%s %w", path.Base(url), ErrOfflineArtifactMissing)
+			}
+			return nil, err
+		}
+		return file, nil
+	}
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to download %s: HTTP status %d", path.Base(url), resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// DownloadArtifacts saves the pulumi and bun release archives for this
+// platform into the bin directory of an offline mirror.
+func DownloadArtifacts(ctx context.Context, dir string) error {
+	urls := []string{}
+	url, err := pulumiURL()
+	if err != nil {
+		return err
+	}
+	urls = append(urls, url)
+	if !flag.NO_BUN {
+		url, err := bunURL()
+		if err != nil {
+			return err
+		}
+		urls = append(urls, url)
+	}
+	err = os.MkdirAll(filepath.Join(dir, "bin"), 0755)
+	if err != nil {
+		return err
+	}
+	for _, url := range urls {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		slog.Info("downloading artifact", "url", url)
+		body, err := openArtifact(url)
+		if err != nil {
+			return err
+		}
+		file, err := os.Create(filepath.Join(dir, "bin", path.Base(url)))
+		if err != nil {
+			body.Close()
+			return err
+		}
+		_, err = io.Copy(file, body)
+		body.Close()
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -5,7 +5,6 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -31,8 +30,7 @@ func NeedsPulumi() bool {
 	return version != PULUMI_VERSION
 }
 
-func InstallPulumi(ctx context.Context) error {
-	slog.Info("pulumi install")
+func pulumiURL() (string, error) {
 	var osArch string
 
 	switch runtime.GOOS {
@@ -43,7 +41,7 @@ func InstallPulumi(ctx context.Context) error {
 	case "windows":
 		osArch = "windows"
 	default:
-		return fmt.Errorf("unsupported operating system")
+		return "", fmt.Errorf("unsupported operating system")
 	}
 
 	switch runtime.GOARCH {
@@ -52,36 +50,40 @@ func InstallPulumi(ctx context.Context) error {
 	case "arm64":
 		osArch += "-arm64"
 	default:
-		return fmt.Errorf("unsupported architecture: " + runtime.GOARCH)
+		return "", fmt.Errorf("unsupported architecture: " + runtime.GOARCH)
 	}
 
 	fileExtension := ".tar.gz"
 	if runtime.GOOS == "windows" {
 		fileExtension = ".zip"
 	}
+	return fmt.Sprintf("https://github.com/pulumi/pulumi/releases/download/%v/pulumi-%s-%s%s", PULUMI_VERSION, PULUMI_VERSION, osArch, fileExtension), nil
+}
+
+func InstallPulumi(ctx context.Context) error {
+	slog.Info("pulumi install")
+	url, err := pulumiURL()
+	if err != nil {
+		return err
+	}
 
-	_, err := task.Run(ctx, func() (any, error) {
-		url := fmt.Sprintf("https://github.com/pulumi/pulumi/releases/download/%v/pulumi-%s-%s%s", PULUMI_VERSION, PULUMI_VERSION, osArch, fileExtension)
+	_, err = task.Run(ctx, func() (any, error) {
 		slog.Info("pulumi downloading", "url", url)
 
-		resp, err := http.Get(url)
+		body, err := openArtifact(url)
 		if err != nil {
 			return nil, err
 		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("failed to download pulumi: HTTP status %d", resp.StatusCode)
-		}
+		defer body.Close()
 
 		tmp := filepath.Join(BinPath(), id.Ascending())
 		err = os.MkdirAll(tmp, 0755)
 		if err != nil {
 			return nil, err
 		}
-		switch fileExtension {
-		case ".tar.gz":
-			gzr, err := gzip.NewReader(resp.Body)
+		switch {
+		case strings.HasSuffix(url, ".tar.gz"):
+			gzr, err := gzip.NewReader(body)
 			if err != nil {
 				return nil, err
 			}
@@ -0,0 +1,168 @@
+package js
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Sourcemap is a parsed v3 source map, like the ones esbuild writes next to
+// the output.
+type Sourcemap struct {
+	sources  []string
+	names    []string
+	segments [][]segment
+}
+
+type segment struct {
+	column       int
+	source       int
+	sourceLine   int
+	sourceColumn int
+	name         int
+}
+
+// Position is a location in an original source file. Line and Column start at
+// 1, like in a stack trace.
+type Position struct {
+	Source string
+	Line   int
+	Column int
+	Name   string
+}
+
+// ReadSourcemap parses the source map at the path. Sources are resolved to
+// absolute paths relative to the map.
+func ReadSourcemap(path string) (*Sourcemap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw struct {
+		Version    int      `json:"version"`
+		SourceRoot string   `json:"sourceRoot"`
+		Sources    []string `json:"sources"`
+		Names      []string `json:"names"`
+		Mappings   string   `json:"mappings"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if raw.Version != 3 {
+		return nil, fmt.Errorf("unsupported source map version %d", raw.Version)
+	}
+	dir := filepath.Dir(path)
+	result := &Sourcemap{
+		sources: make([]string, len(raw.Sources)),
+		names:   raw.Names,
+	}
+	for i, source := range raw.Sources {
+		source = strings.TrimPrefix(source, "file://")
+		if raw.SourceRoot != "" {
+			source = filepath.Join(raw.SourceRoot, source)
+		}
+		if !filepath.IsAbs(source) {
+			source = filepath.Join(dir, source)
+		}
+		result.sources[i] = filepath.Clean(source)
+	}
+	if err := result.decode(raw.Mappings); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// decode reads the base64 VLQ mappings. Every field other than the generated
+// column is relative to the previous segment across lines.
+func (s *Sourcemap) decode(mappings string) error {
+	source, sourceLine, sourceColumn, name := 0, 0, 0, 0
+	for _, line := range strings.Split(mappings, ";") {
+		segments := []segment{}
+		column := 0
+		for _, item := range strings.Split(line, ",") {
+			if item == "" {
+				continue
+			}
+			fields, err := decodeVLQ(item)
+			if err != nil {
+				return err
+			}
+			column += fields[0]
+			seg := segment{column: column, source: -1, name: -1}
+			if len(fields) >= 4 {
+				source += fields[1]
+				sourceLine += fields[2]
+				sourceColumn += fields[3]
+				seg.source = source
+				seg.sourceLine = sourceLine
+				seg.sourceColumn = sourceColumn
+			}
+			if len(fields) >= 5 {
+				name += fields[4]
+				seg.name = name
+			}
+			segments = append(segments, seg)
+		}
+		sort.SliceStable(segments, func(i, j int) bool {
+			return segments[i].column < segments[j].column
+		})
+		s.segments = append(s.segments, segments)
+	}
+	return nil
+}
+
+const base64Chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+func decodeVLQ(input string) ([]int, error) {
+	result := []int{}
+	value, shift := 0, 0
+	for _, char := range input {
+		digit := strings.IndexRune(base64Chars, char)
+		if digit == -1 {
+			return nil, fmt.Errorf("invalid character %q in source map", char)
+		}
+		value += (digit & 31) << shift
+		if digit&32 != 0 {
+			shift += 5
+			continue
+		}
+		if value&1 != 0 {
+			result = append(result, -(value >> 1))
+		} else {
+			result = append(result, value>>1)
+		}
+		value, shift = 0, 0
+	}
+	if shift != 0 {
+		return nil, fmt.Errorf("unterminated value in source map")
+	}
+	return result, nil
+}
+
+// Find returns the original position of a line and column in the generated
+// file. Both start at 1.
+func (s *Sourcemap) Find(line int, column int) (Position, bool) {
+	if line < 1 || line > len(s.segments) {
+		return Position{}, false
+	}
+	segments := s.segments[line-1]
+	index := sort.Search(len(segments), func(i int) bool {
+		return segments[i].column > column-1
+	}) - 1
+	if index < 0 || segments[index].source < 0 || segments[index].source >= len(s.sources) {
+		return Position{}, false
+	}
+	seg := segments[index]
+	result := Position{
+		Source: s.sources[seg.source],
+		Line:   seg.sourceLine + 1,
+		Column: seg.sourceColumn + 1,
+	}
+	if seg.name >= 0 && seg.name < len(s.names) {
+		result.Name = s.names[seg.name]
+	}
+	return result, true
+}
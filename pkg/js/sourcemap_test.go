@@ -0,0 +1,181 @@
+package js
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	esbuild "github.com/evanw/esbuild/pkg/api"
+)
+
+const handlerSource = `export async function handler() {
+  const value = compute(1);
+  return value;
+}
+
+function compute(input: number): number {
+  throw new Error("boom " + input);
+}
+`
+
+// build bundles the handler with esbuild and returns the output file, the
+// source map is written next to it.
+func build(t *testing.T, dir string) string {
+	t.Helper()
+	source := filepath.Join(dir, "src", "handler.ts")
+	if err := os.MkdirAll(filepath.Dir(source), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(source, []byte(handlerSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out", "handler.mjs")
+	result := esbuild.Build(esbuild.BuildOptions{
+		EntryPoints:       []string{source},
+		Outfile:           out,
+		Bundle:            true,
+		Format:            esbuild.FormatESModule,
+		Platform:          esbuild.PlatformNode,
+		Sourcemap:         esbuild.SourceMapLinked,
+		MinifyIdentifiers: true,
+		Write:             true,
+	})
+	if len(result.Errors) > 0 {
+		t.Fatal(result.Errors[0].Text)
+	}
+	return out
+}
+
+// locate returns the line and column of the text in the file, both starting
+// at 1.
+func locate(t *testing.T, file string, text string) (int, int) {
+	t.Helper()
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for index, line := range strings.Split(string(data), "\n") {
+		if column := strings.Index(line, text); column != -1 {
+			return index + 1, column + 1
+		}
+	}
+	t.Fatalf("%q is not in %s", text, file)
+	return 0, 0
+}
+
+func TestSourcemapFind(t *testing.T) {
+	dir := t.TempDir()
+	out := build(t, dir)
+	sourcemap, err := ReadSourcemap(out + ".map")
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := filepath.Join(dir, "src", "handler.ts")
+	tests := []struct {
+		name     string
+		text     string
+		expected Position
+	}{
+		{"renamed function", "o()", Position{Source: source, Line: 1, Column: 23, Name: "handler"}},
+		{"renamed call", "r(1)", Position{Source: source, Line: 2, Column: 17, Name: "compute"}},
+		{"without name", "throw", Position{Source: source, Line: 7, Column: 3}},
+		{"within a segment", "rror(", Position{Source: source, Line: 7, Column: 13}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			line, column := locate(t, out, test.text)
+			position, ok := sourcemap.Find(line, column)
+			if !ok {
+				t.Fatal("expected a position")
+			}
+			if position != test.expected {
+				t.Errorf("expected %+v, got %+v", test.expected, position)
+			}
+		})
+	}
+
+	misses := []struct {
+		name   string
+		line   int
+		column int
+	}{
+		{"line before the start", 0, 1},
+		{"line past the end", 1000, 1},
+		// the comment esbuild adds with the path of the source
+		{"line without mappings", 1, 1},
+	}
+	for _, test := range misses {
+		t.Run(test.name, func(t *testing.T) {
+			if position, ok := sourcemap.Find(test.line, test.column); ok {
+				t.Errorf("expected no position, got %+v", position)
+			}
+		})
+	}
+}
+
+func TestReadSourcemap(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+		err      bool
+	}{
+		{"relative", `{"version":3,"sources":["../src/a.ts"],"names":[],"mappings":""}`, []string{filepath.Join(dir, "src", "a.ts")}, false},
+		{"file url", `{"version":3,"sources":["file:///app/src/a.ts"],"names":[],"mappings":""}`, []string{"/app/src/a.ts"}, false},
+		{"source root", `{"version":3,"sourceRoot":"/app","sources":["src/a.ts"],"names":[],"mappings":""}`, []string{"/app/src/a.ts"}, false},
+		{"version", `{"version":2,"sources":[],"names":[],"mappings":""}`, nil, true},
+		{"invalid character", `{"version":3,"sources":[],"names":[],"mappings":"A!AA"}`, nil, true},
+		{"unterminated", `{"version":3,"sources":[],"names":[],"mappings":"AAAg"}`, nil, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, "out", strings.ReplaceAll(test.name, " ", "-")+".js.map")
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(test.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			sourcemap, err := ReadSourcemap(path)
+			if test.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(sourcemap.sources, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, sourcemap.sources)
+			}
+		})
+	}
+}
+
+func TestDecodeVLQ(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []int
+	}{
+		{"A", []int{0}},
+		{"C", []int{1}},
+		{"D", []int{-1}},
+		{"gB", []int{16}},
+		{"2H", []int{123}},
+		{"AAAA", []int{0, 0, 0, 0}},
+		{"eAAsBA", []int{15, 0, 0, 22, 0}},
+	}
+	for _, test := range tests {
+		result, err := decodeVLQ(test.input)
+		if err != nil {
+			t.Fatalf("%s: %v", test.input, err)
+		}
+		if !slices.Equal(result, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.input, test.expected, result)
+		}
+	}
+}
@@ -7,11 +7,12 @@ import (
 )
 
 func (p *Project) Add(pkg string, version string) error {
-	cmd := process.Command("node", filepath.Join(p.PathPlatformDir(), "src/ast/add.mjs"),
+	name, args := evalCommand(filepath.Join(p.PathPlatformDir(), "src/ast/add.mjs"))
+	cmd := process.Command(name, append(args,
 		p.PathConfig(),
 		pkg,
 		version,
-	)
+	)...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
@@ -0,0 +1,144 @@
+package project
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/zeebo/xxh3"
+)
+
+// configCache stores the result of evaluating `mod.app()` so commands that
+// only need the app metadata can skip building and running the config.
+type configCache struct {
+	Key   string   `json:"key"`
+	Files []string `json:"files"`
+	Env   []string `json:"env"`
+	App   string   `json:"app"`
+}
+
+// env vars that can change the output of app() without being referenced
+// directly in the config
+var configCacheEnv = []string{
+	"SST_STAGE",
+	"NODE_ENV",
+	"CI",
+	"AWS_PROFILE",
+	"AWS_REGION",
+}
+
+var envReferenceRegex = regexp.MustCompile(`(?:process\.env|Bun\.env)(?:\.([A-Za-z_][A-Za-z0-9_]*)|\[\s*["'` + "`" + `]([A-Za-z_][A-Za-z0-9_]*)["'` + "`" + `]\s*\])`)
+
+func ResolveConfigCache(cfgPath string) string {
+	return filepath.Join(ResolveWorkingDir(cfgPath), "config.cache.json")
+}
+
+// ClearConfigCache removes the cached config evaluation so the next command
+// rebuilds and evaluates sst.config.ts.
+func ClearConfigCache(cfgPath string) error {
+	err := os.Remove(ResolveConfigCache(cfgPath))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func loadConfigCache(input *ProjectConfig) (string, bool) {
+	data, err := os.ReadFile(ResolveConfigCache(input.Config))
+	if err != nil {
+		return "", false
+	}
+	var cache configCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return "", false
+	}
+	key, ok := configCacheKey(input, cache.Files, cache.Env)
+	if !ok || key != cache.Key {
+		slog.Info("config cache miss")
+		return "", false
+	}
+	slog.Info("config cache hit", "key", key)
+	return cache.App, true
+}
+
+func writeConfigCache(input *ProjectConfig, files []string, app string) error {
+	existing := []string{}
+	for _, file := range files {
+		if _, err := os.Stat(file); err == nil {
+			existing = append(existing, file)
+		}
+	}
+	sort.Strings(existing)
+	env := configEnvReferences(existing)
+	key, ok := configCacheKey(input, existing, env)
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(configCache{
+		Key:   key,
+		Files: existing,
+		Env:   env,
+		App:   app,
+	})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(ResolveConfigCache(input.Config), data, 0644)
+}
+
+// configEnvReferences returns the env vars that the config files read with a
+// literal name, along with the defaults in configCacheEnv.
+func configEnvReferences(files []string) []string {
+	seen := map[string]bool{}
+	for _, name := range configCacheEnv {
+		seen[name] = true
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		for _, match := range envReferenceRegex.FindAllSubmatch(data, -1) {
+			name := string(match[1])
+			if name == "" {
+				name = string(match[2])
+			}
+			seen[name] = true
+		}
+	}
+	result := []string{}
+	for name := range seen {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func configCacheKey(input *ProjectConfig, files []string, env []string) (string, bool) {
+	hasher := xxh3.New()
+	hasher.WriteString(input.Version)
+	hasher.WriteString("\x00" + input.Config)
+	hasher.WriteString("\x00" + input.Stage)
+	for _, name := range env {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			hasher.WriteString("\x00" + name)
+			continue
+		}
+		hasher.WriteString("\x00" + name + "=" + value)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", false
+		}
+		hasher.WriteString("\x00" + file + "\x00")
+		hasher.Write(data)
+	}
+	sum := hasher.Sum128().Bytes()
+	return hex.EncodeToString(sum[:]), true
+}
@@ -59,7 +59,7 @@ type preset struct {
 	Steps []step `json:"steps"`
 }
 
-var ErrConfigExists = fmt.Errorf("sst.config.ts already exists")
+var ErrConfigExists = fmt.Errorf("sst config already exists")
 var ErrPackageJsonInvalid = fmt.Errorf("package.json is invalid")
 
 func Create(templateName string, home string) ([]string, error) {
@@ -70,8 +70,10 @@ func Create(templateName string, home string) ([]string, error) {
 		},
 	}
 
-	if _, err := os.Stat("sst.config.ts"); err == nil {
-		return nil, ErrConfigExists
+	for _, name := range ConfigNames {
+		if _, err := os.Stat(name); err == nil {
+			return nil, ErrConfigExists
+		}
 	}
 
 	currentDirectory, err := os.Getwd()
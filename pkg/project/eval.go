@@ -0,0 +1,40 @@
+package project
+
+import (
+	"log/slog"
+	"os/exec"
+
+	"github.com/sst/sst/v3/internal/fs"
+	"github.com/sst/sst/v3/pkg/flag"
+	"github.com/sst/sst/v3/pkg/global"
+)
+
+// evalCommand returns the command used to run the built config. It can be
+// set to node, bun, or deno with SST_CONFIG_RUNTIME. Otherwise node is used
+// and bun is used as a fallback if node is not installed.
+func evalCommand(file string) (string, []string) {
+	runtime := flag.SST_CONFIG_RUNTIME
+	if runtime == "" {
+		runtime = "node"
+		if _, err := exec.LookPath("node"); err != nil && !flag.NO_BUN {
+			runtime = "bun"
+		}
+	}
+	if runtime == "bun" && flag.NO_BUN {
+		slog.Info("NO_BUN is set, evaluating config with node")
+		runtime = "node"
+	}
+	slog.Info("evaluating with", "runtime", runtime)
+	switch runtime {
+	case "bun":
+		bun := global.BunPath()
+		if !fs.Exists(bun) {
+			bun = "bun"
+		}
+		return bun, []string{"run", file}
+	case "deno":
+		return "deno", []string{"run", "--allow-all", "--quiet", "--unstable-bare-node-builtins", file}
+	default:
+		return "node", []string{"--no-warnings", file}
+	}
+}
@@ -71,12 +71,25 @@ func (p *Project) Install() error {
 		return err
 	}
 
+	err = p.lockIntegrity()
+	if err != nil {
+		return err
+	}
+
 	err = p.writeProviderLock()
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if global.IsOffline() {
+		err = installOfflinePlugins()
+		if err != nil {
+			return err
+		}
+	}
+
+	// the providers and packages the config runs against changed
+	return ClearConfigCache(p.PathConfig())
 }
 
 func (p *Project) writePackageJson() error {
@@ -167,6 +180,9 @@ func (p *Project) writeTypes() error {
 }
 
 func (p *Project) fetchDeps() error {
+	if global.IsOffline() {
+		return p.fetchOfflineDeps()
+	}
 	slog.Info("fetching deps")
 	manager := global.BunPath()
 	if flag.NO_BUN {
@@ -182,26 +198,38 @@ func (p *Project) fetchDeps() error {
 }
 
 type ProviderLockEntry struct {
-	Name    string `json:"name"`
-	Package string `json:"package"`
-	Version string `json:"version"`
-	Alias   string `json:"alias"`
+	Name      string `json:"name"`
+	Package   string `json:"package"`
+	Version   string `json:"version"`
+	Alias     string `json:"alias"`
+	Integrity string `json:"integrity,omitempty"`
 }
 
 type ProviderLock = []*ProviderLockEntry
 
 func (p *Project) loadProviderLock() error {
+	lock, err := p.readProviderLock()
+	if err != nil {
+		return err
+	}
+	p.lock = lock
+	return nil
+}
+
+// readProviderLock reads the lock file as it is on disk, empty if there isn't
+// one.
+func (p *Project) readProviderLock() (ProviderLock, error) {
 	lockPath := path.ResolveProviderLock(p.PathConfig())
 	data, err := os.ReadFile(lockPath)
 	if err != nil {
-		p.lock = ProviderLock{}
-		return nil
+		return ProviderLock{}, nil
 	}
-	err = json.Unmarshal(data, &p.lock)
+	result := ProviderLock{}
+	err = json.Unmarshal(data, &result)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return result, nil
 }
 
 func (p *Project) generateProviderLock() error {
@@ -254,6 +282,9 @@ func (p *Project) generateProviderLock() error {
 }
 
 func FindProvider(name string, version string) (*ProviderLockEntry, error) {
+	if global.IsOffline() {
+		return findOfflineProvider(name, version)
+	}
 	for _, prefix := range []string{"@sst-provider/", "@pulumi/", "@pulumiverse/", "pulumi-", "@", ""} {
 		pkg, err := npm.Get(prefix+name, version)
 		if err != nil {
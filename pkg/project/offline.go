@@ -0,0 +1,417 @@
+package project
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/sst/sst/v3/internal/fs"
+	"github.com/sst/sst/v3/pkg/flag"
+	"github.com/sst/sst/v3/pkg/global"
+	"github.com/sst/sst/v3/pkg/process"
+)
+
+type OfflineManifest struct {
+	Version   string       `json:"version"`
+	Pulumi    string       `json:"pulumi"`
+	Bun       string       `json:"bun"`
+	OS        string       `json:"os"`
+	Arch      string       `json:"arch"`
+	Providers ProviderLock `json:"providers"`
+}
+
+var ErrIntegrityMismatch = fmt.Errorf("integrity mismatch")
+
+func loadOfflineManifest() (*OfflineManifest, string, error) {
+	dir, err := global.OfflineDir()
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid offline bundle: %w", err)
+	}
+	var manifest OfflineManifest
+	err = json.Unmarshal(data, &manifest)
+	if err != nil {
+		return nil, "", err
+	}
+	if manifest.OS != runtime.GOOS || manifest.Arch != runtime.GOARCH {
+		return nil, "", fmt.Errorf("offline bundle was created for %s/%s", manifest.OS, manifest.Arch)
+	}
+	return &manifest, dir, nil
+}
+
+func findOfflineProvider(name string, version string) (*ProviderLockEntry, error) {
+	manifest, _, err := loadOfflineManifest()
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range manifest.Providers {
+		if entry.Name != name {
+			continue
+		}
+		if version != "latest" && version != entry.Version {
+			continue
+		}
+		result := *entry
+		return &result, nil
+	}
+	return nil, fmt.Errorf("provider %s@%s %w", name, version, global.ErrOfflineArtifactMissing)
+}
+
+// fetchOfflineDeps copies the platform node_modules from the offline mirror
+// after checking that it has every dependency at the version we need.
+func (p *Project) fetchOfflineDeps() error {
+	slog.Info("fetching deps from offline bundle")
+	_, dir, err := loadOfflineManifest()
+	if err != nil {
+		return err
+	}
+	wanted, err := readDependencies(filepath.Join(p.PathPlatformDir(), "package.json"))
+	if err != nil {
+		return err
+	}
+	available, err := readDependencies(filepath.Join(dir, "platform", "package.json"))
+	if err != nil {
+		return err
+	}
+	for name, version := range wanted {
+		if available[name] != version {
+			return fmt.Errorf("%s@%s %w", name, version, global.ErrOfflineArtifactMissing)
+		}
+	}
+	modules := filepath.Join(p.PathPlatformDir(), "node_modules")
+	err = os.RemoveAll(modules)
+	if err != nil {
+		return err
+	}
+	return fs.CopyDir(filepath.Join(dir, "platform", "node_modules"), modules)
+}
+
+// installOfflinePlugins copies the pulumi plugins from the offline mirror into
+// the pulumi home.
+func installOfflinePlugins() error {
+	dir, err := global.OfflineDir()
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "plugins"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		target := filepath.Join(global.ConfigDir(), "plugins", entry.Name())
+		if fs.Exists(target) {
+			continue
+		}
+		slog.Info("installing plugin from offline bundle", "name", entry.Name())
+		err := fs.CopyDir(filepath.Join(dir, "plugins", entry.Name()), target)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockIntegrity records a hash of each installed provider package. When
+// offline it checks it against the hash that was already recorded in the lock
+// file, so a bundle can't vouch for its own packages. Packages the lock file
+// has no hash for yet fall back to the hash in the bundle manifest.
+func (p *Project) lockIntegrity() error {
+	if !global.IsOffline() {
+		for _, entry := range p.lock {
+			integrity, err := packageIntegrity(filepath.Join(p.PathPlatformDir(), "node_modules", entry.Package))
+			if err != nil {
+				return err
+			}
+			entry.Integrity = integrity
+		}
+		return nil
+	}
+	manifest, _, err := loadOfflineManifest()
+	if err != nil {
+		return err
+	}
+	recorded, err := p.readProviderLock()
+	if err != nil {
+		return err
+	}
+	for _, entry := range p.lock {
+		integrity, err := packageIntegrity(filepath.Join(p.PathPlatformDir(), "node_modules", entry.Package))
+		if err != nil {
+			return err
+		}
+		expected := findIntegrity(recorded, entry)
+		if expected == "" {
+			slog.Warn("no recorded integrity for provider, trusting the offline bundle", "package", entry.Package, "version", entry.Version)
+			expected = findIntegrity(manifest.Providers, entry)
+		}
+		if expected != integrity {
+			return fmt.Errorf("%s@%s %w", entry.Package, entry.Version, ErrIntegrityMismatch)
+		}
+		entry.Integrity = integrity
+	}
+	return nil
+}
+
+func findIntegrity(lock ProviderLock, entry *ProviderLockEntry) string {
+	for _, item := range lock {
+		if item.Package == entry.Package && item.Version == entry.Version {
+			return item.Integrity
+		}
+	}
+	return ""
+}
+
+// packageIntegrity hashes every file in an installed package.
+func packageIntegrity(dir string) (string, error) {
+	hash := sha256.New()
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			io.WriteString(hash, filepath.ToSlash(rel)+"\x00->"+link+"\x00")
+		case info.Mode().IsRegular():
+			io.WriteString(hash, filepath.ToSlash(rel)+"\x00")
+			file, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			if _, err := io.Copy(hash, file); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return "sha256-" + base64.StdEncoding.EncodeToString(hash.Sum(nil)), nil
+}
+
+func readDependencies(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var parsed struct {
+		Dependencies map[string]string `json:"dependencies"`
+	}
+	err = json.Unmarshal(data, &parsed)
+	if err != nil {
+		return nil, err
+	}
+	return parsed.Dependencies, nil
+}
+
+// Bundle writes everything needed to run `sst install` without internet
+// access to a tarball. The project needs to be installed first.
+func (p *Project) Bundle(ctx context.Context, output string) error {
+	slog.Info("creating offline bundle", "output", output)
+	staging, err := os.MkdirTemp("", "sst-bundle-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	err = global.DownloadArtifacts(ctx, staging)
+	if err != nil {
+		return err
+	}
+
+	plugins, err := p.installPlugins(ctx)
+	if err != nil {
+		return err
+	}
+
+	manifest := OfflineManifest{
+		Version:   p.version,
+		Pulumi:    global.PULUMI_VERSION,
+		Bun:       global.BUN_VERSION,
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		Providers: p.lock,
+	}
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(filepath.Join(staging, "manifest.json"), manifestBytes, 0644)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	err = writeBundle(ctx, file, output, p.bundleSources(staging, plugins))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// a partial bundle would fail to install later on
+		os.Remove(output)
+		return err
+	}
+	return nil
+}
+
+func (p *Project) bundleSources(staging string, plugins []string) [][2]string {
+	sources := [][2]string{
+		{staging, ""},
+		{filepath.Join(p.PathPlatformDir(), "package.json"), "platform/package.json"},
+		{filepath.Join(p.PathPlatformDir(), "node_modules"), "platform/node_modules"},
+	}
+	for _, plugin := range plugins {
+		sources = append(sources, [2]string{
+			filepath.Join(global.ConfigDir(), "plugins", plugin),
+			"plugins/" + plugin,
+		})
+	}
+	return sources
+}
+
+// writeBundle writes the sources to a tarball, gzipped if the output is named
+// like one. Closing the writers flushes them, so their errors are returned.
+func writeBundle(ctx context.Context, file io.Writer, output string, sources [][2]string) error {
+	var gzw *gzip.Writer
+	writer := file
+	if strings.HasSuffix(output, ".gz") || strings.HasSuffix(output, ".tgz") {
+		gzw = gzip.NewWriter(file)
+		writer = gzw
+	}
+	tw := tar.NewWriter(writer)
+	for _, source := range sources {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		err := addToTar(tw, source[0], source[1])
+		if err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if gzw != nil {
+		return gzw.Close()
+	}
+	return nil
+}
+
+// installPlugins makes sure the pulumi plugins for every platform dependency
+// are in the pulumi home and returns their directory names.
+func (p *Project) installPlugins(ctx context.Context) ([]string, error) {
+	dependencies, err := readDependencies(filepath.Join(p.PathPlatformDir(), "package.json"))
+	if err != nil {
+		return nil, err
+	}
+	pulumiPath := flag.SST_PULUMI_PATH
+	if pulumiPath == "" {
+		pulumiPath = filepath.Join(global.BinPath(), "..")
+	}
+	result := []string{}
+	for name := range dependencies {
+		data, err := os.ReadFile(filepath.Join(p.PathPlatformDir(), "node_modules", name, "package.json"))
+		if err != nil {
+			continue
+		}
+		var pkg struct {
+			Pulumi *struct {
+				Resource bool   `json:"resource"`
+				Name     string `json:"name"`
+				Version  string `json:"version"`
+				Server   string `json:"server"`
+			} `json:"pulumi"`
+		}
+		if err := json.Unmarshal(data, &pkg); err != nil || pkg.Pulumi == nil || !pkg.Pulumi.Resource {
+			continue
+		}
+		dir := fmt.Sprintf("resource-%s-v%s", pkg.Pulumi.Name, pkg.Pulumi.Version)
+		if !fs.Exists(filepath.Join(global.ConfigDir(), "plugins", dir)) {
+			slog.Info("installing plugin", "name", pkg.Pulumi.Name, "version", pkg.Pulumi.Version)
+			args := []string{"plugin", "install", "resource", pkg.Pulumi.Name, pkg.Pulumi.Version}
+			if pkg.Pulumi.Server != "" {
+				args = append(args, "--server", pkg.Pulumi.Server)
+			}
+			cmd := process.CommandContext(ctx, filepath.Join(pulumiPath, "bin/pulumi"), args...)
+			cmd.Env = append(os.Environ(), "PULUMI_HOME="+global.ConfigDir())
+			output, err := cmd.CombinedOutput()
+			if err != nil {
+				return nil, fmt.Errorf("failed to install plugin %s: %s", pkg.Pulumi.Name, output)
+			}
+		}
+		result = append(result, dir)
+	}
+	return result, nil
+}
+
+func addToTar(tw *tar.Writer, src string, prefix string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(filepath.Join(prefix, rel))
+		if name == "." || name == "" {
+			return nil
+		}
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err = os.Readlink(path)
+			if err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tw, file)
+		return err
+	})
+}
@@ -34,6 +34,11 @@ func (p *Project) CopyPlatform(version string) error {
 		return err
 	}
 	p.lock = ProviderLock{}
+	// the config is evaluated against the platform, and not all of it is an
+	// input of the config build the cache is keyed on
+	if err := ClearConfigCache(p.PathConfig()); err != nil {
+		return err
+	}
 	if version == "dev" {
 		currentExecutable, _ := os.Executable()
 		info, _ := os.Stat(currentExecutable)
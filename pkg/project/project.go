@@ -22,6 +22,8 @@ import (
 	"github.com/sst/sst/v3/pkg/runtime/golang"
 	"github.com/sst/sst/v3/pkg/runtime/node"
 	"github.com/sst/sst/v3/pkg/runtime/python"
+	"github.com/sst/sst/v3/pkg/runtime/ruby"
+	"github.com/sst/sst/v3/pkg/runtime/rust"
 	"github.com/sst/sst/v3/pkg/runtime/worker"
 )
 
@@ -51,15 +53,43 @@ type Project struct {
 	Runtime         *runtime.Collection
 }
 
+var ConfigNames = []string{
+	"sst.config.ts",
+	"sst.config.mts",
+	"sst.config.js",
+	"sst.config.mjs",
+}
+
+var ErrConfigNotFound = fmt.Errorf("config not found")
+
 func Discover() (string, error) {
+	if flag.SST_CONFIG != "" {
+		return DiscoverPath(flag.SST_CONFIG)
+	}
 	cwd, err := os.Getwd()
 	if err != nil {
 		return "", err
 	}
-	cfgPath, err := fs.FindUp(cwd, "sst.config.ts")
+	cfgPath, err := fs.FindUpAny(cwd, ConfigNames...)
+	if err != nil {
+		return "", err
+	}
+	err = os.MkdirAll(ResolveWorkingDir(cfgPath), 0755)
 	if err != nil {
 		return "", err
 	}
+	return cfgPath, nil
+}
+
+// DiscoverPath resolves an explicitly passed in config file.
+func DiscoverPath(input string) (string, error) {
+	cfgPath, err := filepath.Abs(input)
+	if err != nil {
+		return "", err
+	}
+	if !fs.Exists(cfgPath) {
+		return "", fmt.Errorf("%w: %s", ErrConfigNotFound, cfgPath)
+	}
 	err = os.MkdirAll(ResolveWorkingDir(cfgPath), 0755)
 	if err != nil {
 		return "", err
@@ -83,6 +113,7 @@ type ProjectConfig struct {
 	Version string
 	Stage   string
 	Config  string
+	NoCache bool
 }
 
 var ErrInvalidStageName = fmt.Errorf("invalid stage name")
@@ -110,10 +141,14 @@ func New(input *ProjectConfig) (*Project, error) {
 		env:     map[string]string{},
 		Runtime: runtime.NewCollection(
 			input.Config,
+			input.Stage,
+			input.Version,
 			node.New(input.Version),
 			worker.New(),
 			python.New(),
 			golang.New(),
+			rust.New(),
+			ruby.New(),
 		),
 	}
 	tmp := proj.PathWorkingDir()
@@ -129,9 +164,117 @@ func New(input *ProjectConfig) (*Project, error) {
 		}
 	}
 
+	appJson, ok := "", false
+	if !input.NoCache {
+		appJson, ok = loadConfigCache(input)
+	}
+	if !ok {
+		appJson, err = proj.evaluateConfig(input)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var parsed App
+	err = json.Unmarshal([]byte(appJson), &parsed)
+	if err != nil {
+		return nil, err
+	}
+	proj.app = &parsed
+	proj.app.Stage = input.Stage
+
+	if proj.app.Providers == nil {
+		proj.app.Providers = map[string]interface{}{}
+	}
+
+	for name, args := range proj.app.Providers {
+		if argsBool, ok := args.(bool); ok && argsBool {
+			proj.app.Providers[name] = make(map[string]interface{})
+		}
+
+		if argsString, ok := args.(string); ok {
+			proj.app.Providers[name] = map[string]interface{}{
+				"version": argsString,
+			}
+		}
+	}
+
+	if proj.app.Name == "" {
+		return nil, fmt.Errorf("Project name is required")
+	}
+
+	if InvalidAppRegex.MatchString(proj.app.Name) {
+		return nil, ErrInvalidAppName
+	}
+
+	// Check if app name has changed by comparing the folder name inside ".pulumi/stacks"
+	// and the app name in the config file.
+	stacksDir := filepath.Join(proj.PathWorkingDir(), ".pulumi", "stacks")
+	files, err := os.ReadDir(stacksDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		files = []os.DirEntry{}
+	}
+	if len(files) > 0 {
+		appName := files[0].Name()
+		if appName != proj.app.Name {
+			return nil, ErrAppNameChanged
+		}
+	}
+
+	if proj.app.Home == "" {
+		return nil, util.NewReadableError(nil, `You must specify a "home" provider in the project configuration file.`)
+	}
+
+	if _, ok := proj.app.Providers[proj.app.Home]; !ok && proj.app.Home != "local" {
+		proj.app.Providers[proj.app.Home] = map[string]interface{}{}
+	}
+
+	if proj.app.RemovalPolicy != "" {
+		return nil, util.NewReadableError(nil, `The "removalPolicy" has been renamed to "removal"`)
+	}
+
+	if proj.app.Removal == "" {
+		proj.app.Removal = "retain"
+	}
+
+	if proj.app.Version != "" && input.Version != "dev" {
+		constraint, err := semver.NewConstraint(proj.app.Version)
+		if err != nil {
+			return nil, ErrVersionInvalid
+		}
+		version, err := semver.NewVersion(input.Version)
+		if err != nil {
+			return nil, ErrVersionInvalid
+		}
+		if !constraint.Check(version) {
+			return nil, fmt.Errorf("%wYou are using v%s which does not match v%s in your \"%s\".", ErrVersionMismatch, input.Version, proj.app.Version, filepath.Base(input.Config))
+		}
+	}
+
+	if proj.app.Removal != "remove" && proj.app.Removal != "retain" && proj.app.Removal != "retain-all" {
+		return nil, fmt.Errorf("Removal must be one of: remove, retain, retain-all")
+	}
+
+	err = proj.loadProviderLock()
+	if err != nil {
+		return nil, err
+	}
+
+	return proj, nil
+}
+
+// evaluateConfig builds the config and runs mod.app() to get the app
+// metadata as JSON. The result is written to the config cache.
+func (proj *Project) evaluateConfig(input *ProjectConfig) (string, error) {
 	inputBytes, err := json.Marshal(map[string]string{
 		"stage": input.Stage,
 	})
+	if err != nil {
+		return "", err
+	}
 	buildResult, err := js.Build(
 		js.EvalOptions{
 			Dir:    proj.PathRoot(),
@@ -152,119 +295,50 @@ console.log("~j" + JSON.stringify(mod.app({
 		},
 	)
 	if err != nil {
-		return nil, fmt.Errorf("%w%s", ErrBuildFailed, err)
+		return "", fmt.Errorf("%w%s", ErrBuildFailed, err)
 	}
 	defer js.Cleanup(buildResult)
 
 	slog.Info("evaluating config")
-	node := process.Command("node", "--no-warnings", string(buildResult.OutputFiles[1].Path))
-	output, err := node.CombinedOutput()
+	name, args := evalCommand(string(buildResult.OutputFiles[1].Path))
+	cmd := process.Command(name, args...)
+	output, err := cmd.CombinedOutput()
 	slog.Info("config evaluated")
 	if err != nil {
-		return nil, fmt.Errorf("Error evaluating config: %w\n%s", err, output)
+		return "", fmt.Errorf("Error evaluating config: %w\n%s", err, output)
 	}
 	scanner := bufio.NewScanner(bytes.NewReader(output))
 	for scanner.Scan() {
 		line := scanner.Text()
 		if line == "~v2" {
-			return nil, ErrV2Config
+			return "", ErrV2Config
 		}
 		if strings.HasPrefix(line, "~j") {
-			var parsed App
-			err = json.Unmarshal([]byte(line[2:]), &parsed)
+			result := line[2:]
+			var meta js.Metafile
+			err = json.Unmarshal([]byte(buildResult.Metafile), &meta)
 			if err != nil {
-				return nil, err
-			}
-			proj.app = &parsed
-			proj.app.Stage = input.Stage
-
-			if proj.app.Providers == nil {
-				proj.app.Providers = map[string]interface{}{}
-			}
-
-			for name, args := range proj.app.Providers {
-				if argsBool, ok := args.(bool); ok && argsBool {
-					proj.app.Providers[name] = make(map[string]interface{})
-				}
-
-				if argsString, ok := args.(string); ok {
-					proj.app.Providers[name] = map[string]interface{}{
-						"version": argsString,
-					}
-				}
-			}
-
-			if proj.app.Name == "" {
-				return nil, fmt.Errorf("Project name is required")
+				return "", err
 			}
-
-			if InvalidAppRegex.MatchString(proj.app.Name) {
-				return nil, ErrInvalidAppName
-			}
-
-			// Check if app name has changed by comparing the folder name inside ".pulumi/stacks"
-			// and the app name in the config file.
-			stacksDir := filepath.Join(proj.PathWorkingDir(), ".pulumi", "stacks")
-			files, err := os.ReadDir(stacksDir)
-			if err != nil {
-				if !os.IsNotExist(err) {
-					return nil, err
-				}
-				files = []os.DirEntry{}
-			}
-			if len(files) > 0 {
-				appName := files[0].Name()
-				if appName != proj.app.Name {
-					return nil, ErrAppNameChanged
-				}
-			}
-
-			if proj.app.Home == "" {
-				return nil, util.NewReadableError(nil, `You must specify a "home" provider in the project configuration file.`)
-			}
-
-			if _, ok := proj.app.Providers[proj.app.Home]; !ok && proj.app.Home != "local" {
-				proj.app.Providers[proj.app.Home] = map[string]interface{}{}
-			}
-
-			if proj.app.RemovalPolicy != "" {
-				return nil, util.NewReadableError(nil, `The "removalPolicy" has been renamed to "removal"`)
-			}
-
-			if proj.app.Removal == "" {
-				proj.app.Removal = "retain"
-			}
-
-			if proj.app.Version != "" && input.Version != "dev" {
-				constraint, err := semver.NewConstraint(proj.app.Version)
-				if err != nil {
-					return nil, ErrVersionInvalid
-				}
-				version, err := semver.NewVersion(input.Version)
+			files := []string{}
+			for key := range meta.Inputs {
+				absPath, err := filepath.Abs(key)
 				if err != nil {
-					return nil, ErrVersionInvalid
-				}
-				if !constraint.Check(version) {
-					return nil, fmt.Errorf("%wYou are using v%s which does not match v%s in your \"sst.config.ts\".", ErrVersionMismatch, input.Version, proj.app.Version)
+					continue
 				}
+				files = append(files, absPath)
 			}
-
-			if proj.app.Removal != "remove" && proj.app.Removal != "retain" && proj.app.Removal != "retain-all" {
-				return nil, fmt.Errorf("Removal must be one of: remove, retain, retain-all")
+			err = writeConfigCache(input, files, result)
+			if err != nil {
+				slog.Error("failed to write config cache", "err", err)
 			}
-			continue
+			return result, nil
 		}
 	}
 	if err := scanner.Err(); err != nil {
-		return nil, err
-	}
-
-	err = proj.loadProviderLock()
-	if err != nil {
-		return nil, err
+		return "", err
 	}
-
-	return proj, nil
+	return "", fmt.Errorf("Error evaluating config: no app returned\n%s", output)
 }
 
 func (proj *Project) LoadHome() error {
@@ -136,6 +136,7 @@ type Update struct {
 	RunID         string         `json:"runID,omitempty"`
 	Version       string         `json:"version"`
 	Command       string         `json:"command"`
+	Attempt       int            `json:"attempt,omitempty"`
 	Errors        []SummaryError `json:"errors"`
 	TimeStarted   string         `json:"timeStarted"`
 	TimeCompleted string         `json:"timeCompleted,omitempty"`
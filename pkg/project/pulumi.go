@@ -0,0 +1,81 @@
+package project
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/sst/sst/v3/pkg/process"
+)
+
+// pulumiCommand runs pulumi for the automation api. The automation api kills
+// pulumi 10 seconds after its context is cancelled, which can leave pending
+// operations and the lock in the state. When a run is superseded pulumi is
+// interrupted and waited on instead, like in RunNext.
+type pulumiCommand struct {
+	auto.PulumiCommand
+	path string
+}
+
+func (c *pulumiCommand) Run(
+	ctx context.Context,
+	workdir string,
+	stdin io.Reader,
+	additionalOutput []io.Writer,
+	additionalErrorOutput []io.Writer,
+	additionalEnv []string,
+	args ...string,
+) (string, string, int, error) {
+	if !slices.Contains(args, "--non-interactive") {
+		args = append(args, "--non-interactive")
+	}
+	cmd := process.Command(c.path, args...)
+	cmd.Dir = workdir
+	cmd.Env = append(os.Environ(), "PATH="+filepath.Dir(c.path)+string(os.PathListSeparator)+os.Getenv("PATH"))
+	cmd.Env = append(cmd.Env, additionalEnv...)
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = io.MultiWriter(append(additionalOutput, &stdout)...)
+	cmd.Stderr = io.MultiWriter(append(additionalErrorOutput, &stderr)...)
+	cmd.Stdin = stdin
+	// the terminal's interrupt would reach pulumi before sst decides what to do
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		return "", "", -2, err
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			cmd.Process.Signal(syscall.SIGINT)
+			// pulumi cancels the update and waits for pending operations
+			if context.Cause(ctx) == ErrSuperseded {
+				return
+			}
+			select {
+			case <-stopped:
+			case <-time.After(10 * time.Second):
+				cmd.Process.Kill()
+			}
+		}
+	}()
+	err := cmd.Wait()
+	close(stopped)
+
+	code := -2
+	if exit, ok := err.(*exec.ExitError); ok {
+		code = exit.ExitCode()
+	} else if err == nil {
+		code = 0
+	}
+	return stdout.String(), stderr.String(), code, err
+}
@@ -0,0 +1,140 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/sst/sst/v3/pkg/bus"
+)
+
+type RetryPolicy struct {
+	// Attempts is the number of times a failed run is retried.
+	Attempts int
+	// Backoff is the delay before the first retry. It doubles on every
+	// attempt up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Patterns are matched against the error messages. A run is only retried
+	// if every error matches one of them.
+	Patterns []*regexp.Regexp
+}
+
+var RetryablePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`Throttling|ThrottlingException|TooManyRequestsException|RequestLimitExceeded|Rate exceeded|SlowDown`),
+	regexp.MustCompile(`ConcurrentModificationException|OperationAbortedException|PriorRequestNotComplete`),
+	// conflicts that clear up once an update in progress finishes, not ones
+	// like a resource that already exists
+	regexp.MustCompile(`ResourceConflictException: (The operation cannot be performed at this time|An update is in progress)`),
+	regexp.MustCompile(`ConflictException: Unable to complete operation due to concurrent modification`),
+	// a role that was just created and hasn't propagated yet
+	regexp.MustCompile(`The role defined for the function cannot be assumed by Lambda`),
+	regexp.MustCompile(`The provided execution role does not have permissions to call`),
+	regexp.MustCompile(`ServiceUnavailable|InternalFailure|InternalError|RequestTimeout|connection reset by peer|i/o timeout|TLS handshake timeout`),
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:   2,
+	Backoff:    5 * time.Second,
+	MaxBackoff: time.Minute,
+	Patterns:   RetryablePatterns,
+}
+
+// RetryEvent is published before a failed run is retried.
+type RetryEvent struct {
+	Attempt int
+	Max     int
+	Delay   time.Duration
+	Targets []string
+	Errors  []Error
+}
+
+// StackRunError is returned when the stack command ran but some resources
+// failed.
+type StackRunError struct {
+	Errors []Error
+}
+
+func (e *StackRunError) Error() string {
+	return ErrStackRunFailed.Error()
+}
+
+func (e *StackRunError) Is(target error) bool {
+	return target == ErrStackRunFailed
+}
+
+// Retryable returns true if every error is tied to a resource and matches
+// one of the retryable patterns.
+func (r *RetryPolicy) Retryable(errs []Error) bool {
+	if len(errs) == 0 {
+		return false
+	}
+	for _, item := range errs {
+		if item.URN == "" {
+			return false
+		}
+		matched := false
+		for _, pattern := range r.Patterns {
+			if pattern.MatchString(item.Message) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *RetryPolicy) delay(attempt int) time.Duration {
+	result := r.Backoff
+	for i := 1; i < attempt; i++ {
+		result *= 2
+		if r.MaxBackoff > 0 && result > r.MaxBackoff {
+			return r.MaxBackoff
+		}
+	}
+	return result
+}
+
+// runWithRetry reruns a failed deploy, targeting only the failed resources
+// and their dependents, as long as all the errors are retryable.
+func (p *Project) runWithRetry(ctx context.Context, input *StackInput, run func(context.Context, *StackInput) error) error {
+	next := *input
+	for attempt := 1; ; attempt++ {
+		next.Attempt = attempt
+		err := run(ctx, &next)
+		if err == nil || input.Retry == nil || input.Command != "deploy" {
+			return err
+		}
+		var runErr *StackRunError
+		if !errors.As(err, &runErr) || !input.Retry.Retryable(runErr.Errors) {
+			return err
+		}
+		if attempt > input.Retry.Attempts {
+			return err
+		}
+		targets := []string{}
+		for _, item := range runErr.Errors {
+			targets = append(targets, item.URN)
+		}
+		delay := input.Retry.delay(attempt)
+		slog.Info("retrying stack command", "attempt", attempt+1, "delay", delay, "targets", targets)
+		bus.Publish(&RetryEvent{
+			Attempt: attempt + 1,
+			Max:     input.Retry.Attempts + 1,
+			Delay:   delay,
+			Targets: targets,
+			Errors:  runErr.Errors,
+		})
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		next.Target = targets
+	}
+}
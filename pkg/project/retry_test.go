@@ -0,0 +1,50 @@
+package project
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryable(t *testing.T) {
+	urn := "urn:pulumi:dev::app::sst:aws:Function$aws:lambda/function:Function::MyFunction"
+	tests := []struct {
+		name     string
+		errs     []Error
+		expected bool
+	}{
+		{"no errors", []Error{}, false},
+		{"throttled", []Error{{URN: urn, Message: "ThrottlingException: Rate exceeded"}}, true},
+		{"update in progress", []Error{{URN: urn, Message: "ResourceConflictException: The operation cannot be performed at this time. An update is in progress for resource: arn:aws:lambda:us-east-1:123:function:fn"}}, true},
+		{"concurrent modification", []Error{{URN: urn, Message: "ConflictException: Unable to complete operation due to concurrent modification. Please try again later."}}, true},
+		{"role not propagated", []Error{{URN: urn, Message: "InvalidParameterValueException: The role defined for the function cannot be assumed by Lambda."}}, true},
+		{"already exists", []Error{{URN: urn, Message: "ResourceConflictException: Function already exist: fn"}}, false},
+		{"conflict", []Error{{URN: urn, Message: "ConflictException: Domain name already exists"}}, false},
+		{"not authorized", []Error{{URN: urn, Message: "AccessDenied: User: arn:aws:iam::123:user/dev is not authorized to perform: sts:AssumeRole on resource: arn:aws:iam::123:role/deploy"}}, false},
+		{"without resource", []Error{{Message: "ThrottlingException: Rate exceeded"}}, false},
+		{"one permanent", []Error{
+			{URN: urn, Message: "ThrottlingException: Rate exceeded"},
+			{URN: urn, Message: "ValidationException: invalid runtime"},
+		}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := DefaultRetryPolicy.Retryable(test.errs); got != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := RetryPolicy{Backoff: 5 * time.Second, MaxBackoff: 30 * time.Second}
+	expected := []time.Duration{5 * time.Second, 10 * time.Second, 20 * time.Second, 30 * time.Second, 30 * time.Second}
+	for index, delay := range expected {
+		if got := policy.delay(index + 1); got != delay {
+			t.Errorf("attempt %d: expected %v, got %v", index+1, delay, got)
+		}
+	}
+	unbounded := RetryPolicy{Backoff: time.Second}
+	if got := unbounded.delay(4); got != 8*time.Second {
+		t.Errorf("expected 8s, got %v", got)
+	}
+}
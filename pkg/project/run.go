@@ -12,6 +12,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
@@ -29,12 +30,16 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
+// ErrSuperseded is the cause a run is cancelled with when a newer one replaces
+// it. Unlike the user interrupting, pulumi hasn't been signalled yet.
+var ErrSuperseded = fmt.Errorf("superseded by a newer run")
+
 func (p *Project) Run(ctx context.Context, input *StackInput) error {
 	if flag.SST_EXPERIMENTAL_RUN {
 		slog.Info("using next run system")
-		return p.RunNext(ctx, input)
+		return p.runWithRetry(ctx, input, p.RunNext)
 	}
-	return p.RunOld(ctx, input)
+	return p.runWithRetry(ctx, input, p.RunOld)
 }
 
 func (p *Project) RunNext(ctx context.Context, input *StackInput) error {
@@ -50,6 +55,8 @@ func (p *Project) RunNext(ctx context.Context, input *StackInput) error {
 		Config:  p.PathConfig(),
 		Command: input.Command,
 		Version: p.Version(),
+		Attempt: input.Attempt,
+		Target:  input.Target,
 	})
 
 	updateID := id.Descending()
@@ -140,12 +147,12 @@ func (p *Project) RunNext(ctx context.Context, input *StackInput) error {
 		Globals: strings.Join(providerShim, "\n"),
 		Code: fmt.Sprintf(`
       import { run } from "%v";
-      import mod from "%v/sst.config.ts";
+      import mod from "%v";
       const result = await run(mod.run);
       export default result;
     `,
 			path.Join(p.PathWorkingDir(), "platform/src/auto/run.ts"),
-			p.PathRoot(),
+			p.PathConfig(),
 		),
 	})
 	if err != nil {
@@ -227,8 +234,8 @@ func (p *Project) RunNext(ctx context.Context, input *StackInput) error {
 		"NODE_OPTIONS=--enable-source-maps --no-deprecation",
 		"PULUMI_HOME="+global.ConfigDir(),
 	)
-	if input.ServerPort != 0 {
-		env = append(env, "SST_SERVER=http://localhost:"+fmt.Sprint(input.ServerPort))
+	if input.ServerURL != "" {
+		env = append(env, "SST_SERVER="+input.ServerURL)
 	}
 	pulumiPath := flag.SST_PULUMI_PATH
 	if pulumiPath == "" {
@@ -269,6 +276,13 @@ func (p *Project) RunNext(ctx context.Context, input *StackInput) error {
 		}
 	}
 
+	for _, target := range input.Target {
+		args = append(args, "--target", target)
+	}
+	if len(input.Target) > 0 && input.TargetDependents {
+		args = append(args, "--target-dependents")
+	}
+
 	switch input.Command {
 	case "diff":
 		args = append([]string{"diff"}, args...)
@@ -335,11 +349,17 @@ func (p *Project) RunNext(ctx context.Context, input *StackInput) error {
 		exited <- cmd.Wait()
 	}()
 
+	stopped := make(chan struct{})
+	defer close(stopped)
 	go func() {
-		<-ctx.Done()
-		// if cmd.Process != nil {
-		// 	cmd.Process.Signal(syscall.SIGINT)
-		// }
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			// pulumi cancels the update and waits for pending operations
+			if context.Cause(ctx) == ErrSuperseded {
+				cmd.Process.Signal(syscall.SIGINT)
+			}
+		}
 	}()
 
 	eventLog, err := os.OpenFile(eventLogPath, os.O_RDWR|os.O_CREATE, 0644)
@@ -474,6 +494,7 @@ loop:
 		update.ID = updateID
 		update.Command = input.Command
 		update.Version = p.Version()
+		update.Attempt = input.Attempt
 		update.TimeStarted = started
 		update.TimeCompleted = time.Now().Format(time.RFC3339)
 		for _, err := range errors {
@@ -490,7 +511,7 @@ loop:
 
 	slog.Info("done running stack command")
 	if cmd.ProcessState.ExitCode() > 0 {
-		return ErrStackRunFailed
+		return &StackRunError{Errors: errors}
 	}
 	return nil
 }
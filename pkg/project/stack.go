@@ -41,13 +41,16 @@ type BuildFailedEvent struct {
 }
 
 type StackInput struct {
-	Command    string
-	Target     []string
-	ServerPort int
-	Dev        bool
-	Verbose    bool
-	Continue   bool
-	SkipHash   string
+	Command          string
+	Target           []string
+	TargetDependents bool
+	ServerURL        string
+	Dev              bool
+	Verbose          bool
+	Continue         bool
+	SkipHash         string
+	Retry            *RetryPolicy
+	Attempt          int
 }
 
 type ConcurrentUpdateEvent struct{}
@@ -71,7 +74,21 @@ type Dev struct {
 	Aws         *struct {
 		Role string `json:"role"`
 	} `json:"aws"`
+	Restart   string    `json:"restart"`
+	DependsOn []string  `json:"dependsOn"`
+	Ready     *DevReady `json:"ready"`
 }
+
+// DevReady is how sst dev tells that a dev process is ready. Every check that's
+// set has to pass.
+type DevReady struct {
+	Port int    `json:"port"`
+	URL  string `json:"url"`
+	Log  string `json:"log"`
+	// in seconds
+	Timeout int `json:"timeout"`
+}
+
 type Devs map[string]Dev
 
 type Task struct {
@@ -115,6 +132,8 @@ type StackCommandEvent struct {
 	Config  string
 	Command string
 	Version string
+	Attempt int
+	Target  []string
 }
 
 type Error struct {
@@ -183,6 +202,8 @@ func (p *Project) RunOld(ctx context.Context, input *StackInput) error {
 		Config:  p.PathConfig(),
 		Command: input.Command,
 		Version: p.Version(),
+		Attempt: input.Attempt,
+		Target:  input.Target,
 	})
 
 	updateID := id.Descending()
@@ -261,10 +282,13 @@ func (p *Project) RunOld(ctx context.Context, input *StackInput) error {
 	env["PULUMI_CONFIG_PASSPHRASE"] = passphrase
 	env["PULUMI_SKIP_UPDATE_CHECK"] = "true"
 	// env["PULUMI_DISABLE_AUTOMATIC_PLUGIN_ACQUISITION"] = "true"
+	if global.IsOffline() {
+		env["PULUMI_DISABLE_AUTOMATIC_PLUGIN_ACQUISITION"] = "true"
+	}
 	env["NODE_OPTIONS"] = "--enable-source-maps --no-deprecation"
 	// env["TMPDIR"] = p.PathLog("")
-	if input.ServerPort != 0 {
-		env["SST_SERVER"] = fmt.Sprintf("http://localhost:%v", input.ServerPort)
+	if input.ServerURL != "" {
+		env["SST_SERVER"] = input.ServerURL
 	}
 	pulumiPath := flag.SST_PULUMI_PATH
 	if pulumiPath == "" {
@@ -278,7 +302,10 @@ func (p *Project) RunOld(ctx context.Context, input *StackInput) error {
 		return err
 	}
 	ws, err := auto.NewLocalWorkspace(ctx,
-		auto.Pulumi(pulumi),
+		auto.Pulumi(&pulumiCommand{
+			PulumiCommand: pulumi,
+			path:          filepath.Join(pulumiPath, "bin", "pulumi"),
+		}),
 		auto.WorkDir(workdir.Backend()),
 		auto.PulumiHome(global.ConfigDir()),
 		auto.Project(workspace.Project{
@@ -360,12 +387,12 @@ func (p *Project) RunOld(ctx context.Context, input *StackInput) error {
 		Globals: strings.Join(providerShim, "\n"),
 		Code: fmt.Sprintf(`
       import { run } from "%v";
-      import mod from "%v/sst.config.ts";
+      import mod from "%v";
       const result = await run(mod.run);
       export default result;
     `,
 			path.Join(p.PathWorkingDir(), "platform/src/auto/run.ts"),
-			p.PathRoot(),
+			p.PathConfig(),
 		),
 	})
 	if err != nil {
@@ -587,10 +614,12 @@ func (p *Project) RunOld(ctx context.Context, input *StackInput) error {
 		opts := []optup.Option{
 			optup.DebugLogging(debugLogging),
 			optup.Target(input.Target),
-			optup.TargetDependents(),
 			optup.ProgressStreams(pulumiLog),
 			optup.EventStreams(stream),
 		}
+		if input.TargetDependents {
+			opts = append(opts, optup.TargetDependents())
+		}
 		if input.Continue {
 			opts = append(opts, optup.ContinueOnError())
 		}
@@ -599,15 +628,18 @@ func (p *Project) RunOld(ctx context.Context, input *StackInput) error {
 		)
 
 	case "remove":
-		_, runError = stack.Destroy(ctx,
+		opts := []optdestroy.Option{
 			optdestroy.DebugLogging(debugLogging),
 			optdestroy.ContinueOnError(),
 			optdestroy.Target(input.Target),
-			optdestroy.TargetDependents(),
 			optdestroy.ProgressStreams(pulumiLog),
 			optdestroy.EventStreams(stream),
 			optdestroy.ContinueOnError(),
-		)
+		}
+		if input.TargetDependents {
+			opts = append(opts, optdestroy.TargetDependents())
+		}
+		_, runError = stack.Destroy(ctx, opts...)
 
 	case "refresh":
 
@@ -658,6 +690,7 @@ func (p *Project) RunOld(ctx context.Context, input *StackInput) error {
 		update.ID = updateID
 		update.Command = input.Command
 		update.Version = p.Version()
+		update.Attempt = input.Attempt
 		update.TimeStarted = started
 		update.TimeCompleted = time.Now().Format(time.RFC3339)
 		for _, err := range errors {
@@ -675,7 +708,7 @@ func (p *Project) RunOld(ctx context.Context, input *StackInput) error {
 	slog.Info("done running stack command")
 	if runError != nil {
 		slog.Error("stack run failed", "error", runError)
-		return ErrStackRunFailed
+		return &StackRunError{Errors: errors}
 	}
 	return nil
 }
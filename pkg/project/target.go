@@ -0,0 +1,193 @@
+package project
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"regexp"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+var ErrTargetNotFound = fmt.Errorf("did not match any resources")
+
+// ResolveTargets turns the selectors passed to --target and --exclude into a
+// list of URNs using the current state of the stage. A selector can be:
+//
+//	urn:pulumi:...          a full URN, or a glob of one
+//	MyApi, Api*             the name of a resource, or a glob of it
+//	type=sst:aws:Function   the type of a resource, or a glob of it
+//
+// Matching a component also matches all of its children. If only excludes are
+// passed, everything else in the state is targeted, so resources that haven't
+// been deployed yet are left out.
+//
+// Pulumi can't exclude a resource that --target-dependents pulls back in, so
+// with excludes the dependents are resolved here instead when dependents is
+// set, and the stack command shouldn't target dependents itself.
+func (p *Project) ResolveTargets(ctx context.Context, include []string, exclude []string, dependents bool) ([]string, error) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil, nil
+	}
+	if len(exclude) == 0 && allURNs(include) {
+		return include, nil
+	}
+	complete, err := p.GetCompleted(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result, err := resolveTargets(complete.Resources, include, exclude, dependents)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("resolved targets", "include", include, "exclude", exclude, "targets", len(result))
+	return result, nil
+}
+
+func resolveTargets(resources []apitype.ResourceV3, include []string, exclude []string, dependents bool) ([]string, error) {
+	children := map[resource.URN][]resource.URN{}
+	dependedOn := map[resource.URN][]resource.URN{}
+	selectable := []apitype.ResourceV3{}
+	for _, item := range resources {
+		if item.Type == "pulumi:pulumi:Stack" || strings.HasPrefix(string(item.Type), "pulumi:providers:") {
+			continue
+		}
+		selectable = append(selectable, item)
+		if item.Parent != "" {
+			children[item.Parent] = append(children[item.Parent], item.URN)
+		}
+		for _, dep := range item.Dependencies {
+			dependedOn[dep] = append(dependedOn[dep], item.URN)
+		}
+		for _, deps := range item.PropertyDependencies {
+			for _, dep := range deps {
+				dependedOn[dep] = append(dependedOn[dep], item.URN)
+			}
+		}
+		if item.DeletedWith != "" {
+			dependedOn[item.DeletedWith] = append(dependedOn[item.DeletedWith], item.URN)
+		}
+	}
+
+	var expand func(urn resource.URN, set map[resource.URN]bool)
+	expand = func(urn resource.URN, set map[resource.URN]bool) {
+		if set[urn] {
+			return
+		}
+		set[urn] = true
+		for _, child := range children[urn] {
+			expand(child, set)
+		}
+	}
+
+	match := func(selectors []string) (map[resource.URN]bool, []string, error) {
+		set := map[resource.URN]bool{}
+		// full URNs that are not in the state yet are passed through as is
+		extra := []string{}
+		for _, selector := range selectors {
+			found := false
+			for _, item := range selectable {
+				if matchTarget(selector, item) {
+					found = true
+					expand(item.URN, set)
+				}
+			}
+			if found {
+				continue
+			}
+			if isURN(selector) && !isGlob(selector) {
+				extra = append(extra, selector)
+				continue
+			}
+			return nil, nil, fmt.Errorf("%s %w", selector, ErrTargetNotFound)
+		}
+		return set, extra, nil
+	}
+
+	included, extra, err := match(include)
+	if err != nil {
+		return nil, err
+	}
+	excluded, _, err := match(exclude)
+	if err != nil {
+		return nil, err
+	}
+	if dependents && len(include) > 0 && len(exclude) > 0 {
+		var addDependents func(urn resource.URN)
+		addDependents = func(urn resource.URN) {
+			for _, dependent := range dependedOn[urn] {
+				if included[dependent] || excluded[dependent] {
+					continue
+				}
+				expand(dependent, included)
+				addDependents(dependent)
+			}
+		}
+		for urn := range maps.Clone(included) {
+			addDependents(urn)
+		}
+	}
+
+	result := []string{}
+	for _, item := range selectable {
+		if len(include) > 0 && !included[item.URN] {
+			continue
+		}
+		if excluded[item.URN] {
+			continue
+		}
+		result = append(result, string(item.URN))
+	}
+	for _, urn := range extra {
+		if !excluded[resource.URN(urn)] {
+			result = append(result, urn)
+		}
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("%s %w", strings.Join(append(include, exclude...), ","), ErrTargetNotFound)
+	}
+	return result, nil
+}
+
+func matchTarget(selector string, item apitype.ResourceV3) bool {
+	if isURN(selector) {
+		return matchGlob(selector, string(item.URN))
+	}
+	if typ, ok := strings.CutPrefix(selector, "type="); ok {
+		return matchGlob(typ, string(item.Type))
+	}
+	return matchGlob(selector, item.URN.Name())
+}
+
+// matchGlob supports * and ?, which unlike path.Match also match "/" since
+// it shows up in resource types.
+func matchGlob(pattern string, input string) bool {
+	if !isGlob(pattern) {
+		return pattern == input
+	}
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+	matched, _ := regexp.MatchString("^"+expr+"$", input)
+	return matched
+}
+
+func isGlob(input string) bool {
+	return strings.ContainsAny(input, "*?")
+}
+
+func isURN(input string) bool {
+	return strings.HasPrefix(input, "urn:")
+}
+
+func allURNs(input []string) bool {
+	for _, item := range input {
+		if !isURN(item) || isGlob(item) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,102 @@
+package project
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		input    string
+		expected bool
+	}{
+		{"MyApi", "MyApi", true},
+		{"MyApi", "MyApiHandler", false},
+		{"Api*", "ApiHandler", true},
+		{"Api*", "MyApi", false},
+		{"*Api", "MyApi", true},
+		{"Api?", "Api1", true},
+		{"Api?", "Api12", false},
+		{"sst:aws:*", "sst:aws:Function", true},
+		{"aws:lambda/*", "aws:lambda/function:Function", true},
+		{"Api.", "ApiX", false},
+		{"Api[1]", "Api[1]", true},
+	}
+	for _, test := range tests {
+		if got := matchGlob(test.pattern, test.input); got != test.expected {
+			t.Errorf("matchGlob(%q, %q): expected %v, got %v", test.pattern, test.input, test.expected, got)
+		}
+	}
+}
+
+func testURN(typ string, name string) resource.URN {
+	return resource.URN("urn:pulumi:dev::app::" + typ + "::" + name)
+}
+
+func TestResolveTargets(t *testing.T) {
+	stack := testURN("pulumi:pulumi:Stack", "app-dev")
+	api := testURN("sst:aws:Function", "Api")
+	apiRole := testURN("sst:aws:Function$aws:iam/role:Role", "ApiRole")
+	web := testURN("sst:aws:Nextjs", "Web")
+	bucket := testURN("sst:aws:Bucket", "Bucket")
+	cron := testURN("sst:aws:Function", "Cron")
+	resources := []apitype.ResourceV3{
+		{URN: stack, Type: "pulumi:pulumi:Stack"},
+		{URN: testURN("pulumi:providers:aws", "default"), Type: "pulumi:providers:aws"},
+		{URN: bucket, Type: "sst:aws:Bucket"},
+		{URN: api, Type: "sst:aws:Function", Dependencies: []resource.URN{bucket}},
+		{URN: apiRole, Type: "aws:iam/role:Role", Parent: api},
+		{URN: web, Type: "sst:aws:Nextjs", PropertyDependencies: map[resource.PropertyKey][]resource.URN{
+			"environment": {api},
+		}},
+		{URN: cron, Type: "sst:aws:Function"},
+	}
+	tests := []struct {
+		name       string
+		include    []string
+		exclude    []string
+		dependents bool
+		expected   []resource.URN
+		err        error
+	}{
+		{name: "name", include: []string{"Bucket"}, expected: []resource.URN{bucket}},
+		{name: "children", include: []string{"Api"}, expected: []resource.URN{api, apiRole}},
+		{name: "type", include: []string{"type=sst:aws:Function"}, expected: []resource.URN{api, apiRole, cron}},
+		{name: "glob", include: []string{"C*"}, expected: []resource.URN{cron}},
+		{name: "exclude only", exclude: []string{"Web", "Cron"}, expected: []resource.URN{bucket, api, apiRole}},
+		{name: "exclude child", include: []string{"Api"}, exclude: []string{"ApiRole"}, expected: []resource.URN{api}},
+		{name: "dependents", include: []string{"Bucket"}, exclude: []string{"Cron"}, dependents: true, expected: []resource.URN{bucket, api, apiRole, web}},
+		{name: "excluded dependent", include: []string{"Bucket"}, exclude: []string{"Api"}, dependents: true, expected: []resource.URN{bucket}},
+		{name: "new urn", include: []string{string(testURN("sst:aws:Queue", "Queue"))}, exclude: []string{"Cron"}, expected: []resource.URN{testURN("sst:aws:Queue", "Queue")}},
+		{name: "not found", include: []string{"Missing"}, err: ErrTargetNotFound},
+		{name: "everything excluded", include: []string{"Cron"}, exclude: []string{"Cron"}, err: ErrTargetNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := resolveTargets(resources, test.include, test.exclude, test.dependents)
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("expected %v, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := []string{}
+			for _, urn := range test.expected {
+				expected = append(expected, string(urn))
+			}
+			slices.Sort(expected)
+			slices.Sort(result)
+			if !slices.Equal(result, expected) {
+				t.Errorf("expected %v, got %v", expected, result)
+			}
+		})
+	}
+}
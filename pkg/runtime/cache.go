@@ -0,0 +1,274 @@
+package runtime
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/sst/sst/v3/internal/fs"
+	"github.com/sst/sst/v3/pkg/bus"
+	"github.com/sst/sst/v3/pkg/flag"
+	"github.com/sst/sst/v3/pkg/project/path"
+)
+
+// bump this when the layout of the cache changes
+const cacheFormat = "1"
+
+// lockfiles that are part of the cache key when they are found above the
+// handler, since they pin the dependencies that are not tracked as inputs
+var lockfiles = []string{
+	"package-lock.json",
+	"yarn.lock",
+	"pnpm-lock.yaml",
+	"bun.lockb",
+	"bun.lock",
+	"go.sum",
+	"go.work.sum",
+	"Cargo.lock",
+	"uv.lock",
+	"poetry.lock",
+	"Gemfile.lock",
+}
+
+// Toolchain is implemented by runtimes whose output depends on the version of
+// a compiler on the machine, so it's made part of the cache key.
+type Toolchain interface {
+	Toolchain(ctx context.Context, input *BuildInput) string
+}
+
+// BuildCacheEvent is published for every function build that can be cached.
+type BuildCacheEvent struct {
+	FunctionID string
+	Hit        bool
+}
+
+type cacheEntry struct {
+	Spec       string            `json:"spec"`
+	Key        string            `json:"key"`
+	Handler    string            `json:"handler"`
+	Sourcemaps []string          `json:"sourcemaps"`
+	Inputs     map[string]string `json:"inputs"`
+}
+
+// buildCache stores the output of function builds so a deploy can skip
+// building functions that have not changed. It lives in .sst/cache/functions
+// unless SST_BUILD_CACHE_DIR is set, so it can be persisted in CI.
+//
+// A function has an entry with the list of files it was built from. Its key is
+// a hash of those files, along with everything else the build depends on, and
+// it points to a copy of the output in artifacts/<key>.
+type buildCache struct {
+	dir string
+}
+
+func (c *Collection) buildCache(input *BuildInput) *buildCache {
+	if input.Dev || input.Bundle != "" || flag.SST_NO_BUILD_CACHE {
+		return nil
+	}
+	dir := flag.SST_BUILD_CACHE_DIR
+	if dir == "" {
+		dir = filepath.Join(path.ResolveWorkingDir(c.cfgPath), "cache", "functions")
+	}
+	return &buildCache{dir: dir}
+}
+
+// spec hashes everything the build depends on other than its input files.
+func (c *Collection) cacheSpec(ctx context.Context, runtime Runtime, input *BuildInput) (string, error) {
+	hash := sha256.New()
+	fmt.Fprintln(hash, cacheFormat, c.version, input.FunctionID, input.Runtime, input.Handler)
+	hash.Write(input.Properties)
+	links, err := json.Marshal(input.Links)
+	if err != nil {
+		return "", err
+	}
+	hash.Write(links)
+	if toolchain, ok := runtime.(Toolchain); ok {
+		fmt.Fprintln(hash, toolchain.Toolchain(ctx, input))
+	}
+	handler := input.Handler
+	if !filepath.IsAbs(handler) {
+		handler = filepath.Join(path.ResolveRootDir(input.CfgPath), handler)
+	}
+	for _, name := range lockfiles {
+		found, err := fs.FindUp(filepath.Dir(handler), name)
+		if err != nil {
+			continue
+		}
+		sum, err := hashFile(found)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintln(hash, found, sum)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (b *buildCache) entryPath(functionID string) string {
+	return filepath.Join(b.dir, "entries", functionID+".json")
+}
+
+func (b *buildCache) artifactPath(key string) string {
+	return filepath.Join(b.dir, "artifacts", key)
+}
+
+// restore copies the cached output into out if the function and its inputs
+// have not changed since it was stored.
+func (b *buildCache) restore(functionID string, spec string, out string) (*BuildOutput, bool) {
+	data, err := os.ReadFile(b.entryPath(functionID))
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Spec != spec {
+		return nil, false
+	}
+	inputs := map[string]string{}
+	for file, sum := range entry.Inputs {
+		current, err := hashFile(file)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, false
+		}
+		if current != sum {
+			slog.Info("build cache input changed", "functionID", functionID, "file", file)
+			return nil, false
+		}
+		inputs[file] = current
+	}
+	if cacheKey(spec, inputs) != entry.Key {
+		return nil, false
+	}
+	artifact := b.artifactPath(entry.Key)
+	if !fs.Exists(artifact) {
+		return nil, false
+	}
+	if err := os.RemoveAll(out); err != nil {
+		return nil, false
+	}
+	if err := fs.CopyDir(artifact, out); err != nil {
+		slog.Error("failed to restore build from cache", "functionID", functionID, "err", err)
+		return nil, false
+	}
+	return &BuildOutput{
+		Handler:    entry.Handler,
+		Sourcemaps: entry.Sourcemaps,
+		Errors:     []string{},
+	}, true
+}
+
+// store copies the output into the cache and replaces the previous entry of
+// the function.
+func (b *buildCache) store(functionID string, spec string, out string, result *BuildOutput) error {
+	inputs := map[string]string{}
+	for _, file := range result.Inputs {
+		sum, err := hashFile(file)
+		// a file that doesn't exist is stored without a hash so creating it
+		// invalidates the cache
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		inputs[file] = sum
+	}
+	key := cacheKey(spec, inputs)
+	artifact := b.artifactPath(key)
+	if !fs.Exists(artifact) {
+		tmp := artifact + ".tmp"
+		os.RemoveAll(tmp)
+		if err := fs.CopyDir(out, tmp); err != nil {
+			os.RemoveAll(tmp)
+			return err
+		}
+		if err := os.Rename(tmp, artifact); err != nil {
+			os.RemoveAll(tmp)
+			return err
+		}
+	}
+
+	entryPath := b.entryPath(functionID)
+	if data, err := os.ReadFile(entryPath); err == nil {
+		var previous cacheEntry
+		if json.Unmarshal(data, &previous) == nil && previous.Key != "" && previous.Key != key {
+			os.RemoveAll(b.artifactPath(previous.Key))
+		}
+	}
+	data, err := json.MarshalIndent(cacheEntry{
+		Spec:       spec,
+		Key:        key,
+		Handler:    result.Handler,
+		Sourcemaps: result.Sourcemaps,
+		Inputs:     inputs,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(entryPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(entryPath, data, 0644)
+}
+
+func (c *Collection) buildCached(ctx context.Context, runtime Runtime, cache *buildCache, input *BuildInput, out string) (*BuildOutput, error) {
+	spec, err := c.cacheSpec(ctx, runtime, input)
+	if err != nil {
+		return nil, err
+	}
+	if result, ok := cache.restore(input.FunctionID, spec, out); ok {
+		slog.Info("build cache hit", "functionID", input.FunctionID)
+		bus.Publish(&BuildCacheEvent{FunctionID: input.FunctionID, Hit: true})
+		return result, nil
+	}
+	result, err := runtime.Build(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	// only runtimes that report their inputs can be cached
+	if len(result.Errors) > 0 || len(result.Inputs) == 0 {
+		return result, nil
+	}
+	slog.Info("build cache miss", "functionID", input.FunctionID)
+	bus.Publish(&BuildCacheEvent{FunctionID: input.FunctionID, Hit: false})
+	if err := cache.store(input.FunctionID, spec, out, result); err != nil {
+		slog.Error("failed to store build in cache", "functionID", input.FunctionID, "err", err)
+	}
+	return result, nil
+}
+
+func cacheKey(spec string, inputs map[string]string) string {
+	files := make([]string, 0, len(inputs))
+	for file := range inputs {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	hash := sha256.New()
+	fmt.Fprintln(hash, spec)
+	for _, file := range files {
+		fmt.Fprintln(hash, file, inputs[file])
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func hashFile(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", file)
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,134 @@
+package runtime
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func write(t *testing.T, file string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildCache(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src", "index.ts")
+	tsconfig := filepath.Join(dir, "tsconfig.json")
+	missing := filepath.Join(dir, "src", "missing.ts")
+	write(t, src, "export const handler = 1")
+	write(t, tsconfig, "{}")
+
+	out := filepath.Join(dir, "out")
+	write(t, filepath.Join(out, "bundle.mjs"), "built")
+	cache := &buildCache{dir: filepath.Join(dir, "cache")}
+	result := &BuildOutput{
+		Handler: "bundle.handler",
+		Inputs:  []string{src, tsconfig, missing},
+	}
+	if err := cache.store("fn", "spec", out, result); err != nil {
+		t.Fatal(err)
+	}
+
+	restore := func() bool {
+		t.Helper()
+		os.RemoveAll(out)
+		restored, ok := cache.restore("fn", "spec", out)
+		if !ok {
+			return false
+		}
+		if restored.Handler != "bundle.handler" {
+			t.Fatalf("expected the stored handler, got %s", restored.Handler)
+		}
+		data, err := os.ReadFile(filepath.Join(out, "bundle.mjs"))
+		if err != nil || string(data) != "built" {
+			t.Fatalf("expected the stored output, got %q %v", data, err)
+		}
+		return true
+	}
+
+	if !restore() {
+		t.Fatal("expected a hit")
+	}
+	if _, ok := cache.restore("fn", "other spec", out); ok {
+		t.Fatal("expected a miss when the spec changes")
+	}
+	if _, ok := cache.restore("other", "spec", out); ok {
+		t.Fatal("expected a miss for another function")
+	}
+
+	write(t, tsconfig, `{"compilerOptions":{"jsx":"react"}}`)
+	if restore() {
+		t.Fatal("expected a miss when an input changes")
+	}
+	write(t, tsconfig, "{}")
+	if !restore() {
+		t.Fatal("expected a hit once the input is back")
+	}
+
+	write(t, missing, "export {}")
+	if restore() {
+		t.Fatal("expected a miss when a missing input is created")
+	}
+}
+
+func TestBuildCacheStoreFails(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	write(t, filepath.Join(out, "bundle.mjs"), "built")
+	cache := &buildCache{dir: filepath.Join(dir, "cache")}
+	// a directory can't be hashed
+	err := cache.store("fn", "spec", out, &BuildOutput{Inputs: []string{dir}})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if _, ok := cache.restore("fn", "spec", out); ok {
+		t.Fatal("expected nothing to be stored")
+	}
+}
+
+func TestCacheSpec(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "sst.config.ts")
+	write(t, cfgPath, "")
+	write(t, filepath.Join(dir, "src", "index.ts"), "")
+	lockfile := filepath.Join(dir, "package-lock.json")
+	write(t, lockfile, "1")
+
+	c := &Collection{cfgPath: cfgPath, version: "3.0.0"}
+	input := &BuildInput{
+		CfgPath:    cfgPath,
+		FunctionID: "fn",
+		Runtime:    "nodejs20.x",
+		Handler:    "src/index.handler",
+		Properties: []byte(`{"minify":true}`),
+	}
+	spec := func() string {
+		t.Helper()
+		result, err := c.cacheSpec(context.Background(), nil, input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	first := spec()
+	if spec() != first {
+		t.Fatal("expected the spec to be stable")
+	}
+	write(t, lockfile, "2")
+	second := spec()
+	if second == first {
+		t.Fatal("expected the spec to change with the lockfile")
+	}
+	input.Properties = []byte(`{"minify":false}`)
+	if spec() == second {
+		t.Fatal("expected the spec to change with the properties")
+	}
+}
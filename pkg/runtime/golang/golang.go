@@ -1,8 +1,10 @@
 package golang
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
@@ -19,6 +21,19 @@ import (
 type Runtime struct {
 	mut         sync.Mutex
 	directories map[string]string
+	// functionID -> *dependencies
+	deps sync.Map
+}
+
+// dependencies are the files a function is built from, found with go list
+type dependencies struct {
+	// package directories, a .go file changing or being added to them
+	// triggers a rebuild
+	dirs map[string]bool
+	// go.mod, go.sum, and embedded files
+	files map[string]bool
+	// source files of packages that are not in the module cache
+	sources []string
 }
 
 type Worker struct {
@@ -31,6 +46,10 @@ func (w *Worker) Stop() {
 	process.Kill(w.cmd.Process)
 }
 
+func (w *Worker) Pid() int {
+	return w.cmd.Process.Pid
+}
+
 func (w *Worker) Logs() io.ReadCloser {
 	reader, writer := io.Pipe()
 
@@ -83,6 +102,10 @@ func (r *Runtime) Build(ctx context.Context, input *runtime.BuildInput) (*runtim
 	out := filepath.Join(input.Out(), "bootstrap")
 	args := []string{"build"}
 	env := os.Environ()
+	if input.Debug {
+		// keep the binary debuggable by turning off optimizations and inlining
+		args = append(args, "-gcflags", "all=-N -l")
+	}
 	if !input.Dev {
 		args = append(args, "-ldflags", "-s -w")
 		env = append(env, "CGO_ENABLED=0")
@@ -104,18 +127,57 @@ func (r *Runtime) Build(ctx context.Context, input *runtime.BuildInput) (*runtim
 		}, nil
 	}
 	r.directories[input.FunctionID], _ = filepath.Abs(root)
+	inputs := []string{}
+	deps, err := listDependencies(ctx, root, src, env)
+	if err != nil {
+		slog.Error("failed to list go dependencies", "err", err)
+		r.deps.Delete(input.FunctionID)
+	} else {
+		r.deps.Store(input.FunctionID, deps)
+		inputs = append(inputs, deps.sources...)
+		for file := range deps.files {
+			inputs = append(inputs, file)
+		}
+	}
 	return &runtime.BuildOutput{
 		Handler:    "bootstrap",
 		Sourcemaps: []string{},
 		Errors:     []string{},
 		Out:        root,
+		Inputs:     inputs,
 	}, nil
 }
 
+func (r *Runtime) Toolchain(ctx context.Context, input *runtime.BuildInput) string {
+	cmd := process.CommandContext(ctx, "go", "env", "GOVERSION")
+	cmd.Dir = filepath.Dir(input.Handler)
+	output, _ := cmd.Output()
+	return strings.TrimSpace(string(output))
+}
+
+func (r *Runtime) Debugger() (string, int) {
+	return "dlv", 2345
+}
+
 func (r *Runtime) Run(ctx context.Context, input *runtime.RunInput) (runtime.Worker, error) {
 	cmd := process.Command(
 		filepath.Join(input.Build.Out, input.Build.Handler),
 	)
+	if input.Debug != nil {
+		if _, err := exec.LookPath("dlv"); err != nil {
+			return nil, fmt.Errorf("dlv is not installed, install it with `go install github.com/go-delve/delve/cmd/dlv@latest`")
+		}
+		cmd = process.Command(
+			"dlv",
+			"exec",
+			"--headless",
+			"--listen", fmt.Sprintf("127.0.0.1:%d", input.Debug.Port),
+			"--api-version", "2",
+			"--accept-multiclient",
+			"--continue",
+			filepath.Join(input.Build.Out, input.Build.Handler),
+		)
+	}
 	slog.Info("running go run", "server", input.Server)
 	cmd.Env = input.Env
 	cmd.Env = append(cmd.Env, "AWS_LAMBDA_RUNTIME_API="+input.Server)
@@ -130,7 +192,85 @@ func (r *Runtime) Run(ctx context.Context, input *runtime.RunInput) (runtime.Wor
 	}, nil
 }
 
+type listPackage struct {
+	Dir        string
+	Standard   bool
+	GoFiles    []string
+	CgoFiles   []string
+	EmbedFiles []string
+	Module     *struct {
+		GoMod   string
+		Main    bool
+		Replace *struct {
+			Version string
+		}
+	}
+}
+
+// listDependencies uses `go list -deps` to find every package the function is
+// built from. This includes packages from go.work workspace modules and local
+// replace directives, but skips the standard library.
+func listDependencies(ctx context.Context, root string, src string, env []string) (*dependencies, error) {
+	cmd := process.CommandContext(ctx, "go", "list", "-deps", "-json=Dir,Standard,GoFiles,CgoFiles,EmbedFiles,Module", src)
+	cmd.Dir = root
+	cmd.Env = env
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	result := &dependencies{
+		dirs:  map[string]bool{},
+		files: map[string]bool{},
+	}
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	for decoder.More() {
+		var pkg listPackage
+		if err := decoder.Decode(&pkg); err != nil {
+			return nil, err
+		}
+		if pkg.Standard {
+			continue
+		}
+		result.dirs[pkg.Dir] = true
+		for _, file := range pkg.EmbedFiles {
+			result.files[filepath.Join(pkg.Dir, file)] = true
+		}
+		// modules downloaded to the module cache are pinned by go.sum, local
+		// ones are the main modules and replace directives without a version
+		if pkg.Module == nil || pkg.Module.Main || (pkg.Module.Replace != nil && pkg.Module.Replace.Version == "") {
+			for _, file := range append(pkg.GoFiles, pkg.CgoFiles...) {
+				result.sources = append(result.sources, filepath.Join(pkg.Dir, file))
+			}
+		}
+		if pkg.Module != nil && pkg.Module.GoMod != "" {
+			result.files[pkg.Module.GoMod] = true
+			result.files[filepath.Join(filepath.Dir(pkg.Module.GoMod), "go.sum")] = true
+		}
+	}
+	work := process.CommandContext(ctx, "go", "env", "GOWORK")
+	work.Dir = root
+	work.Env = env
+	if output, err := work.Output(); err == nil {
+		if path := strings.TrimSpace(string(output)); path != "" && path != "off" {
+			result.files[path] = true
+			result.files[path+".sum"] = true
+		}
+	}
+	return result, nil
+}
+
 func (r *Runtime) ShouldRebuild(functionID string, file string) bool {
+	if value, ok := r.deps.Load(functionID); ok {
+		deps := value.(*dependencies)
+		if deps.files[file] {
+			return true
+		}
+		if !strings.HasSuffix(file, ".go") {
+			return false
+		}
+		return deps.dirs[filepath.Dir(file)]
+	}
+	// go list failed, fall back to any change in the module
 	if !strings.HasSuffix(file, ".go") {
 		return false
 	}
@@ -0,0 +1,113 @@
+package runtime
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const memoryInterval = 100 * time.Millisecond
+
+// Usage is implemented by the workers from Collection.Run whose memory is
+// tracked.
+type Usage interface {
+	// MaxMemory is the most memory the worker has used, in MB.
+	MaxMemory() int64
+	// OutOfMemory is true if the worker was stopped for going over its limit.
+	OutOfMemory() bool
+}
+
+// memoryTracker measures the memory of a worker process along with the
+// processes it started.
+type memoryTracker interface {
+	// usage returns the memory in use in bytes, or an error once the process
+	// has exited.
+	usage() (int64, error)
+}
+
+type limitedWorker struct {
+	Worker
+	functionID string
+	limit      int64
+	tracker    memoryTracker
+	cancel     context.CancelFunc
+	mut        sync.Mutex
+	peak       int64
+	oom        bool
+}
+
+// limit tracks the memory of the worker and stops it when it goes over the
+// limit of the function, the same way Lambda would.
+func limit(input *RunInput, worker Worker) Worker {
+	if input.Memory <= 0 {
+		return worker
+	}
+	proc, ok := worker.(Process)
+	if !ok {
+		return worker
+	}
+	limit := int64(input.Memory) * 1024 * 1024
+	tracker := newMemoryTracker(proc.Pid())
+	if tracker == nil {
+		return worker
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	result := &limitedWorker{
+		Worker:     worker,
+		functionID: input.FunctionID,
+		limit:      limit,
+		tracker:    tracker,
+		cancel:     cancel,
+	}
+	go result.watch(ctx)
+	return result
+}
+
+func (w *limitedWorker) watch(ctx context.Context) {
+	ticker := time.NewTicker(memoryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		used, err := w.tracker.usage()
+		if err != nil {
+			return
+		}
+		w.mut.Lock()
+		w.peak = max(w.peak, used)
+		w.mut.Unlock()
+		if used > w.limit {
+			slog.Info("worker out of memory", "functionID", w.functionID, "used", used, "limit", w.limit)
+			w.setOutOfMemory()
+			w.Worker.Stop()
+			return
+		}
+	}
+}
+
+func (w *limitedWorker) setOutOfMemory() {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	w.oom = true
+}
+
+func (w *limitedWorker) Stop() {
+	w.cancel()
+	w.Worker.Stop()
+}
+
+func (w *limitedWorker) MaxMemory() int64 {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	return (w.peak + 1024*1024 - 1) / (1024 * 1024)
+}
+
+func (w *limitedWorker) OutOfMemory() bool {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	return w.oom
+}
@@ -0,0 +1,114 @@
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// newMemoryTracker measures the worker and the processes it started through
+// /proc. Rlimits are not used since they cap virtual memory, and node and go
+// reserve far more of it than they use.
+func newMemoryTracker(pid int) memoryTracker {
+	return &procTracker{pid: pid}
+}
+
+// procTable is the process tree, it's shared by every worker so /proc is only
+// scanned once an interval however many are running.
+type procTable struct {
+	mut      sync.Mutex
+	scanned  time.Time
+	children map[int][]int
+	zombies  map[int]bool
+	err      error
+}
+
+var procs = &procTable{}
+
+// snapshot returns the children of every process and the ones that have
+// exited, rescanning /proc if the last scan is out of date. The maps aren't
+// changed once they're returned.
+func (t *procTable) snapshot() (map[int][]int, map[int]bool, error) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	if time.Since(t.scanned) < memoryInterval/2 {
+		return t.children, t.zombies, t.err
+	}
+	t.children, t.zombies, t.err = scanProcs()
+	t.scanned = time.Now()
+	return t.children, t.zombies, t.err
+}
+
+func scanProcs() (map[int][]int, map[int]bool, error) {
+	children := map[int][]int{}
+	zombies := map[int]bool{}
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+		if err != nil {
+			continue
+		}
+		// the name can have spaces, so the fields start after the last paren
+		end := strings.LastIndexByte(string(stat), ')')
+		if end == -1 {
+			continue
+		}
+		fields := strings.Fields(string(stat[end+1:]))
+		if len(fields) < 2 {
+			continue
+		}
+		if fields[0] == "Z" {
+			zombies[pid] = true
+		}
+		parent, _ := strconv.Atoi(fields[1])
+		children[parent] = append(children[parent], pid)
+	}
+	return children, zombies, nil
+}
+
+// procTracker adds up the resident memory of the worker and its children.
+type procTracker struct {
+	pid int
+}
+
+func (p *procTracker) usage() (int64, error) {
+	children, zombies, err := procs.snapshot()
+	if err != nil {
+		return 0, err
+	}
+	if zombies[p.pid] {
+		return 0, os.ErrProcessDone
+	}
+	if _, ok := children[p.pid]; !ok {
+		if _, err := os.Stat(fmt.Sprintf("/proc/%d", p.pid)); err != nil {
+			return 0, os.ErrProcessDone
+		}
+	}
+	var total int64
+	pending := []int{p.pid}
+	for len(pending) > 0 {
+		pid := pending[0]
+		pending = append(pending[1:], children[pid]...)
+		statm, err := os.ReadFile(fmt.Sprintf("/proc/%d/statm", pid))
+		if err != nil {
+			continue
+		}
+		fields := strings.Fields(string(statm))
+		if len(fields) < 2 {
+			continue
+		}
+		pages, _ := strconv.ParseInt(fields[1], 10, 64)
+		total += pages * int64(os.Getpagesize())
+	}
+	return total, nil
+}
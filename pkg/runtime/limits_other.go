@@ -0,0 +1,8 @@
+//go:build !linux
+
+package runtime
+
+// memory limits are only enforced on linux
+func newMemoryTracker(pid int) memoryTracker {
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
 	"slices"
@@ -207,6 +208,7 @@ func (r *Runtime) Build(ctx context.Context, input *runtime.BuildInput) (*runtim
 	}
 
 	sourcemaps := []string{}
+	inputs := []string{}
 	if !input.Dev {
 		if properties.SourceMap == nil {
 			for _, file := range result.OutputFiles {
@@ -217,6 +219,21 @@ func (r *Runtime) Build(ctx context.Context, input *runtime.BuildInput) (*runtim
 		}
 		var metafile js.Metafile
 		json.Unmarshal([]byte(result.Metafile), &metafile)
+		for key := range metafile.Inputs {
+			// packages in node_modules are covered by the lockfile
+			if strings.Contains(key, "node_modules/") {
+				continue
+			}
+			if abs, err := filepath.Abs(key); err == nil {
+				inputs = append(inputs, abs)
+			}
+		}
+		// config that changes the output without being bundled
+		for _, name := range []string{"tsconfig.json", "jsconfig.json", "package.json"} {
+			if found, err := fs.FindUp(filepath.Dir(file), name); err == nil {
+				inputs = append(inputs, found)
+			}
+		}
 
 		installPackages := properties.Install
 		for _, pkg := range forceExternal {
@@ -248,6 +265,7 @@ func (r *Runtime) Build(ctx context.Context, input *runtime.BuildInput) (*runtim
 			if err != nil {
 				return nil, err
 			}
+			inputs = append(inputs, src)
 			dependencies := map[string]string{}
 			for _, pkg := range installPackages {
 				dependencies[pkg] = "*"
@@ -255,6 +273,11 @@ func (r *Runtime) Build(ctx context.Context, input *runtime.BuildInput) (*runtim
 					dependencies[pkg] = parsed.Dependencies[pkg]
 				}
 			}
+			if slices.Contains(slices.Collect(maps.Values(dependencies)), "*") {
+				// whatever is latest gets installed, so the build can't be cached
+				log.Info("not caching build with unpinned packages", "dependencies", dependencies)
+				inputs = nil
+			}
 			outPkg := filepath.Join(input.Out(), "package.json")
 			outFile, err := os.Create(outPkg)
 			if err != nil {
@@ -297,5 +320,6 @@ func (r *Runtime) Build(ctx context.Context, input *runtime.BuildInput) (*runtim
 		Handler:    handler,
 		Errors:     errors,
 		Sourcemaps: sourcemaps,
+		Inputs:     inputs,
 	}, nil
 }
@@ -3,6 +3,7 @@ package node
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
@@ -90,6 +91,10 @@ func (w *Worker) Stop() {
 	process.Kill(w.cmd.Process)
 }
 
+func (w *Worker) Pid() int {
+	return w.cmd.Process.Pid
+}
+
 func (w *Worker) Logs() io.ReadCloser {
 	reader, writer := io.Pipe()
 
@@ -128,9 +133,11 @@ type NodeProperties struct {
 var NODE_EXTENSIONS = []string{".ts", ".tsx", ".mts", ".cts", ".js", ".jsx", ".mjs", ".cjs"}
 
 func (r *Runtime) Run(ctx context.Context, input *runtime.RunInput) (runtime.Worker, error) {
-	cmd := process.Command(
-		"node",
-		"--enable-source-maps",
+	args := []string{"--enable-source-maps"}
+	if input.Debug != nil {
+		args = append(args, fmt.Sprintf("--inspect=127.0.0.1:%d", input.Debug.Port))
+	}
+	args = append(args,
 		filepath.Join(
 			path.ResolvePlatformDir(input.CfgPath),
 			"/dist/nodejs-runtime/index.js",
@@ -138,6 +145,7 @@ func (r *Runtime) Run(ctx context.Context, input *runtime.RunInput) (runtime.Wor
 		filepath.Join(input.Build.Out, input.Build.Handler),
 		input.WorkerID,
 	)
+	cmd := process.Command("node", args...)
 	cmd.Env = input.Env
 	cmd.Env = append(cmd.Env, "NODE_OPTIONS="+os.Getenv("NODE_OPTIONS"))
 	cmd.Env = append(cmd.Env, "VSCODE_INSPECTOR_OPTIONS="+os.Getenv("VSCODE_INSPECTOR_OPTIONS"))
@@ -158,6 +166,10 @@ func (r *Runtime) Match(runtime string) bool {
 	return strings.HasPrefix(runtime, "node")
 }
 
+func (r *Runtime) Debugger() (string, int) {
+	return "inspector", 9229
+}
+
 func (r *Runtime) getFile(input *runtime.BuildInput) (string, bool) {
 	dir := filepath.Dir(input.Handler)
 	fileSplit := strings.Split(filepath.Base(input.Handler), ".")
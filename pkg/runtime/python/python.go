@@ -3,6 +3,7 @@ package python
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
@@ -10,13 +11,16 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 
 	"github.com/BurntSushi/toml"
+	"github.com/sst/sst/v3/pkg/flag"
 	"github.com/sst/sst/v3/pkg/process"
 	"github.com/sst/sst/v3/pkg/project/path"
 	"github.com/sst/sst/v3/pkg/runtime"
+	"golang.org/x/sync/semaphore"
 )
 
 type Worker struct {
@@ -30,6 +34,10 @@ func (w *Worker) Stop() {
 	process.Kill(w.cmd.Process)
 }
 
+func (w *Worker) Pid() int {
+	return w.cmd.Process.Pid
+}
+
 func (w *Worker) Logs() io.ReadCloser {
 	reader, writer := io.Pipe()
 
@@ -70,12 +78,21 @@ func (w *Worker) Logs() io.ReadCloser {
 }
 
 type PythonRuntime struct {
+	mut              sync.Mutex
 	lastBuiltHandler map[string]string
+	concurrency      *semaphore.Weighted
 }
 
 func New() *PythonRuntime {
+	weight := int64(4)
+	if flag.SST_BUILD_CONCURRENCY_FUNCTION != "" {
+		weight, _ = strconv.ParseInt(flag.SST_BUILD_CONCURRENCY_FUNCTION, 10, 64)
+	} else if flag.SST_BUILD_CONCURRENCY != "" {
+		weight, _ = strconv.ParseInt(flag.SST_BUILD_CONCURRENCY, 10, 64)
+	}
 	return &PythonRuntime{
 		lastBuiltHandler: map[string]string{},
+		concurrency:      semaphore.NewWeighted(weight),
 	}
 }
 
@@ -86,6 +103,9 @@ func (r *PythonRuntime) Build(ctx context.Context, input *runtime.BuildInput) (*
 	if !ok {
 		return nil, fmt.Errorf("handler not found: %v", input.Handler)
 	}
+	// the handler keeps its path so imports relative to its package still work,
+	// the dependencies are installed at the root of the package
+	handler := input.Handler
 	targetDir := filepath.Join(input.Out(), filepath.Dir(input.Handler))
 	if err := os.MkdirAll(targetDir, os.ModePerm); err != nil {
 		return nil, fmt.Errorf("failed to create target directory: %v", err)
@@ -132,6 +152,7 @@ func (r *PythonRuntime) Build(ctx context.Context, input *runtime.BuildInput) (*
 	if err := copyFile(pyProjectFile, filepath.Join(targetDir, filepath.Base(pyProjectFile))); err != nil {
 		return nil, err
 	}
+	inputs := append(pythonFiles, pyProjectFile, filepath.Join(filepath.Dir(pyProjectFile), "Dockerfile"))
 
 	// Write the links to resources.json file at the root of the output directory
 	resourcesFile := filepath.Join(targetDir, "resources.json")
@@ -139,89 +160,191 @@ func (r *PythonRuntime) Build(ctx context.Context, input *runtime.BuildInput) (*
 		return nil, err
 	}
 
-	// We need to check if a deployment instead of dev
-	rpcBuildEnabled := false
-	if !input.Dev && rpcBuildEnabled {
-		// Handle zip and containers differently
-		// TODO: walln - handle container flag (not sure how the RPC call is shaped from component?)
+	errors := []string{}
+	if !input.Dev {
+		if err := r.concurrency.Acquire(ctx, 1); err != nil {
+			return nil, err
+		}
+		defer r.concurrency.Release(1)
 
-		containerDeployment := false
-		if containerDeployment {
-			// Copy the Dockerfile to the output directory if it exists
-			dockerFile := filepath.Join(filepath.Dir(pyProjectFile), "Dockerfile")
-			if _, err := os.Stat(dockerFile); err == nil {
-				if err := copyFile(dockerFile, filepath.Join(targetDir, "Dockerfile")); err != nil {
-					return nil, err
-				}
+		var properties Properties
+		json.Unmarshal(input.Properties, &properties)
+		version := strings.TrimPrefix(input.Runtime, "python")
+		if properties.Container {
+			err = packageContainer(input.CfgPath, pyProjectFile, input.Out())
+		} else {
+			lockFile := filepath.Join(filepath.Dir(pyProjectFile), "uv.lock")
+			if _, statErr := os.Stat(lockFile); statErr != nil {
+				// resolving the dependencies here would write a uv.lock into
+				// the project during the deploy
+				errors = []string{fmt.Sprintf("%s not found. Run `uv lock` in %s to lock the dependencies of the function.", lockFile, filepath.Dir(pyProjectFile))}
 			} else {
-				// Copy the default Dockerfile
-				defaultDockefilePath := filepath.Join(path.ResolvePlatformDir(input.CfgPath), "functions", "docker", "python.Dockerfile")
-				if err := copyFile(defaultDockefilePath, filepath.Join(targetDir, "Dockerfile")); err != nil {
-					return nil, err
-				}
+				errors, err = packageZip(ctx, pyProjectFile, input.Out(), version, properties.Architecture)
 			}
-		} else {
-			// In a zip deployment we have to manage the dependency packing without uv's virtualenv since
-			// uv is not available in the lambda environment
-
-			// 1. Install python dependencies locally with the correct versions
-			// TOOD: walln - when uv supports specifying the platform that would be a good idea
-			// but its not there yet
-			uvSyncCmd := process.Command(
-				"uv",
-				"sync")
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
 
-			// use the output pyproject.toml directory as the working directory
-			workDir := filepath.Dir(filepath.Join(targetDir, filepath.Base(pyProjectFile)))
+	r.mut.Lock()
+	r.lastBuiltHandler[input.FunctionID] = file
+	r.mut.Unlock()
 
-			slog.Info("starting build uv sync", "env", uvSyncCmd.Env, "args", uvSyncCmd.Args)
-			uvSyncCmd.Dir = workDir
+	return &runtime.BuildOutput{
+		Handler: handler,
+		Errors:  errors,
+		Inputs:  inputs,
+	}, nil
+}
 
-			err := uvSyncCmd.Run()
-			if err != nil {
-				return nil, fmt.Errorf("failed to run uv sync: %v", err)
-			}
+type Properties struct {
+	Architecture string `json:"architecture"`
+	Container    bool   `json:"container"`
+}
 
-			// 2. Convert the virtualenv to site-packages so that lambda can find the packages
-			sitePackagesCmd := process.Command(
-				"cp",
-				"-r",
-				filepath.Join(workDir, ".venv", "lib", "python3.*", "site-packages", "*"),
-				filepath.Join(targetDir))
+// packageContainer adds the Dockerfile at the root of the build. It uses the one
+// next to pyproject.toml if there is one, otherwise the default template.
+func packageContainer(cfgPath string, pyProjectFile string, targetDir string) error {
+	// the Dockerfile installs the dependencies from the root of the build
+	if err := copyFile(pyProjectFile, filepath.Join(targetDir, "pyproject.toml")); err != nil {
+		return err
+	}
+	lockFile := filepath.Join(filepath.Dir(pyProjectFile), "uv.lock")
+	if _, err := os.Stat(lockFile); err == nil {
+		if err := copyFile(lockFile, filepath.Join(targetDir, "uv.lock")); err != nil {
+			return err
+		}
+	}
+	dockerFile := filepath.Join(filepath.Dir(pyProjectFile), "Dockerfile")
+	if _, err := os.Stat(dockerFile); err != nil {
+		dockerFile = filepath.Join(path.ResolvePlatformDir(cfgPath), "functions", "docker", "python.Dockerfile")
+	}
+	return copyFile(dockerFile, filepath.Join(targetDir, "Dockerfile"))
+}
 
-			slog.Info("starting build site packages", "env", sitePackagesCmd.Env, "args", sitePackagesCmd.Args)
-			sitePackagesCmd.Dir = workDir
+// packageZip installs the locked dependencies for the Lambda platform and lays
+// out their site-packages flat at the root of the package, where Lambda looks
+// for them.
+func packageZip(ctx context.Context, pyProjectFile string, targetDir string, version string, architecture string) ([]string, error) {
+	tmp, err := os.MkdirTemp("", "sst-python-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
 
-			err = sitePackagesCmd.Run()
-			if err != nil {
-				return nil, fmt.Errorf("failed to run site packages: %v", err)
-			}
+	projectDir := filepath.Dir(pyProjectFile)
+	requirements := filepath.Join(tmp, "requirements.txt")
+	args := []string{"export", "--frozen", "--no-dev", "--no-emit-project", "--no-hashes", "--format", "requirements-txt", "--output-file", requirements}
+	if output, err := runUv(ctx, projectDir, args...); err != nil {
+		return []string{output}, nil
+	}
 
-			// 3. Remove the virtualenv because it does not need to be included in the zip
-			removeVirtualEnvCmd := process.Command(
-				"rm",
-				"-rf",
-				filepath.Join(workDir, ".venv"))
+	// path dependencies are exported as editable, which would only link to the
+	// source directory
+	data, err := os.ReadFile(requirements)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, "-e ")
+	}
+	if err := os.WriteFile(requirements, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		return nil, err
+	}
 
-			slog.Info("starting build remove virtual env", "env", removeVirtualEnvCmd.Env, "args", removeVirtualEnvCmd.Args)
-			removeVirtualEnvCmd.Dir = workDir
+	platform := "x86_64-manylinux2014"
+	if architecture == "arm64" {
+		platform = "aarch64-manylinux2014"
+	}
+	site := filepath.Join(tmp, "site-packages")
+	args = []string{"pip", "install", "--no-deps", "--requirement", requirements, "--target", site, "--python-platform", platform}
+	if version != "" {
+		args = append(args, "--python-version", version)
+	}
+	if output, err := runUv(ctx, projectDir, args...); err != nil {
+		return []string{output}, nil
+	}
+	if _, err := os.Stat(site); err != nil {
+		// no dependencies
+		return nil, nil
+	}
+	if err := pruneSitePackages(site); err != nil {
+		return nil, err
+	}
+	return nil, copyDir(site, targetDir)
+}
 
-			err = removeVirtualEnvCmd.Run()
-			if err != nil {
-				return nil, fmt.Errorf("failed to run remove virtual env: %v", err)
-			}
+func runUv(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := process.CommandContext(ctx, "uv", args...)
+	cmd.Dir = dir
+	slog.Info("running uv", "args", cmd.Args)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "uv is not installed. Install it from https://docs.astral.sh/uv/ to deploy python functions.", err
 		}
-
+		return string(output), err
 	}
+	return "", nil
+}
 
-	r.lastBuiltHandler[input.FunctionID] = file
+// distInfoKeep are the files kept in *.dist-info, the rest is not needed at
+// runtime
+var distInfoKeep = map[string]bool{
+	"METADATA":         true,
+	"entry_points.txt": true,
+	"top_level.txt":    true,
+}
 
-	errors := []string{}
+// pruneSitePackages removes the scripts, tests, bytecode, and most of the
+// dist-info that get installed with the packages.
+func pruneSitePackages(site string) error {
+	if err := os.RemoveAll(filepath.Join(site, "bin")); err != nil {
+		return err
+	}
+	return filepath.WalkDir(site, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(site, file)
+		depth := len(strings.Split(filepath.ToSlash(rel), "/"))
+		parent := filepath.Base(filepath.Dir(file))
+		if d.IsDir() {
+			// tests inside a package, a top level package could be named test
+			isTests := depth > 1 && (d.Name() == "tests" || d.Name() == "test")
+			// like the licenses directory
+			inDistInfo := strings.HasSuffix(parent, ".dist-info")
+			if d.Name() != "__pycache__" && !isTests && !inDistInfo {
+				return nil
+			}
+			if err := os.RemoveAll(file); err != nil {
+				return err
+			}
+			return filepath.SkipDir
+		}
+		if strings.HasSuffix(d.Name(), ".pyc") || (strings.HasSuffix(parent, ".dist-info") && !distInfoKeep[d.Name()]) {
+			return os.Remove(file)
+		}
+		return nil
+	})
+}
 
-	return &runtime.BuildOutput{
-		Handler: input.Handler,
-		Errors:  errors,
-	}, nil
+func copyDir(src string, dst string) error {
+	return filepath.WalkDir(src, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(src, file)
+		if err != nil {
+			return err
+		}
+		return copyFile(file, filepath.Join(dst, rel))
+	})
 }
 
 func (r *PythonRuntime) Match(runtime string) bool {
@@ -321,6 +444,14 @@ func (r *PythonRuntime) Run(ctx context.Context, input *runtime.RunInput) (runti
 		}
 	}
 
+	if input.Debug != nil {
+		args = append(args,
+			"--with", "debugpy",
+			"python", "-m", "debugpy",
+			"--listen", fmt.Sprintf("127.0.0.1:%d", input.Debug.Port),
+		)
+	}
+
 	args = append(args,
 		filepath.Join(path.ResolvePlatformDir(input.CfgPath), "/dist/python-runtime/index.py"),
 		filepath.Join(input.Build.Out, input.Build.Handler),
@@ -349,6 +480,10 @@ func (r *PythonRuntime) Run(ctx context.Context, input *runtime.RunInput) (runti
 	}, nil
 }
 
+func (r *PythonRuntime) Debugger() (string, int) {
+	return "debugpy", 5678
+}
+
 func (r *PythonRuntime) ShouldRebuild(functionID string, file string) bool {
 	return true
 }
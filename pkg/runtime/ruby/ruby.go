@@ -0,0 +1,350 @@
+package ruby
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	goruntime "runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sst/sst/v3/internal/fs"
+	"github.com/sst/sst/v3/pkg/process"
+	"github.com/sst/sst/v3/pkg/project/path"
+	"github.com/sst/sst/v3/pkg/runtime"
+)
+
+type Runtime struct {
+	mut      sync.Mutex
+	projects map[string]*project
+}
+
+type project struct {
+	root    string
+	gemfile string
+}
+
+type Worker struct {
+	stdout io.ReadCloser
+	stderr io.ReadCloser
+	cmd    *exec.Cmd
+}
+
+func (w *Worker) Stop() {
+	process.Kill(w.cmd.Process)
+}
+
+func (w *Worker) Pid() int {
+	return w.cmd.Process.Pid
+}
+
+func (w *Worker) Logs() io.ReadCloser {
+	reader, writer := io.Pipe()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, _ = io.Copy(writer, w.stdout)
+	}()
+	go func() {
+		defer wg.Done()
+		_, _ = io.Copy(writer, w.stderr)
+	}()
+
+	go func() {
+		wg.Wait()
+		defer writer.Close()
+	}()
+
+	return reader
+}
+
+func New() *Runtime {
+	return &Runtime{
+		projects: map[string]*project{},
+	}
+}
+
+func (r *Runtime) Match(runtime string) bool {
+	return strings.HasPrefix(runtime, "ruby")
+}
+
+type Properties struct {
+	Architecture string `json:"architecture"`
+}
+
+// directories that are never copied into the function
+var ignoredDirs = map[string]bool{
+	".bundle":      true,
+	".git":         true,
+	".sst":         true,
+	"node_modules": true,
+	"vendor":       true,
+	"tmp":          true,
+	"log":          true,
+}
+
+func (r *Runtime) Build(ctx context.Context, input *runtime.BuildInput) (*runtime.BuildOutput, error) {
+	slog.Info("building ruby function", "handler", input.Handler)
+	var properties Properties
+	json.Unmarshal(input.Properties, &properties)
+
+	file, method := parseHandler(input.Handler)
+	file = filepath.Join(path.ResolveRootDir(input.CfgPath), file+".rb")
+	if !fs.Exists(file) {
+		return nil, fmt.Errorf("handler not found: %v", input.Handler)
+	}
+
+	// the function is packaged from the directory with the Gemfile, or the
+	// directory of the handler if there isn't one
+	root := filepath.Dir(file)
+	gemfile, _ := fs.FindUp(root, "Gemfile")
+	if gemfile != "" {
+		// ignore a Gemfile outside of the app
+		if rel, _ := filepath.Rel(path.ResolveRootDir(input.CfgPath), gemfile); strings.HasPrefix(rel, "..") {
+			gemfile = ""
+		}
+	}
+	if gemfile != "" {
+		root = filepath.Dir(gemfile)
+	}
+	out := input.Out()
+	inputs, err := copySources(root, out)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(root, file)
+	if err != nil {
+		return nil, err
+	}
+	handler := strings.TrimSuffix(filepath.ToSlash(rel), ".rb") + "." + method
+
+	err = writeResourcesFile(filepath.Join(out, "resources.json"), input.Links)
+	if err != nil {
+		return nil, err
+	}
+
+	if !input.Dev && gemfile != "" {
+		problems, err := bundle(ctx, out, properties.Architecture)
+		if err != nil {
+			return nil, err
+		}
+		if len(problems) > 0 {
+			return &runtime.BuildOutput{
+				Errors: problems,
+			}, nil
+		}
+	}
+
+	r.mut.Lock()
+	r.projects[input.FunctionID] = &project{
+		root:    root,
+		gemfile: gemfile,
+	}
+	r.mut.Unlock()
+	return &runtime.BuildOutput{
+		Handler:    handler,
+		Sourcemaps: []string{},
+		Errors:     []string{},
+		Inputs:     inputs,
+	}, nil
+}
+
+// parseHandler splits a handler like `src/function.handler` or
+// `src/function.MyModule::MyClass.process` into the file and the method.
+func parseHandler(handler string) (string, string) {
+	dir, base := filepath.Split(handler)
+	parts := strings.SplitN(base, ".", 2)
+	if len(parts) == 1 {
+		return filepath.Join(dir, parts[0]), "handler"
+	}
+	return filepath.Join(dir, parts[0]), parts[1]
+}
+
+// copySources copies the sources into out and returns the files it copied.
+func copySources(root string, out string) ([]string, error) {
+	copied := []string{}
+	err := filepath.Walk(root, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if file != root && ignoredDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name := info.Name()
+		if !strings.HasSuffix(name, ".rb") && !strings.HasSuffix(name, ".gemspec") && name != "Gemfile" && name != "Gemfile.lock" {
+			return nil
+		}
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(out, rel)
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		copied = append(copied, file)
+		return fs.CopyFile(file, dest, info.Mode().Perm())
+	})
+	return copied, err
+}
+
+// bundle vendors the gems into vendor/bundle. This is the same as
+// `bundle install --deployment` but set through the local config so the
+// runtime in Lambda picks it up from .bundle/config.
+func bundle(ctx context.Context, dir string, architecture string) ([]string, error) {
+	if !fs.Exists(filepath.Join(dir, "Gemfile.lock")) {
+		return []string{"Gemfile.lock not found. Run `bundle install` to create it, Bundler needs it to package the function."}, nil
+	}
+	steps := [][]string{
+		{"config", "set", "--local", "deployment", "true"},
+		{"config", "set", "--local", "path", "vendor/bundle"},
+		{"config", "set", "--local", "without", "development:test"},
+		{"install"},
+	}
+	for _, args := range steps {
+		cmd := process.CommandContext(ctx, "bundle", args...)
+		cmd.Dir = dir
+		cmd.Env = os.Environ()
+		slog.Info("running bundle", "args", cmd.Args)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			if errors.Is(err, exec.ErrNotFound) {
+				return []string{"Bundler is not installed. Install it with `gem install bundler`."}, nil
+			}
+			return []string{string(output)}, nil
+		}
+	}
+	return nativeExtensionErrors(filepath.Join(dir, "vendor", "bundle"), architecture)
+}
+
+// nativeExtensionErrors reports gems with compiled extensions when they were
+// not built on the same platform as the function, since they won't load in
+// Lambda.
+func nativeExtensionErrors(vendor string, architecture string) ([]string, error) {
+	arch := "amd64"
+	if architecture == "arm64" {
+		arch = "arm64"
+	}
+	if goruntime.GOOS == "linux" && goruntime.GOARCH == arch {
+		return nil, nil
+	}
+	gems := map[string]bool{}
+	err := filepath.Walk(vendor, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() || (filepath.Ext(file) != ".so" && filepath.Ext(file) != ".bundle") {
+			return nil
+		}
+		rel, _ := filepath.Rel(vendor, file)
+		// ruby/<version>/gems/<gem>/...
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if len(parts) > 3 && parts[2] == "gems" {
+			gems[parts[3]] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(gems) == 0 {
+		return nil, nil
+	}
+	names := []string{}
+	for name := range gems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return []string{fmt.Sprintf(
+		"These gems have native extensions built for %s/%s, which won't load in Lambda on linux/%s: %s. Deploy from a linux/%s machine, like in CI or a container.",
+		goruntime.GOOS, goruntime.GOARCH, arch, strings.Join(names, ", "), arch,
+	)}, nil
+}
+
+func writeResourcesFile(resourcesFile string, links map[string]json.RawMessage) error {
+	jsonData, err := json.MarshalIndent(links, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal links to JSON: %v", err)
+	}
+	return os.WriteFile(resourcesFile, jsonData, 0644)
+}
+
+func (r *Runtime) Run(ctx context.Context, input *runtime.RunInput) (runtime.Worker, error) {
+	r.mut.Lock()
+	project, ok := r.projects[input.FunctionID]
+	r.mut.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("function not built: %v", input.FunctionID)
+	}
+	file, method := parseHandler(input.Build.Handler)
+	args := []string{
+		filepath.Join(path.ResolvePlatformDir(input.CfgPath), "dist", "ruby-runtime", "index.rb"),
+		filepath.Join(input.Build.Out, file+".rb"),
+		method,
+	}
+	name := "ruby"
+	if project.gemfile != "" {
+		name = "bundle"
+		args = append([]string{"exec", "ruby"}, args...)
+	}
+	cmd := process.Command(name, args...)
+	cmd.Env = append(input.Env, "AWS_LAMBDA_RUNTIME_API="+input.Server)
+	if project.gemfile != "" {
+		// use the gems installed for the original project
+		cmd.Env = append(cmd.Env, "BUNDLE_GEMFILE="+project.gemfile)
+	}
+	cmd.Dir = input.Build.Out
+	slog.Info("starting ruby worker", "args", cmd.Args)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %v", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stderr pipe: %v", err)
+	}
+	err = cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+	return &Worker{
+		stdout,
+		stderr,
+		cmd,
+	}, nil
+}
+
+func (r *Runtime) ShouldRebuild(functionID string, file string) bool {
+	base := filepath.Base(file)
+	if !strings.HasSuffix(file, ".rb") && base != "Gemfile" && base != "Gemfile.lock" {
+		return false
+	}
+	r.mut.Lock()
+	project, ok := r.projects[functionID]
+	r.mut.Unlock()
+	if !ok {
+		return false
+	}
+	rel, err := filepath.Rel(project.root, file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+		if ignoredDirs[part] {
+			return false
+		}
+	}
+	return true
+}
@@ -9,8 +9,11 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/sst/sst/v3/pkg/project/path"
 )
@@ -22,6 +25,19 @@ type Runtime interface {
 	ShouldRebuild(functionID string, path string) bool
 }
 
+// Debugger is implemented by runtimes that can run a worker with a debugger
+// attached. It returns the name of the debugger and the port it usually
+// listens on.
+type Debugger interface {
+	Debugger() (string, int)
+}
+
+// Process is implemented by workers that run as a process on this machine, so
+// their memory can be measured.
+type Process interface {
+	Pid() int
+}
+
 type Worker interface {
 	Stop()
 	Logs() io.ReadCloser
@@ -29,6 +45,7 @@ type Worker interface {
 
 type BuildInput struct {
 	CfgPath       string
+	Debug         bool                       `json:"-"`
 	Dev           bool                       `json:"dev"`
 	FunctionID    string                     `json:"functionID"`
 	Handler       string                     `json:"handler"`
@@ -41,6 +58,12 @@ type BuildInput struct {
 		From string `json:"from"`
 		To   string `json:"to"`
 	} `json:"copyFiles"`
+	// limits of the deployed function, in seconds and MB
+	Timeout int `json:"timeout"`
+	Memory  int `json:"memory"`
+	// Invoke builds into its own directory, so it doesn't replace the build
+	// of a worker running in `sst dev`
+	Invoke bool `json:"-"`
 }
 
 func (input *BuildInput) Out() string {
@@ -48,6 +71,9 @@ func (input *BuildInput) Out() string {
 	if input.Dev {
 		suffix = "-dev"
 	}
+	if input.Invoke {
+		suffix = "-invoke"
+	}
 	return filepath.Join(path.ResolveWorkingDir(input.CfgPath), "artifacts", input.FunctionID+suffix)
 }
 
@@ -56,6 +82,8 @@ type BuildOutput struct {
 	Handler    string   `json:"handler"`
 	Errors     []string `json:"errors"`
 	Sourcemaps []string `json:"sourcemaps"`
+	// files the function was built from, used to cache the build
+	Inputs []string `json:"-"`
 }
 
 type RunInput struct {
@@ -66,22 +94,86 @@ type RunInput struct {
 	WorkerID   string
 	Build      *BuildOutput
 	Env        []string
+	Debug      *Debug
+	// the memory limit of the worker in MB, not enforced when zero
+	Memory int
+}
+
+type Debug struct {
+	Debugger string
+	Port     int
 }
 
 type Collection struct {
 	runtimes []Runtime
 	cfgPath  string
+	stage    string
+	version  string
 	targets  map[string]*BuildInput
+	mut      sync.Mutex
+	debug    []string
+	ports    map[string]int
 }
 
-func NewCollection(platform string, runtimes ...Runtime) *Collection {
+func NewCollection(platform string, stage string, version string, runtimes ...Runtime) *Collection {
 	return &Collection{
 		runtimes: runtimes,
 		cfgPath:  platform,
+		stage:    stage,
+		version:  version,
 		targets:  map[string]*BuildInput{},
+		ports:    map[string]int{},
 	}
 }
 
+// SetDebug turns on the debugger for the functions matching the given names.
+// A name can be a glob, and "*" matches every function.
+func (c *Collection) SetDebug(functions []string) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.debug = functions
+}
+
+func (c *Collection) ShouldDebug(functionID string) bool {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	for _, pattern := range c.debug {
+		if match, _ := filepath.Match(pattern, functionID); match || strings.EqualFold(pattern, functionID) {
+			return true
+		}
+	}
+	return false
+}
+
+// debugPort returns the port for the debugger of a worker. A worker keeps its
+// port when it is restarted after a rebuild, so a debugger that reconnects
+// to it keeps its breakpoints.
+func (c *Collection) debugPort(workerID string, start int) int {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if port, ok := c.ports[workerID]; ok {
+		return port
+	}
+	taken := map[int]bool{}
+	for _, port := range c.ports {
+		taken[port] = true
+	}
+	port := start
+	for ; port < start+1000; port++ {
+		if taken[port] {
+			continue
+		}
+		listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+		if err != nil {
+			continue
+		}
+		listener.Close()
+		break
+	}
+	c.ports[workerID] = port
+	return port
+}
+
 func (c *Collection) Runtime(input string) (Runtime, bool) {
 	for _, runtime := range c.runtimes {
 		if runtime.Match(input) {
@@ -93,6 +185,13 @@ func (c *Collection) Runtime(input string) (Runtime, bool) {
 
 func (c *Collection) Build(ctx context.Context, input *BuildInput) (*BuildOutput, error) {
 	slog.Info("building function", "runtime", input.Runtime, "functionID", input.FunctionID)
+	input.Debug = input.Dev && c.ShouldDebug(input.FunctionID)
+	// an invoke is built from the saved target
+	if !input.Invoke {
+		if err := c.saveTarget(input); err != nil {
+			slog.Error("failed to save build input", "functionID", input.FunctionID, "err", err)
+		}
+	}
 	defer slog.Info("function built", "runtime", input.Runtime, "functionID", input.FunctionID)
 	out := input.Out()
 	var result *BuildOutput
@@ -118,7 +217,11 @@ func (c *Collection) Build(ctx context.Context, input *BuildInput) (*BuildOutput
 		if !ok {
 			return nil, fmt.Errorf("Runtime not found: %v", input.Runtime)
 		}
-		result, err = runtime.Build(ctx, input)
+		if cache := c.buildCache(input); cache != nil {
+			result, err = c.buildCached(ctx, runtime, cache, input, out)
+		} else {
+			result, err = runtime.Build(ctx, input)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -206,7 +309,19 @@ func (c *Collection) Run(ctx context.Context, input *RunInput) (Worker, error) {
 	if !ok {
 		return nil, fmt.Errorf("runtime not found")
 	}
-	return runtime.Run(ctx, input)
+	if debugger, ok := runtime.(Debugger); ok && c.ShouldDebug(input.FunctionID) {
+		name, port := debugger.Debugger()
+		input.Debug = &Debug{
+			Debugger: name,
+			Port:     c.debugPort(input.WorkerID, port),
+		}
+		slog.Info("debugging function", "functionID", input.FunctionID, "debugger", name, "port", input.Debug.Port)
+	}
+	worker, err := runtime.Run(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return limit(input, worker), nil
 }
 
 func (c *Collection) ShouldRebuild(runtime string, functionID string, file string) bool {
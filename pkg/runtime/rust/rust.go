@@ -0,0 +1,334 @@
+package rust
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/sst/sst/v3/internal/fs"
+	"github.com/sst/sst/v3/pkg/process"
+	"github.com/sst/sst/v3/pkg/runtime"
+)
+
+type Runtime struct {
+	mut        sync.Mutex
+	workspaces map[string]*workspace
+}
+
+type workspace struct {
+	root    string
+	target  string
+	members []string
+}
+
+type Worker struct {
+	stdout io.ReadCloser
+	stderr io.ReadCloser
+	cmd    *exec.Cmd
+}
+
+func (w *Worker) Stop() {
+	process.Kill(w.cmd.Process)
+}
+
+func (w *Worker) Pid() int {
+	return w.cmd.Process.Pid
+}
+
+func (w *Worker) Logs() io.ReadCloser {
+	reader, writer := io.Pipe()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, _ = io.Copy(writer, w.stdout)
+	}()
+	go func() {
+		defer wg.Done()
+		_, _ = io.Copy(writer, w.stderr)
+	}()
+
+	go func() {
+		wg.Wait()
+		defer writer.Close()
+	}()
+
+	return reader
+}
+
+func New() *Runtime {
+	return &Runtime{
+		workspaces: map[string]*workspace{},
+	}
+}
+
+func (r *Runtime) Match(runtime string) bool {
+	return runtime == "rust"
+}
+
+type Properties struct {
+	Architecture string `json:"architecture"`
+}
+
+type metadata struct {
+	WorkspaceRoot   string `json:"workspace_root"`
+	TargetDirectory string `json:"target_directory"`
+	Packages        []struct {
+		Name         string `json:"name"`
+		ManifestPath string `json:"manifest_path"`
+		Targets      []struct {
+			Name    string   `json:"name"`
+			Kind    []string `json:"kind"`
+			SrcPath string   `json:"src_path"`
+		} `json:"targets"`
+	} `json:"packages"`
+}
+
+func (r *Runtime) Build(ctx context.Context, input *runtime.BuildInput) (*runtime.BuildOutput, error) {
+	var properties Properties
+	json.Unmarshal(input.Properties, &properties)
+
+	handler, err := filepath.Abs(input.Handler)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := fs.FindUp(handler, "Cargo.toml")
+	if err != nil {
+		return nil, err
+	}
+	meta, err := loadMetadata(ctx, manifest)
+	if err != nil {
+		return &runtime.BuildOutput{
+			Errors: []string{err.Error()},
+		}, nil
+	}
+	bin, err := findBinary(meta, manifest, handler)
+	if err != nil {
+		return &runtime.BuildOutput{
+			Errors: []string{err.Error()},
+		}, nil
+	}
+
+	args := []string{"build", "--manifest-path", manifest, "--bin", bin}
+	env := os.Environ()
+	built := filepath.Join(meta.TargetDirectory, "debug", bin)
+	if !input.Dev {
+		target := resolveTarget(ctx, properties.Architecture)
+		args = append(args, "--release", "--target", target)
+		built = filepath.Join(meta.TargetDirectory, target, "release", bin)
+		if strings.HasSuffix(target, "-musl") {
+			env = append(env, "RUSTFLAGS="+strings.TrimSpace(os.Getenv("RUSTFLAGS")+" -C target-feature=+crt-static"))
+		}
+		// cargo-zigbuild takes care of the linker when cross compiling
+		if _, err := exec.LookPath("cargo-zigbuild"); err == nil {
+			args[0] = "zigbuild"
+		}
+	}
+	cmd := process.CommandContext(ctx, "cargo", args...)
+	cmd.Dir = filepath.Dir(manifest)
+	cmd.Env = env
+	slog.Info("running cargo build", "cmd", cmd.Args)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return &runtime.BuildOutput{
+			Errors: []string{string(output)},
+		}, nil
+	}
+	err = fs.CopyFile(built, filepath.Join(input.Out(), "bootstrap"), 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	members := []string{}
+	for _, pkg := range meta.Packages {
+		members = append(members, filepath.Dir(pkg.ManifestPath))
+	}
+	r.mut.Lock()
+	r.workspaces[input.FunctionID] = &workspace{
+		root:    meta.WorkspaceRoot,
+		target:  meta.TargetDirectory,
+		members: members,
+	}
+	r.mut.Unlock()
+	return &runtime.BuildOutput{
+		Handler:    "bootstrap",
+		Sourcemaps: []string{},
+		Errors:     []string{},
+		Inputs:     listInputs(meta.WorkspaceRoot, meta.TargetDirectory, members),
+	}, nil
+}
+
+// listInputs finds the sources and manifests of the workspace members, along
+// with the manifest of the workspace itself.
+func listInputs(root string, target string, members []string) []string {
+	result := []string{filepath.Join(root, "Cargo.toml")}
+	for _, member := range members {
+		filepath.Walk(member, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if file == target || info.Name() == ".git" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasSuffix(file, ".rs") || (info.Name() == "Cargo.toml" && file != result[0]) {
+				result = append(result, file)
+			}
+			return nil
+		})
+	}
+	return result
+}
+
+func (r *Runtime) Toolchain(ctx context.Context, input *runtime.BuildInput) string {
+	cmd := process.CommandContext(ctx, "rustc", "--version")
+	cmd.Dir = filepath.Dir(input.Handler)
+	output, _ := cmd.Output()
+	return strings.TrimSpace(string(output))
+}
+
+func loadMetadata(ctx context.Context, manifest string) (*metadata, error) {
+	cmd := process.CommandContext(ctx, "cargo", "metadata", "--format-version", "1", "--no-deps", "--manifest-path", manifest)
+	cmd.Dir = filepath.Dir(manifest)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cargo metadata: %s", stderr.String())
+	}
+	var result metadata
+	err = json.Unmarshal(output, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// findBinary picks the bin target to build. If the handler points to a source
+// file it uses the bin for that file, otherwise the bin named after the
+// package, or its only bin.
+func findBinary(meta *metadata, manifest string, handler string) (string, error) {
+	for _, pkg := range meta.Packages {
+		if filepath.Clean(pkg.ManifestPath) != filepath.Clean(manifest) {
+			continue
+		}
+		bins := []string{}
+		for _, target := range pkg.Targets {
+			for _, kind := range target.Kind {
+				if kind != "bin" {
+					continue
+				}
+				if filepath.Clean(target.SrcPath) == handler {
+					return target.Name, nil
+				}
+				bins = append(bins, target.Name)
+			}
+		}
+		for _, bin := range bins {
+			if bin == pkg.Name {
+				return bin, nil
+			}
+		}
+		if len(bins) == 1 {
+			return bins[0], nil
+		}
+		if len(bins) == 0 {
+			return "", fmt.Errorf("crate %s has no binary targets", pkg.Name)
+		}
+		return "", fmt.Errorf("crate %s has multiple binary targets, point the handler to one of them: %s", pkg.Name, strings.Join(bins, ", "))
+	}
+	return "", fmt.Errorf("%s is a virtual manifest, point the handler to a crate in the workspace", manifest)
+}
+
+// resolveTarget prefers musl so the binary is statically linked, but falls
+// back to gnu if only that target is installed.
+func resolveTarget(ctx context.Context, architecture string) string {
+	arch := "x86_64"
+	if architecture == "arm64" {
+		arch = "aarch64"
+	}
+	musl := arch + "-unknown-linux-musl"
+	gnu := arch + "-unknown-linux-gnu"
+	output, err := process.CommandContext(ctx, "rustup", "target", "list", "--installed").Output()
+	if err != nil {
+		return musl
+	}
+	installed := strings.Fields(string(output))
+	for _, target := range installed {
+		if target == musl {
+			return musl
+		}
+	}
+	for _, target := range installed {
+		if target == gnu {
+			slog.Info("musl target not installed, using gnu", "target", gnu)
+			return gnu
+		}
+	}
+	return musl
+}
+
+func (r *Runtime) Run(ctx context.Context, input *runtime.RunInput) (runtime.Worker, error) {
+	cmd := process.Command(
+		filepath.Join(input.Build.Out, input.Build.Handler),
+	)
+	slog.Info("running rust function", "server", input.Server)
+	cmd.Env = input.Env
+	cmd.Env = append(cmd.Env, "AWS_LAMBDA_RUNTIME_API="+input.Server)
+	cmd.Dir = input.Build.Out
+	stdout, _ := cmd.StdoutPipe()
+	stderr, _ := cmd.StderrPipe()
+	err := cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+	return &Worker{
+		stdout,
+		stderr,
+		cmd,
+	}, nil
+}
+
+func (r *Runtime) ShouldRebuild(functionID string, file string) bool {
+	base := filepath.Base(file)
+	if !strings.HasSuffix(file, ".rs") && base != "Cargo.toml" && base != "Cargo.lock" {
+		return false
+	}
+	r.mut.Lock()
+	ws, ok := r.workspaces[functionID]
+	r.mut.Unlock()
+	if !ok {
+		return false
+	}
+	if contains(ws.target, file) {
+		return false
+	}
+	if base == "Cargo.lock" || file == filepath.Join(ws.root, "Cargo.toml") {
+		return contains(ws.root, file)
+	}
+	for _, member := range ws.members {
+		if contains(member, file) {
+			slog.Info("checking if file needs to be rebuilt", "file", file, "match", member)
+			return true
+		}
+	}
+	return false
+}
+
+func contains(dir string, file string) bool {
+	rel, err := filepath.Rel(dir, file)
+	if err != nil {
+		return false
+	}
+	return !strings.HasPrefix(rel, "..")
+}
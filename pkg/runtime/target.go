@@ -0,0 +1,55 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sst/sst/v3/pkg/project/path"
+)
+
+var ErrFunctionNotFound = fmt.Errorf("function has not been built")
+
+// targets are kept per stage, so deploying one stage doesn't change what
+// another one invokes with.
+func (c *Collection) targetPath(functionID string) string {
+	return filepath.Join(path.ResolveWorkingDir(c.cfgPath), "functions", c.stage, functionID+".json")
+}
+
+// saveTarget records the last build input of a function, so it can be built
+// again without deploying, like in `sst invoke`.
+func (c *Collection) saveTarget(input *BuildInput) error {
+	if input.FunctionID == "" {
+		return nil
+	}
+	saved := *input
+	saved.EncryptionKey = ""
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return err
+	}
+	file := c.targetPath(input.FunctionID)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0600)
+}
+
+// LoadTarget returns the last build input of a function that was deployed or
+// run in dev.
+func (c *Collection) LoadTarget(functionID string) (*BuildInput, error) {
+	data, err := os.ReadFile(c.targetPath(functionID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s %w", functionID, ErrFunctionNotFound)
+		}
+		return nil, err
+	}
+	var input BuildInput
+	if err := json.Unmarshal(data, &input); err != nil {
+		return nil, err
+	}
+	input.CfgPath = c.cfgPath
+	return &input, nil
+}
@@ -0,0 +1,42 @@
+package runtime
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestTargetStage(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "sst.config.ts")
+	write(t, cfgPath, "")
+
+	production := &Collection{cfgPath: cfgPath, stage: "production"}
+	personal := &Collection{cfgPath: cfgPath, stage: "frank"}
+	save := func(c *Collection, env string) {
+		t.Helper()
+		err := c.saveTarget(&BuildInput{
+			FunctionID: "fn",
+			Runtime:    "nodejs20.x",
+			Links:      map[string]json.RawMessage{"env": json.RawMessage(`"` + env + `"`)},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	save(personal, "frank")
+	save(production, "production")
+
+	input, err := personal.LoadTarget("fn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(input.Links["env"]); got != `"frank"` {
+		t.Fatalf("expected the target of the stage, got %s", got)
+	}
+	other := &Collection{cfgPath: cfgPath, stage: "staging"}
+	if _, err := other.LoadTarget("fn"); !errors.Is(err, ErrFunctionNotFound) {
+		t.Fatalf("expected %v, got %v", ErrFunctionNotFound, err)
+	}
+}
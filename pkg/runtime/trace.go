@@ -0,0 +1,116 @@
+package runtime
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sst/sst/v3/pkg/js"
+)
+
+// Frame is a line of a stack trace. The location is filled in when the line
+// could be parsed, and points to the original source when the function was
+// built with source maps.
+type Frame struct {
+	Raw      string `json:"raw"`
+	Function string `json:"function,omitempty"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	// false for frames in node_modules and node itself, which are collapsed
+	Important bool `json:"important"`
+}
+
+// matches the frames in a v8 stack trace, like `at handler (/out/index.mjs:3:9)`
+// or `at /out/index.mjs:3:9`
+var v8Frame = regexp.MustCompile(`^\s*at (?:(.+?) \()?(.+?):(\d+):(\d+)\)?$`)
+
+// ResolveTrace parses the stack trace of an error thrown by the function and
+// maps each frame through the source maps in the output of its build.
+func (b *BuildOutput) ResolveTrace(trace []string) []Frame {
+	result := make([]Frame, 0, len(trace))
+	for _, raw := range trace {
+		frame := Frame{Raw: raw}
+		match := v8Frame.FindStringSubmatch(raw)
+		if match == nil {
+			result = append(result, frame)
+			continue
+		}
+		frame.Function = strings.TrimPrefix(match[1], "async ")
+		frame.File = match[2]
+		frame.Line, _ = strconv.Atoi(match[3])
+		frame.Column, _ = strconv.Atoi(match[4])
+		if strings.HasPrefix(frame.File, "file://") {
+			if parsed, err := url.Parse(frame.File); err == nil {
+				frame.File = parsed.Path
+			}
+		}
+		if b != nil {
+			if sourcemap := b.sourcemap(frame.File); sourcemap != nil {
+				if position, ok := sourcemap.Find(frame.Line, frame.Column); ok {
+					frame.File = position.Source
+					frame.Line = position.Line
+					frame.Column = position.Column
+				}
+			}
+		}
+		frame.Important = !strings.HasPrefix(frame.File, "node:") &&
+			!strings.Contains(filepath.ToSlash(frame.File), "/node_modules/") &&
+			filepath.IsAbs(frame.File)
+		result = append(result, frame)
+	}
+	return result
+}
+
+// sourcemap returns the map for a file in the output, which esbuild writes
+// next to it. Maps of files outside the output are not used.
+func (b *BuildOutput) sourcemap(file string) *js.Sourcemap {
+	if b.Out == "" || !filepath.IsAbs(file) {
+		return nil
+	}
+	out, err := filepath.Abs(b.Out)
+	if err != nil {
+		return nil
+	}
+	rel, err := filepath.Rel(out, file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return nil
+	}
+	return sourcemaps.get(file + ".map")
+}
+
+type sourcemapCache struct {
+	mut   sync.Mutex
+	items map[string]cachedSourcemap
+}
+
+type cachedSourcemap struct {
+	modified time.Time
+	value    *js.Sourcemap
+}
+
+// parsed maps are kept until the function is rebuilt
+var sourcemaps = &sourcemapCache{items: map[string]cachedSourcemap{}}
+
+func (c *sourcemapCache) get(path string) *js.Sourcemap {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if item, ok := c.items[path]; ok && item.modified.Equal(info.ModTime()) {
+		return item.value
+	}
+	value, err := js.ReadSourcemap(path)
+	if err != nil {
+		value = nil
+	}
+	c.items[path] = cachedSourcemap{modified: info.ModTime(), value: value}
+	return value
+}
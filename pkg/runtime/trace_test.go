@@ -0,0 +1,153 @@
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	esbuild "github.com/evanw/esbuild/pkg/api"
+)
+
+// position returns the line and column of the text in the file, both starting
+// at 1.
+func position(t *testing.T, file string, text string) (int, int) {
+	t.Helper()
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for index, line := range strings.Split(string(data), "\n") {
+		if column := strings.Index(line, text); column != -1 {
+			return index + 1, column + 1
+		}
+	}
+	t.Fatalf("%q is not in %s", text, file)
+	return 0, 0
+}
+
+func TestResolveTrace(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "src", "handler.ts")
+	write(t, source, `export async function handler() {
+  const value = compute(1);
+  return value;
+}
+
+function compute(input: number): number {
+  throw new Error("boom " + input);
+}
+`)
+	out := filepath.Join(dir, "out")
+	file := filepath.Join(out, "handler.mjs")
+	built := esbuild.Build(esbuild.BuildOptions{
+		EntryPoints:       []string{source},
+		Outfile:           file,
+		Bundle:            true,
+		Format:            esbuild.FormatESModule,
+		Platform:          esbuild.PlatformNode,
+		Sourcemap:         esbuild.SourceMapLinked,
+		MinifyIdentifiers: true,
+		Write:             true,
+	})
+	if len(built.Errors) > 0 {
+		t.Fatal(built.Errors[0].Text)
+	}
+	at := func(text string) string {
+		line, column := position(t, file, text)
+		return fmt.Sprintf("%s:%d:%d", file, line, column)
+	}
+	// a map next to a file outside the output isn't used
+	outside := filepath.Join(dir, "other.mjs")
+	write(t, outside+".map", "{}")
+
+	tests := []struct {
+		name     string
+		raw      string
+		expected Frame
+	}{
+		{
+			name:     "message",
+			raw:      "Error: boom 1",
+			expected: Frame{},
+		},
+		{
+			name:     "function",
+			raw:      "    at r (" + at("throw") + ")",
+			expected: Frame{Function: "r", File: source, Line: 7, Column: 3, Important: true},
+		},
+		{
+			name:     "async function",
+			raw:      "    at async o (" + at("r(1)") + ")",
+			expected: Frame{Function: "o", File: source, Line: 2, Column: 17, Important: true},
+		},
+		{
+			name:     "without function",
+			raw:      "    at " + at("throw"),
+			expected: Frame{File: source, Line: 7, Column: 3, Important: true},
+		},
+		{
+			name:     "file url",
+			raw:      "    at r (file://" + at("throw") + ")",
+			expected: Frame{Function: "r", File: source, Line: 7, Column: 3, Important: true},
+		},
+		{
+			name:     "node_modules",
+			raw:      "    at Object.parse (/app/node_modules/lib/index.js:10:5)",
+			expected: Frame{Function: "Object.parse", File: "/app/node_modules/lib/index.js", Line: 10, Column: 5},
+		},
+		{
+			name:     "node",
+			raw:      "    at process.processTicksAndRejections (node:internal/process/task_queues:95:5)",
+			expected: Frame{Function: "process.processTicksAndRejections", File: "node:internal/process/task_queues", Line: 95, Column: 5},
+		},
+		{
+			name:     "outside the output",
+			raw:      "    at main (" + outside + ":3:4)",
+			expected: Frame{Function: "main", File: outside, Line: 3, Column: 4, Important: true},
+		},
+	}
+	build := &BuildOutput{Out: out}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			frames := build.ResolveTrace([]string{test.raw})
+			if len(frames) != 1 {
+				t.Fatalf("expected one frame, got %d", len(frames))
+			}
+			test.expected.Raw = test.raw
+			if frames[0] != test.expected {
+				t.Errorf("expected %+v, got %+v", test.expected, frames[0])
+			}
+		})
+	}
+
+	// frames in dependencies are collapsed by the ui, so only the ones in the
+	// function stay important
+	t.Run("collapsed", func(t *testing.T) {
+		frames := build.ResolveTrace([]string{
+			"Error: boom 1",
+			"    at r (" + at("throw") + ")",
+			"    at Object.parse (/app/node_modules/lib/index.js:10:5)",
+			"    at /app/node_modules/.pnpm/lib@1.0.0/node_modules/lib/index.js:3:1",
+			"    at async o (" + at("r(1)") + ")",
+			"    at process.processTicksAndRejections (node:internal/process/task_queues:95:5)",
+		})
+		expected := []bool{false, true, false, false, true, false}
+		for index, frame := range frames {
+			if frame.Important != expected[index] {
+				t.Errorf("%s: expected important to be %v", frame.Raw, expected[index])
+			}
+		}
+	})
+
+	t.Run("without build", func(t *testing.T) {
+		raw := "    at r (" + at("throw") + ")"
+		frames := (*BuildOutput)(nil).ResolveTrace([]string{raw})
+		line, column := position(t, file, "throw")
+		expected := Frame{Raw: raw, Function: "r", File: file, Line: line, Column: column, Important: true}
+		if frames[0] != expected {
+			t.Errorf("expected %+v, got %+v", expected, frames[0])
+		}
+	})
+}
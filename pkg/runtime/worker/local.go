@@ -0,0 +1,203 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"github.com/sst/sst/v3/internal/fs"
+	"github.com/sst/sst/v3/pkg/process"
+	"github.com/sst/sst/v3/pkg/project/path"
+	"github.com/sst/sst/v3/pkg/runtime"
+)
+
+// same as the deployed worker script
+const compatibilityDate = "2024-09-23"
+
+type Worker struct {
+	stdout io.ReadCloser
+	stderr io.ReadCloser
+	cmd    *exec.Cmd
+}
+
+func (w *Worker) Stop() {
+	process.Kill(w.cmd.Process)
+}
+
+func (w *Worker) Pid() int {
+	return w.cmd.Process.Pid
+}
+
+func (w *Worker) Logs() io.ReadCloser {
+	reader, writer := io.Pipe()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, _ = io.Copy(writer, w.stdout)
+	}()
+	go func() {
+		defer wg.Done()
+		_, _ = io.Copy(writer, w.stderr)
+	}()
+
+	go func() {
+		wg.Wait()
+		defer writer.Close()
+	}()
+
+	return reader
+}
+
+type wranglerConfig struct {
+	Name               string            `toml:"name"`
+	Main               string            `toml:"main"`
+	CompatibilityDate  string            `toml:"compatibility_date"`
+	CompatibilityFlags []string          `toml:"compatibility_flags"`
+	NoBundle           bool              `toml:"no_bundle"`
+	Vars               map[string]string `toml:"vars,omitempty"`
+	KVNamespaces       []kvNamespace     `toml:"kv_namespaces,omitempty"`
+	R2Buckets          []r2Bucket        `toml:"r2_buckets,omitempty"`
+	D1Databases        []d1Database      `toml:"d1_databases,omitempty"`
+}
+
+type kvNamespace struct {
+	Binding string `toml:"binding"`
+	ID      string `toml:"id"`
+}
+
+type r2Bucket struct {
+	Binding    string `toml:"binding"`
+	BucketName string `toml:"bucket_name"`
+}
+
+type d1Database struct {
+	Binding      string `toml:"binding"`
+	DatabaseName string `toml:"database_name"`
+	DatabaseID   string `toml:"database_id"`
+}
+
+// Run starts the built worker locally in workerd, through wrangler's local
+// mode so KV, R2, and D1 bindings are simulated and persisted in
+// .sst/cloudflare. The worker listens on input.Server.
+func (r *Runtime) Run(ctx context.Context, input *runtime.RunInput) (runtime.Worker, error) {
+	r.lock.RLock()
+	properties, ok := r.properties[input.FunctionID]
+	r.lock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("worker not built: %v", input.FunctionID)
+	}
+	host, port, ok := strings.Cut(input.Server, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid address for worker: %v", input.Server)
+	}
+
+	config := filepath.Join(input.Build.Out, "wrangler.toml")
+	err := writeConfig(config, input.FunctionID, filepath.Join(input.Build.Out, input.Build.Handler), properties)
+	if err != nil {
+		return nil, err
+	}
+	persist := filepath.Join(path.ResolveWorkingDir(input.CfgPath), "cloudflare", input.FunctionID)
+	name, err := wrangler(path.ResolveRootDir(input.CfgPath))
+	if err != nil {
+		return nil, err
+	}
+	args := []string{
+		"dev",
+		"--local",
+		"--config", config,
+		"--ip", host,
+		"--port", port,
+		"--persist-to", persist,
+		"--log-level", "warn",
+		"--show-interactive-dev-session=false",
+	}
+	cmd := process.Command(name, args...)
+	cmd.Env = append(os.Environ(), input.Env...)
+	cmd.Dir = input.Build.Out
+	slog.Info("starting local worker", "args", cmd.Args)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %v", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stderr pipe: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start wrangler: %v", err)
+	}
+	return &Worker{
+		stdout,
+		stderr,
+		cmd,
+	}, nil
+}
+
+var ErrWranglerNotFound = fmt.Errorf("wrangler is not installed, run `npm install --save-dev wrangler` to run workers locally")
+
+// wrangler uses the version installed in the app, or the one on the PATH. It's
+// never downloaded, so workers can run without a network connection.
+func wrangler(root string) (string, error) {
+	if bin, err := fs.FindUp(root, filepath.Join("node_modules", ".bin", "wrangler")); err == nil {
+		return bin, nil
+	}
+	if bin, err := exec.LookPath("wrangler"); err == nil {
+		return bin, nil
+	}
+	return "", ErrWranglerNotFound
+}
+
+func writeConfig(file string, functionID string, main string, properties *Properties) error {
+	name := properties.ScriptName
+	if name == "" {
+		name = strings.ToLower(functionID)
+	}
+	config := wranglerConfig{
+		Name:               name,
+		Main:               main,
+		CompatibilityDate:  compatibilityDate,
+		CompatibilityFlags: []string{"nodejs_compat"},
+		NoBundle:           true,
+		Vars:               map[string]string{},
+	}
+	for key, value := range properties.Environment {
+		config.Vars[key] = value
+	}
+	types := make([]string, 0, len(properties.Bindings))
+	for typ := range properties.Bindings {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	for _, typ := range types {
+		for _, binding := range properties.Bindings[typ] {
+			switch typ {
+			case "plainTextBindings", "secretTextBindings":
+				config.Vars[binding.Name] = binding.Text
+			case "kvNamespaceBindings":
+				config.KVNamespaces = append(config.KVNamespaces, kvNamespace{binding.Name, binding.NamespaceID})
+			case "r2BucketBindings":
+				config.R2Buckets = append(config.R2Buckets, r2Bucket{binding.Name, binding.BucketName})
+			case "d1DatabaseBindings":
+				config.D1Databases = append(config.D1Databases, d1Database{binding.Name, binding.Name, binding.DatabaseID})
+			default:
+				slog.Info("binding not supported locally", "functionID", functionID, "type", typ, "name", binding.Name)
+			}
+		}
+	}
+	out, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	return toml.NewEncoder(out).Encode(config)
+}
@@ -19,16 +19,33 @@ import (
 )
 
 type Runtime struct {
-	contexts map[string]esbuild.BuildContext
-	results  map[string]esbuild.BuildResult
-	lock     sync.RWMutex
-	unenv    *unenv
+	contexts   map[string]esbuild.BuildContext
+	results    map[string]esbuild.BuildResult
+	properties map[string]*Properties
+	lock       sync.RWMutex
+	unenv      *unenv
 }
 
 type Properties struct {
 	AccountID  string              `json:"accountID"`
 	ScriptName string              `json:"scriptName"`
 	Build      node.NodeProperties `json:"build"`
+	// run the worker locally in dev instead of updating the deployed script
+	Local       bool                 `json:"local"`
+	Bindings    map[string][]Binding `json:"bindings"`
+	Environment map[string]string    `json:"environment"`
+}
+
+// Binding is a binding of the worker, keyed by its type like
+// `kvNamespaceBindings` in Properties.Bindings.
+type Binding struct {
+	Name        string `json:"name"`
+	Text        string `json:"text"`
+	NamespaceID string `json:"namespaceId"`
+	BucketName  string `json:"bucketName"`
+	DatabaseID  string `json:"databaseId"`
+	Service     string `json:"service"`
+	Queue       string `json:"queue"`
 }
 
 type unenv struct {
@@ -44,10 +61,11 @@ func New() *Runtime {
 	var unenv unenv
 	json.Unmarshal(data, &unenv)
 	return &Runtime{
-		contexts: map[string]esbuild.BuildContext{},
-		results:  map[string]esbuild.BuildResult{},
-		lock:     sync.RWMutex{},
-		unenv:    &unenv,
+		contexts:   map[string]esbuild.BuildContext{},
+		results:    map[string]esbuild.BuildResult{},
+		properties: map[string]*Properties{},
+		lock:       sync.RWMutex{},
+		unenv:      &unenv,
 	}
 }
 
@@ -55,6 +73,9 @@ func (w *Runtime) Build(ctx context.Context, input *runtime.BuildInput) (*runtim
 	var properties Properties
 	json.Unmarshal(input.Properties, &properties)
 	build := properties.Build
+	w.lock.Lock()
+	w.properties[input.FunctionID] = &properties
+	w.lock.Unlock()
 
 	abs, err := filepath.Abs(input.Handler)
 	if err != nil {
@@ -204,10 +225,6 @@ func (r *Runtime) ShouldRebuild(functionID string, file string) bool {
 	return false
 }
 
-func (r *Runtime) Run(ctx context.Context, input *runtime.RunInput) (runtime.Worker, error) {
-	return nil, fmt.Errorf("not implemented")
-}
-
 var NODE_BUILTINS = map[string]bool{
 	"assert":              true,
 	"async_hooks":         true,
@@ -2,6 +2,7 @@ package server
 
 import (
 	"errors"
+	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -31,6 +32,11 @@ func resolveServerFile(cfgPath, stage string) string {
 	return filepath.Join(project.ResolveWorkingDir(cfgPath), stage+".server")
 }
 
+// the token is kept apart from the url so it can only be read by the user
+func resolveTokenFile(cfgPath, stage string) string {
+	return filepath.Join(project.ResolveWorkingDir(cfgPath), stage+".server.token")
+}
+
 var ErrServerNotFound = errors.New("server not found")
 
 func Discover(cfgPath string, stage string) (string, error) {
@@ -45,5 +51,15 @@ func Discover(cfgPath string, stage string) (string, error) {
 		}
 		return "", err
 	}
-	return string(contents), nil
+	token, err := os.ReadFile(resolveTokenFile(cfgPath, stage))
+	if err != nil {
+		// written by a version of sst dev without tokens
+		return string(contents), nil
+	}
+	u, err := url.Parse(string(contents))
+	if err != nil {
+		return "", err
+	}
+	u.User = url.UserPassword("sst", string(token))
+	return u.String(), nil
 }
@@ -2,6 +2,9 @@ package server
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"log/slog"
@@ -13,7 +16,11 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
+	"github.com/gorilla/websocket"
+	"github.com/sst/sst/v3/pkg/flag"
 	"github.com/sst/sst/v3/pkg/global"
 	"github.com/sst/sst/v3/pkg/project"
 	"github.com/sst/sst/v3/pkg/server/aws"
@@ -26,18 +33,42 @@ type Server struct {
 	Port int
 	Mux  *http.ServeMux
 	Rpc  *rpc.Server
+	// required on every request, generated for each session
+	Token string
+	// the address to listen on, only loopback unless SST_SERVER_HOST is set
+	Host string
+	// loopback is listened on as well when Host is another interface
+	hosts []string
+}
+
+// origins of the browser apps that can connect to the socket, since browsers
+// can't pass a token to a websocket
+var allowedOrigins = []string{
+	"https://console.sst.dev",
 }
 
 func New() (*Server, error) {
-	port, err := port()
+	host := flag.SST_SERVER_HOST
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	hosts := listenHosts(host)
+	port, err := port(hosts)
 	slog.Info("server port assigned", "port", port)
 	if err != nil {
 		return nil, err
 	}
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
 	result := &Server{
-		Port: port,
-		Mux:  http.NewServeMux(),
-		Rpc:  rpc.NewServer(),
+		Port:  port,
+		Mux:   http.NewServeMux(),
+		Rpc:   rpc.NewServer(),
+		Token: hex.EncodeToString(token),
+		Host:  host,
+		hosts: hosts,
 	}
 	result.Mux.HandleFunc("/rpc", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != "POST" {
@@ -50,6 +81,76 @@ func New() (*Server, error) {
 	return result, nil
 }
 
+// listenHosts adds loopback to the host, since the workers and the processes
+// sst starts always reach the server on it. A wildcard address already covers
+// it.
+func listenHosts(host string) []string {
+	if host == "localhost" {
+		return []string{"127.0.0.1"}
+	}
+	ip := net.ParseIP(host)
+	if ip != nil && (ip.IsUnspecified() || ip.Equal(net.IPv4(127, 0, 0, 1))) {
+		return []string{host}
+	}
+	return []string{host, "127.0.0.1"}
+}
+
+// URL is what processes on this machine use to reach the server, with the
+// token in it. It's passed to them in SST_SERVER.
+func (s *Server) URL() string {
+	u := &url.URL{
+		Scheme: "http",
+		User:   url.UserPassword("sst", s.Token),
+		Host:   fmt.Sprintf("127.0.0.1:%d", s.Port),
+	}
+	return u.String()
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		slog.Info("unauthorized request", "addr", r.RemoteAddr, "url", r.URL.Path)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	s.Mux.ServeHTTP(w, r)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	// the lambda runtime clients in the workers can't be given a token, but
+	// the workers always run on this machine
+	if strings.HasPrefix(r.URL.Path, "/lambda/") {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		ip := net.ParseIP(host)
+		return ip != nil && ip.IsLoopback()
+	}
+	// the socket checks the origin itself when it upgrades, any other route
+	// can be reached with the same headers so it needs the token
+	if r.URL.Path == "/socket" && r.Header.Get("Origin") != "" && websocket.IsWebSocketUpgrade(r) && s.CheckOrigin(r) {
+		return true
+	}
+	return s.hasToken(r)
+}
+
+func (s *Server) hasToken(r *http.Request) bool {
+	token := ""
+	if _, password, ok := r.BasicAuth(); ok {
+		token = password
+	} else if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		token = bearer
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) == 1
+}
+
+// CheckOrigin only lets browsers connect to a websocket from the allowed
+// origins. Other clients don't send an origin and need the token instead.
+func (s *Server) CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	return slices.Contains(allowedOrigins, origin)
+}
+
 func (s *Server) Start(ctx context.Context, p *project.Project) error {
 	defer slog.Info("server done")
 
@@ -59,30 +160,53 @@ func (s *Server) Start(ctx context.Context, p *project.Project) error {
 	runtime.Register(ctx, p, s.Rpc)
 
 	server := &http.Server{
-		Handler: s.Mux,
+		Handler: s,
+	}
+	listeners := []net.Listener{}
+	for _, host := range s.hosts {
+		listener, err := net.Listen("tcp", net.JoinHostPort(host, fmt.Sprint(s.Port)))
+		if err != nil {
+			for _, item := range listeners {
+				item.Close()
+			}
+			return err
+		}
+		slog.Info("server", "addr", listener.Addr().String())
+		listeners = append(listeners, listener)
 	}
-	server.Addr = fmt.Sprintf("0.0.0.0:%d", s.Port)
-	slog.Info("server", "addr", server.Addr)
 	serverPath := resolveServerFile(p.PathConfig(), p.App().Stage)
-	u, _ := url.Parse("http://" + server.Addr)
+	tokenPath := resolveTokenFile(p.PathConfig(), p.App().Stage)
+	u, _ := url.Parse(fmt.Sprintf("http://127.0.0.1:%d", s.Port))
+	os.WriteFile(tokenPath, []byte(s.Token), 0600)
+	defer os.Remove(tokenPath)
 	os.WriteFile(serverPath, []byte(u.String()), 0644)
 	defer os.Remove(serverPath)
-	go server.ListenAndServe()
+	for _, listener := range listeners {
+		go server.Serve(listener)
+	}
 
 	keyPath := filepath.Join(global.CertPath(), "key.pem")
 	certPath := filepath.Join(global.CertPath(), "cert.pem")
 	if _, err := os.Stat(keyPath); err == nil {
 		slog.Info("https enabled")
 		proxy := httputil.NewSingleHostReverseProxy(u)
-		go http.ListenAndServeTLS(
-			fmt.Sprintf("0.0.0.0:%d", s.Port+1000),
-			certPath,
-			keyPath,
-			proxy,
-		)
-		if err != nil {
-			slog.Error("failed to start https server", "err", err)
-			return err
+		// the proxy connects over loopback, so the workers' exception from the
+		// token can't apply to what it forwards
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasPrefix(r.URL.Path, "/lambda/") && !s.hasToken(r) {
+				slog.Info("unauthorized request", "addr", r.RemoteAddr, "url", r.URL.Path)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			proxy.ServeHTTP(w, r)
+		})
+		for _, host := range s.hosts {
+			go func() {
+				err := http.ListenAndServeTLS(net.JoinHostPort(host, fmt.Sprint(s.Port+1000)), certPath, keyPath, handler)
+				if err != nil {
+					slog.Error("failed to start https server", "host", host, "err", err)
+				}
+			}()
 		}
 	}
 
@@ -92,19 +216,28 @@ func (s *Server) Start(ctx context.Context, p *project.Project) error {
 	return nil
 }
 
-func port() (int, error) {
+// port finds a port that's free on every host.
+func port(hosts []string) (int, error) {
 	port := 13557
 	for {
 		if port == 65535 {
 			return 0, fmt.Errorf("no port available")
 		}
-		listener, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", port))
-		if err != nil {
-			port++
-			continue
+		listeners := []net.Listener{}
+		for _, host := range hosts {
+			listener, err := net.Listen("tcp", net.JoinHostPort(host, fmt.Sprint(port)))
+			if err != nil {
+				break
+			}
+			listeners = append(listeners, listener)
+		}
+		for _, listener := range listeners {
+			listener.Close()
+		}
+		if len(listeners) == len(hosts) {
+			return port, nil
 		}
-		defer listener.Close()
-		return port, nil
+		port++
 	}
 }
 
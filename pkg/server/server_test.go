@@ -0,0 +1,53 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthorized(t *testing.T) {
+	s := &Server{
+		Mux:   http.NewServeMux(),
+		Token: "secret",
+	}
+	s.Mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	upgrade := map[string]string{
+		"Origin":     allowedOrigins[0],
+		"Connection": "Upgrade",
+		"Upgrade":    "websocket",
+	}
+	tests := []struct {
+		name     string
+		path     string
+		headers  map[string]string
+		remote   string
+		expected int
+	}{
+		{"token", "/api/env", map[string]string{"Authorization": "Bearer secret"}, "10.0.0.2:1234", http.StatusOK},
+		{"wrong token", "/api/env", map[string]string{"Authorization": "Bearer wrong"}, "10.0.0.2:1234", http.StatusUnauthorized},
+		{"no token", "/rpc", nil, "127.0.0.1:1234", http.StatusUnauthorized},
+		{"socket from allowed origin", "/socket", upgrade, "10.0.0.2:1234", http.StatusOK},
+		{"socket from other origin", "/socket", map[string]string{"Origin": "https://example.com", "Connection": "Upgrade", "Upgrade": "websocket"}, "10.0.0.2:1234", http.StatusUnauthorized},
+		{"forged upgrade", "/api/env", upgrade, "10.0.0.2:1234", http.StatusUnauthorized},
+		{"forged upgrade to rpc", "/rpc", upgrade, "10.0.0.2:1234", http.StatusUnauthorized},
+		{"lambda from loopback", "/lambda/worker/2018-06-01/runtime/invocation/next", nil, "127.0.0.1:1234", http.StatusOK},
+		{"lambda from elsewhere", "/lambda/worker/2018-06-01/runtime/invocation/next", nil, "10.0.0.2:1234", http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", test.path, nil)
+			r.RemoteAddr = test.remote
+			for key, value := range test.headers {
+				r.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, r)
+			if w.Code != test.expected {
+				t.Errorf("expected %d, got %d", test.expected, w.Code)
+			}
+		})
+	}
+}
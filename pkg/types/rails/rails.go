@@ -5,33 +5,66 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/sst/sst/v3/internal/fs"
 	"github.com/sst/sst/v3/pkg/project/common"
 )
 
 func Generate(root string, links common.Links) error {
-	return nil
 	projects := fs.FindDown(root, "config.ru")
 	files := []io.Writer{}
 	for _, project := range projects {
+		// skip gems vendored with bundler
+		if strings.Contains(project, string(filepath.Separator)+"vendor"+string(filepath.Separator)) {
+			continue
+		}
 		// check if lib path exists
 		if _, err := os.Stat(filepath.Join(filepath.Dir(project), "lib")); err == nil {
 			path := filepath.Join(filepath.Dir(project), "lib", "sst.rb")
-			file, _ := os.Create(path)
+			file, err := os.Create(path)
+			if err != nil {
+				return err
+			}
 			files = append(files, file)
 		}
 	}
+	if len(files) == 0 {
+		return nil
+	}
 	writer := io.MultiWriter(files...)
-	writer.Write([]byte(`require 'json'
+	writer.Write([]byte(`# Automatically generated by SST
+require 'json'
+
 module SST
   class << self
-    private
+    def resource(name)
+      @resources ||= {}
+      return @resources[name] if @resources.key?(name)
+      value = parse_json(ENV["SST_RESOURCE_#{name}"])
+      value = resources_file[name] if value.nil?
+      raise KeyError, "SST resource #{name} is not linked" if value.nil?
+      @resources[name] = value
+    end
+`,
+	))
 
-    def parse_resource(resource_name)
-      env_var = "SST_RESOURCE_#{resource_name}"
-      parse_json(ENV[env_var])
+	names := make([]string, 0, len(links))
+	for name := range links {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writer.Write([]byte(fmt.Sprintf(`
+    def %s
+      resource('%s')
     end
+`, name, name)))
+	}
+
+	writer.Write([]byte(`
+    private
 
     def parse_json(json_string)
       return nil if json_string.nil?
@@ -40,19 +73,25 @@ module SST
       json_string  # Return the original string if it's not valid JSON
     end
 
+    # resources.json is written next to the function by the ruby runtime
+    def resources_file
+      @resources_file ||= begin
+        dir = Dir.pwd
+        loop do
+          path = File.join(dir, 'resources.json')
+          break JSON.parse(File.read(path)) if File.exist?(path)
+          parent = File.dirname(dir)
+          break {} if parent == dir
+          dir = parent
+        end
+      end
+    end
   end
-`,
-	))
-
-	for name := range links {
-		writer.Write([]byte(fmt.Sprintf(`
-  def %s
-    @%s ||= parse_resource('%s')
-  end
-`, name, name, name)))
+end
+`))
+	for _, file := range files {
+		file.(io.WriteCloser).Close()
 	}
 
-	writer.Write([]byte("\nend"))
-
 	return nil
 }
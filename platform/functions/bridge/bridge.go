@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"os"
@@ -16,6 +17,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/sst/sst/v3/cmd/sst/mosaic/aws/appsync"
 	"github.com/sst/sst/v3/cmd/sst/mosaic/aws/bridge"
+	"github.com/sst/sst/v3/cmd/sst/mosaic/aws/relay"
 )
 
 var version = "0.0.1"
@@ -28,6 +30,8 @@ var SST_REGION = os.Getenv("SST_REGION")
 var SST_ASSET_BUCKET = os.Getenv("SST_ASSET_BUCKET")
 var SST_APPSYNC_HTTP = os.Getenv("SST_APPSYNC_HTTP")
 var SST_APPSYNC_REALTIME = os.Getenv("SST_APPSYNC_REALTIME")
+var SST_RELAY_URL = os.Getenv("SST_RELAY_URL")
+var SST_RELAY_TOKEN = os.Getenv("SST_RELAY_TOKEN")
 
 var ENV_BLACKLIST = map[string]bool{
 	"SST_DEBUG_ENDPOINT":              true,
@@ -58,6 +62,7 @@ var ENV_BLACKLIST = map[string]bool{
 	"_LAMBDA_SB_ID":                   true,
 	"_LAMBDA_SERVER_PORT":             true,
 	"_LAMBDA_SHARED_MEM_FD":           true,
+	"SST_RELAY_TOKEN":                 true,
 }
 
 func main() {
@@ -90,11 +95,27 @@ func run() error {
 		return err
 	}
 
-	conn, err := appsync.Dial(ctx, config, SST_APPSYNC_HTTP, SST_APPSYNC_REALTIME)
+	var conn bridge.Transport
+	if SST_RELAY_URL != "" {
+		conn, err = relay.Dial(ctx, SST_RELAY_URL, SST_RELAY_TOKEN)
+	} else {
+		conn, err = appsync.Dial(ctx, config, SST_APPSYNC_HTTP, SST_APPSYNC_REALTIME)
+	}
+	if err != nil {
+		return err
+	}
+	client, err := bridge.NewClient(ctx, conn, workerID, prefix+"/"+workerID)
+	if err != nil {
+		return err
+	}
+	routing, err := newRouter(config)
+	if err != nil {
+		return err
+	}
+	live, err := watchLiveness(ctx, conn, prefix)
 	if err != nil {
 		return err
 	}
-	client := bridge.NewClient(ctx, conn, workerID, prefix+"/"+workerID)
 
 	init := bridge.InitBody{
 		FunctionID:  SST_FUNCTION_ID,
@@ -111,11 +132,41 @@ func run() error {
 	json.NewEncoder(writer).Encode(init)
 	writer.Close()
 
-	notRunning, _ := json.Marshal(map[string]string{
-		"statusCode": "500",
-		"body":       "sst dev is not running (worker: " + workerID + ")",
+	notRunning, _ := json.Marshal(bridge.ErrorBody{
+		ErrorType:    "Bridge.NotRunning",
+		ErrorMessage: "sst dev is not running (worker: " + workerID + ")",
+	})
+	notRouted, _ := json.Marshal(bridge.ErrorBody{
+		ErrorType:    "Bridge.NotRouted",
+		ErrorMessage: "The event was not sent to sst dev and there is no fallback function",
 	})
 
+	// fallback handles the invocations that don't go to sst dev
+	fallback := func(requestID string, event []byte, otherwise []byte, failed bool) {
+		url := "http://" + LAMBDA_RUNTIME_API + "/2018-06-01/runtime/invocation/" + requestID
+		if routing.fallback == "" {
+			if failed {
+				http.Post(url+"/error", "application/json", bytes.NewReader(otherwise))
+				return
+			}
+			http.Post(url+"/response", "application/json", bytes.NewReader(otherwise))
+			return
+		}
+		payload, functionError, err := routing.invoke(ctx, event)
+		if err != nil {
+			payload, _ = json.Marshal(bridge.ErrorBody{
+				ErrorType:    "Bridge.FallbackFailed",
+				ErrorMessage: err.Error(),
+			})
+			functionError = true
+		}
+		if functionError {
+			http.Post(url+"/error", "application/json", bytes.NewReader(payload))
+			return
+		}
+		http.Post(url+"/response", "application/json", bytes.NewReader(payload))
+	}
+
 	for {
 		resp, err := http.Get("http://" + LAMBDA_RUNTIME_API + "/2018-06-01/runtime/invocation/next")
 		fmt.Println("status", resp.Status)
@@ -124,6 +175,21 @@ func run() error {
 			return err
 		}
 		requestID := resp.Header.Get("lambda-runtime-aws-request-id")
+		event, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(event))
+		if !routing.routed(event) {
+			fmt.Println("not routed", requestID)
+			fallback(requestID, event, notRouted, true)
+			continue
+		}
+		if !live.alive(ctx, routing.timeout) {
+			fmt.Println("sst dev is not running", requestID)
+			fallback(requestID, event, notRunning, true)
+			continue
+		}
 		writer := client.NewWriter(bridge.MessageNext, prefix+"/in")
 		resp.Write(writer)
 		writer.Close()
@@ -155,12 +221,13 @@ func run() error {
 					continue
 				}
 				if msg.Type == bridge.MessagePing {
+					live.mark()
 					timeout = time.Minute * 15
 					continue
 				}
 			case <-time.After(timeout):
 				fmt.Println("timeout", requestID)
-				http.Post("http://"+LAMBDA_RUNTIME_API+"/2018-06-01/runtime/invocation/"+requestID+"/response", "application/json", bytes.NewReader(notRunning))
+				fallback(requestID, event, notRunning, true)
 				break loop
 			}
 		}
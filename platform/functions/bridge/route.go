@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/sst/sst/v3/cmd/sst/mosaic/aws/bridge"
+)
+
+var SST_BRIDGE_TIMEOUT = os.Getenv("SST_BRIDGE_TIMEOUT")
+var SST_BRIDGE_SAMPLE = os.Getenv("SST_BRIDGE_SAMPLE")
+var SST_BRIDGE_FILTER = os.Getenv("SST_BRIDGE_FILTER")
+var SST_BRIDGE_FALLBACK = os.Getenv("SST_BRIDGE_FALLBACK")
+
+// router decides which invocations go to sst dev, from the dev options of the
+// function. The rest are sent to the fallback function if there is one.
+type router struct {
+	timeout  time.Duration
+	sample   float64
+	filter   map[string]interface{}
+	fallback string
+	lambda   *lambda.Client
+}
+
+func newRouter(cfg aws.Config) (*router, error) {
+	result := &router{
+		timeout:  time.Second * 10,
+		sample:   100,
+		fallback: SST_BRIDGE_FALLBACK,
+		lambda:   lambda.NewFromConfig(cfg),
+	}
+	if SST_BRIDGE_TIMEOUT != "" {
+		seconds, err := strconv.Atoi(SST_BRIDGE_TIMEOUT)
+		if err != nil {
+			return nil, err
+		}
+		result.timeout = time.Duration(seconds) * time.Second
+	}
+	if SST_BRIDGE_SAMPLE != "" {
+		sample, err := strconv.ParseFloat(SST_BRIDGE_SAMPLE, 64)
+		if err != nil {
+			return nil, err
+		}
+		result.sample = sample
+	}
+	if SST_BRIDGE_FILTER != "" {
+		filter, err := parseFilter(SST_BRIDGE_FILTER)
+		if err != nil {
+			return nil, err
+		}
+		result.filter = filter
+	}
+	return result, nil
+}
+
+var ErrUnsupportedFilter = fmt.Errorf("unsupported filter")
+
+// parseFilter reads a filter and checks that it only uses the operators
+// matchRule knows, so a filter can't silently never match.
+func parseFilter(input string) (map[string]interface{}, error) {
+	var filter map[string]interface{}
+	if err := json.Unmarshal([]byte(input), &filter); err != nil {
+		return nil, err
+	}
+	if err := validatePattern(filter); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+func validatePattern(pattern map[string]interface{}) error {
+	for key, rule := range pattern {
+		switch rule := rule.(type) {
+		case map[string]interface{}:
+			if err := validatePattern(rule); err != nil {
+				return err
+			}
+		case []interface{}:
+			for _, item := range rule {
+				if err := validateRule(item); err != nil {
+					return fmt.Errorf("%w: %s: %v", ErrUnsupportedFilter, key, err)
+				}
+			}
+		default:
+			return fmt.Errorf("%w: %s has to be an object or a list", ErrUnsupportedFilter, key)
+		}
+	}
+	return nil
+}
+
+func validateRule(rule interface{}) error {
+	operator, ok := rule.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	if len(operator) != 1 {
+		return fmt.Errorf("a rule has to have one operator")
+	}
+	for name, arg := range operator {
+		switch name {
+		case "exists":
+			if _, ok := arg.(bool); !ok {
+				return fmt.Errorf("exists has to be true or false")
+			}
+		case "prefix", "suffix", "equals-ignore-case":
+			if _, ok := arg.(string); !ok {
+				return fmt.Errorf("%s has to be a string", name)
+			}
+		case "anything-but":
+			values, ok := arg.([]interface{})
+			if !ok {
+				values = []interface{}{arg}
+			}
+			for _, value := range values {
+				switch value.(type) {
+				case map[string]interface{}, []interface{}:
+					return fmt.Errorf("anything-but has to be a value or a list of values")
+				}
+			}
+		case "numeric":
+			conditions, ok := arg.([]interface{})
+			if !ok || len(conditions) == 0 || len(conditions)%2 != 0 {
+				return fmt.Errorf("numeric has to be a list of comparisons")
+			}
+			for i := 0; i < len(conditions); i += 2 {
+				comparison, _ := conditions[i].(string)
+				if _, ok := comparisons[comparison]; !ok {
+					return fmt.Errorf("numeric comparison %v is not supported", conditions[i])
+				}
+				if _, ok := conditions[i+1].(float64); !ok {
+					return fmt.Errorf("numeric has to compare against a number")
+				}
+			}
+		default:
+			return fmt.Errorf("operator %s is not supported", name)
+		}
+	}
+	return nil
+}
+
+var comparisons = map[string]func(a float64, b float64) bool{
+	"=":  func(a float64, b float64) bool { return a == b },
+	"<":  func(a float64, b float64) bool { return a < b },
+	"<=": func(a float64, b float64) bool { return a <= b },
+	">":  func(a float64, b float64) bool { return a > b },
+	">=": func(a float64, b float64) bool { return a >= b },
+}
+
+// routed reports whether the event should go to sst dev.
+func (r *router) routed(event []byte) bool {
+	if r.sample < 100 && rand.Float64()*100 >= r.sample {
+		return false
+	}
+	if r.filter == nil {
+		return true
+	}
+	var parsed interface{}
+	if err := json.Unmarshal(event, &parsed); err != nil {
+		return false
+	}
+	return matchPattern(r.filter, parsed)
+}
+
+// invoke sends the event to the fallback function and returns its response
+// and whether it failed.
+func (r *router) invoke(ctx context.Context, event []byte) ([]byte, bool, error) {
+	out, err := r.lambda.Invoke(ctx, &lambda.InvokeInput{
+		FunctionName: aws.String(r.fallback),
+		Payload:      event,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return out.Payload, out.FunctionError != nil, nil
+}
+
+// matchPattern follows the syntax of Lambda event filtering. Every field in
+// the pattern has to match the event.
+func matchPattern(pattern map[string]interface{}, event interface{}) bool {
+	fields, _ := event.(map[string]interface{})
+	for key, rule := range pattern {
+		value, exists := fields[key]
+		switch rule := rule.(type) {
+		case map[string]interface{}:
+			if !exists || !matchPattern(rule, value) {
+				return false
+			}
+		case []interface{}:
+			if !matchAny(rule, value, exists) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func matchAny(rules []interface{}, value interface{}, exists bool) bool {
+	for _, rule := range rules {
+		if matchRule(rule, value, exists) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchRule(rule interface{}, value interface{}, exists bool) bool {
+	// a list in the event matches if any of its items does
+	if values, ok := value.([]interface{}); ok {
+		for _, item := range values {
+			if matchRule(rule, item, true) {
+				return true
+			}
+		}
+		return false
+	}
+	operator, ok := rule.(map[string]interface{})
+	if !ok {
+		return exists && rule == value
+	}
+	// parseFilter makes sure there's one operator
+	for name, arg := range operator {
+		str, isString := value.(string)
+		switch name {
+		case "exists":
+			expected, _ := arg.(bool)
+			return exists == expected
+		case "prefix":
+			prefix, _ := arg.(string)
+			return exists && isString && strings.HasPrefix(str, prefix)
+		case "suffix":
+			suffix, _ := arg.(string)
+			return exists && isString && strings.HasSuffix(str, suffix)
+		case "equals-ignore-case":
+			expected, _ := arg.(string)
+			return exists && isString && strings.EqualFold(str, expected)
+		case "anything-but":
+			if !exists {
+				return false
+			}
+			if list, ok := arg.([]interface{}); ok {
+				for _, item := range list {
+					if item == value {
+						return false
+					}
+				}
+				return true
+			}
+			return arg != value
+		case "numeric":
+			number, ok := value.(float64)
+			conditions, _ := arg.([]interface{})
+			if !exists || !ok || len(conditions)%2 != 0 {
+				return false
+			}
+			for i := 0; i < len(conditions); i += 2 {
+				comparison, _ := conditions[i].(string)
+				compare, ok := comparisons[comparison]
+				against, isNumber := conditions[i+1].(float64)
+				if !ok || !isNumber || !compare(number, against) {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// liveness tracks when sst dev was last heard from.
+type liveness struct {
+	mut     sync.Mutex
+	last    time.Time
+	changed chan struct{}
+}
+
+// watchLiveness fails if it can't subscribe to the heartbeats, since without
+// them every invocation would look like sst dev isn't running.
+func watchLiveness(ctx context.Context, conn bridge.Transport, prefix string) (*liveness, error) {
+	sub, err := conn.Subscribe(ctx, bridge.LiveChannel(prefix))
+	if err != nil {
+		return nil, err
+	}
+	result := &liveness{changed: make(chan struct{})}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sub:
+				result.mark()
+			}
+		}
+	}()
+	return result, nil
+}
+
+func (l *liveness) mark() {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+	l.last = time.Now()
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// alive reports whether sst dev has been heard from within the timeout. The
+// function can be frozen between invocations and miss heartbeats, so it waits
+// for the next one before giving up.
+func (l *liveness) alive(ctx context.Context, timeout time.Duration) bool {
+	l.mut.Lock()
+	last := l.last
+	changed := l.changed
+	l.mut.Unlock()
+	if time.Since(last) < timeout {
+		return true
+	}
+	select {
+	case <-changed:
+		return true
+	case <-time.After(min(timeout, bridge.HEARTBEAT_INTERVAL+time.Second)):
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
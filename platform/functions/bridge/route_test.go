@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/sst/sst/v3/cmd/sst/mosaic/aws/bridge"
+)
+
+func TestMatchPattern(t *testing.T) {
+	event := `{
+		"source": "orders",
+		"detail": {
+			"status": "Shipped",
+			"total": 42.5,
+			"count": 3,
+			"path": "/orders/123/items",
+			"tags": ["rush", "gift"],
+			"note": null
+		}
+	}`
+	tests := []struct {
+		name     string
+		filter   string
+		expected bool
+	}{
+		{"value", `{"source": ["orders"]}`, true},
+		{"other value", `{"source": ["users"]}`, false},
+		{"any value", `{"source": ["users", "orders"]}`, true},
+		{"nested", `{"detail": {"status": ["Shipped"]}}`, true},
+		{"missing object", `{"other": {"status": ["Shipped"]}}`, false},
+		{"every field", `{"source": ["orders"], "detail": {"status": ["Pending"]}}`, false},
+		{"null", `{"detail": {"note": [null]}}`, true},
+		{"number", `{"detail": {"count": [3]}}`, true},
+		{"list in event", `{"detail": {"tags": ["gift"]}}`, true},
+		{"list in event without match", `{"detail": {"tags": ["fragile"]}}`, false},
+		{"exists", `{"detail": {"status": [{"exists": true}]}}`, true},
+		{"does not exist", `{"detail": {"missing": [{"exists": false}]}}`, true},
+		{"exists when missing", `{"detail": {"missing": [{"exists": true}]}}`, false},
+		{"prefix", `{"detail": {"path": [{"prefix": "/orders"}]}}`, true},
+		{"other prefix", `{"detail": {"path": [{"prefix": "/users"}]}}`, false},
+		{"prefix of a number", `{"detail": {"count": [{"prefix": "3"}]}}`, false},
+		{"suffix", `{"detail": {"path": [{"suffix": "/items"}]}}`, true},
+		{"other suffix", `{"detail": {"path": [{"suffix": "/users"}]}}`, false},
+		{"suffix when missing", `{"detail": {"missing": [{"suffix": ""}]}}`, false},
+		{"equals ignore case", `{"detail": {"status": [{"equals-ignore-case": "shipped"}]}}`, true},
+		{"equals ignore case differs", `{"detail": {"status": [{"equals-ignore-case": "pending"}]}}`, false},
+		{"anything but", `{"source": [{"anything-but": "users"}]}`, true},
+		{"anything but the value", `{"source": [{"anything-but": "orders"}]}`, false},
+		{"anything but list", `{"source": [{"anything-but": ["users", "orders"]}]}`, false},
+		{"anything but when missing", `{"missing": [{"anything-but": "orders"}]}`, false},
+		{"numeric equals", `{"detail": {"count": [{"numeric": ["=", 3]}]}}`, true},
+		{"numeric range", `{"detail": {"total": [{"numeric": [">", 0, "<=", 50]}]}}`, true},
+		{"numeric outside range", `{"detail": {"total": [{"numeric": [">", 0, "<", 40]}]}}`, false},
+		{"numeric of a string", `{"detail": {"status": [{"numeric": [">", 0]}]}}`, false},
+		{"numeric when missing", `{"detail": {"missing": [{"numeric": [">=", 0]}]}}`, false},
+	}
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(event), &parsed); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filter, err := parseFilter(test.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := matchPattern(filter, parsed); got != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		err    error
+	}{
+		{"values", `{"source": ["orders", 1, true, null]}`, nil},
+		{"operators", `{"a": [{"prefix": "x"}, {"suffix": "y"}, {"equals-ignore-case": "z"}, {"exists": false}, {"anything-but": ["x"]}, {"numeric": ["<", 1]}]}`, nil},
+		{"unknown operator", `{"source": [{"wildcard": "order*"}]}`, ErrUnsupportedFilter},
+		{"two operators", `{"source": [{"prefix": "a", "suffix": "b"}]}`, ErrUnsupportedFilter},
+		{"not a list", `{"source": "orders"}`, ErrUnsupportedFilter},
+		{"nested unknown operator", `{"detail": {"status": [{"cidr": "10.0.0.0/24"}]}}`, ErrUnsupportedFilter},
+		{"prefix of a number", `{"source": [{"prefix": 1}]}`, ErrUnsupportedFilter},
+		{"anything but operator", `{"source": [{"anything-but": {"prefix": "a"}}]}`, ErrUnsupportedFilter},
+		{"numeric comparison", `{"count": [{"numeric": ["!=", 1]}]}`, ErrUnsupportedFilter},
+		{"numeric without number", `{"count": [{"numeric": [">", "1"]}]}`, ErrUnsupportedFilter},
+		{"numeric without pairs", `{"count": [{"numeric": [">", 1, "<"]}]}`, ErrUnsupportedFilter},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parseFilter(test.filter)
+			if test.err == nil && err != nil {
+				t.Fatal(err)
+			}
+			if test.err != nil && !errors.Is(err, test.err) {
+				t.Fatalf("expected %v, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+type unsubscribable struct {
+	bridge.Transport
+}
+
+var errSubscribe = errors.New("subscribe failed")
+
+func (unsubscribable) Subscribe(ctx context.Context, channel string) (chan string, error) {
+	return nil, errSubscribe
+}
+
+func TestWatchLivenessFailed(t *testing.T) {
+	_, err := watchLiveness(context.Background(), unsubscribable{}, "/sst/app/stage")
+	if !errors.Is(err, errSubscribe) {
+		t.Fatalf("expected %v, got %v", errSubscribe, err)
+	}
+}
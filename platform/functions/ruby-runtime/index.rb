@@ -0,0 +1,77 @@
+require "json"
+require "net/http"
+require "uri"
+
+# Usage: index.rb <file> <handler>
+#
+# The handler is either a method defined at the top level of the file, like
+# `handler`, or a class method, like `MyModule::MyClass.process`.
+file, handler = ARGV
+$stdout.sync = true
+$stderr.sync = true
+
+API = "http://#{ENV.fetch("AWS_LAMBDA_RUNTIME_API")}/2018-06-01/runtime"
+
+def post(path, body)
+  uri = URI("#{API}#{path}")
+  Net::HTTP.post(uri, JSON.generate(body), "Content-Type" => "application/json")
+end
+
+def error_body(ex)
+  {
+    errorType: ex.class.name,
+    errorMessage: ex.message,
+    trace: ex.backtrace || [],
+  }
+end
+
+class LambdaContext
+  attr_reader :aws_request_id, :invoked_function_arn, :function_name,
+    :function_version, :memory_limit_in_mb, :log_group_name, :log_stream_name
+
+  def initialize(headers)
+    @aws_request_id = headers["lambda-runtime-aws-request-id"]
+    @invoked_function_arn = headers["lambda-runtime-invoked-function-arn"]
+    @deadline_ms = headers["lambda-runtime-deadline-ms"].to_i
+    @function_name = ENV["AWS_LAMBDA_FUNCTION_NAME"]
+    @function_version = ENV["AWS_LAMBDA_FUNCTION_VERSION"]
+    @memory_limit_in_mb = ENV["AWS_LAMBDA_FUNCTION_MEMORY_SIZE"]
+    @log_group_name = ENV["AWS_LAMBDA_LOG_GROUP_NAME"]
+    @log_stream_name = ENV["AWS_LAMBDA_LOG_STREAM_NAME"]
+  end
+
+  def get_remaining_time_in_millis
+    [@deadline_ms - (Time.now.to_f * 1000).to_i, 0].max
+  end
+end
+
+begin
+  $LOAD_PATH.unshift(File.dirname(file))
+  require File.expand_path(file)
+  if handler.include?(".")
+    receiver, method = handler.split(".", 2)
+    target = Object.const_get(receiver)
+  else
+    target = self
+    method = handler
+  end
+  unless target.respond_to?(method, true)
+    raise NameError, "#{handler} is not defined in #{file}"
+  end
+rescue Exception => ex
+  post("/init/error", error_body(ex))
+  exit 1
+end
+
+loop do
+  response = Net::HTTP.get_response(URI("#{API}/invocation/next"))
+  context = LambdaContext.new(response.each_header.to_h)
+  begin
+    event = JSON.parse(response.body)
+    result = target.send(method, event: event, context: context)
+    post("/invocation/#{context.aws_request_id}/response", result)
+  rescue Exception => ex
+    warn "#{ex.class}: #{ex.message}"
+    post("/invocation/#{context.aws_request_id}/error", error_body(ex))
+  end
+end
@@ -36,7 +36,6 @@ import {
 } from "@pulumi/aws";
 import { Permission, permission } from "./permission.js";
 import { Vpc } from "./vpc.js";
-import { buildPython, buildPythonContainer } from "../../runtime/python.js";
 import { Image } from "@pulumi/docker-build";
 import { rpc } from "../rpc/rpc.js";
 import { parseRoleArn } from "./helpers/arn.js";
@@ -229,6 +228,9 @@ export interface FunctionArgs {
    *
    * Read more about [Live](/docs/live/) and [`sst dev`](/docs/reference/cli/#dev).
    *
+   * You can also configure what the stub does with an invocation, like when nobody is running
+   * `sst dev`, or to only send some of the events to your machine.
+   *
    * @default `true`
    * @example
    * ```js
@@ -236,8 +238,93 @@ export interface FunctionArgs {
    *   dev: false
    * }
    * ```
+   *
+   * Send a tenth of the orders to `sst dev` and the rest to a deployed function.
+   *
+   * ```js
+   * {
+   *   dev: {
+   *     sample: 10,
+   *     filter: { detail: { type: ["order"] } },
+   *     fallback: "arn:aws:lambda:us-east-1:123456789012:function:orders"
+   *   }
+   * }
+   * ```
    */
-  dev?: Input<false>;
+  dev?: Input<
+    | false
+    | {
+        /**
+         * How long the stub waits to hear from `sst dev` before it treats it as not running.
+         *
+         * `sst dev` lets the stub know it's running every few seconds. If it hasn't in this
+         * long, the invocation fails right away with an error, or goes to the `fallback`.
+         *
+         * @default `"10 seconds"`
+         * @example
+         * ```js
+         * {
+         *   dev: {
+         *     timeout: "30 seconds"
+         *   }
+         * }
+         * ```
+         */
+        timeout?: Input<DurationMinutes>;
+        /**
+         * The percentage of invocations to send to `sst dev`. The rest go to the `fallback`.
+         *
+         * @default `100`
+         * @example
+         * ```js
+         * {
+         *   dev: {
+         *     sample: 10
+         *   }
+         * }
+         * ```
+         */
+        sample?: Input<number>;
+        /**
+         * Only send the invocations with an event that matches this pattern to `sst dev`. The
+         * rest go to the `fallback`.
+         *
+         * It follows the [Lambda event filtering](https://docs.aws.amazon.com/lambda/latest/dg/invocation-eventfiltering.html)
+         * syntax. Each field is a list of the values to match, which can also be
+         * `{ prefix: "..." }`, `{ suffix: "..." }`, `{ "equals-ignore-case": "..." }`,
+         * `{ "anything-but": [...] }`, `{ numeric: [">", 0, "<=", 100] }`, or
+         * `{ exists: false }`. Other operators fail the invocations.
+         *
+         * @example
+         * ```js
+         * {
+         *   dev: {
+         *     filter: {
+         *       requestContext: { http: { path: [{ prefix: "/orders" }] } }
+         *     }
+         *   }
+         * }
+         * ```
+         */
+        filter?: Input<Record<string, any>>;
+        /**
+         * The ARN of a function to invoke with the events that aren't sent to `sst dev`, or
+         * when it's not running. Its response is returned as the response of this function.
+         *
+         * By default, these invocations fail with an error.
+         *
+         * @example
+         * ```js
+         * {
+         *   dev: {
+         *     fallback: "arn:aws:lambda:us-east-1:123456789012:function:orders"
+         *   }
+         * }
+         * ```
+         */
+        fallback?: Input<string>;
+      }
+  >;
   /**
    * The name for the function.
    *
@@ -281,8 +368,14 @@ export interface FunctionArgs {
    * Currently supports Node.js and Golang functions.
    * :::
    *
-   * Currently supports **Node.js** and **Golang** functions. Python is community supported
-   * and is currently a work in progress. Other runtimes are on the roadmap.
+   * Currently supports **Node.js**, **Golang**, and **Rust** functions. Python is community
+   * supported and is currently a work in progress. Other runtimes are on the roadmap.
+   *
+   * For Ruby, the `handler` is the file and method, like `src/function.handler`. The gems in
+   * the closest `Gemfile` are vendored with Bundler when deploying.
+   *
+   * For Rust, the `handler` is the path to your crate, or to the source file of one of its
+   * binaries. The crate is found through its `Cargo.toml` and built into a `bootstrap` binary.
    *
    * @default `"nodejs20.x"`
    *
@@ -298,11 +391,14 @@ export interface FunctionArgs {
     | "nodejs20.x"
     | "nodejs22.x"
     | "go"
+    | "rust"
     | "provided.al2023"
     | "python3.9"
     | "python3.10"
     | "python3.11"
     | "python3.12"
+    | "ruby3.2"
+    | "ruby3.3"
   >;
   /**
    * Path to the source code directory for the function. By default, the handler is
@@ -380,6 +476,8 @@ export interface FunctionArgs {
    *
    * While the maximum timeout is 15 minutes, if a function is connected to other services, it'll time out based on those limits. API Gateway for example has a timeout of 30 seconds. So even if the function has a timeout of 15 minutes, the API request will time out after 30 seconds.
    *
+   * In `sst dev`, the timeout is also enforced on your machine. The invocation fails with a timeout error and the function is restarted. It's not enforced while a debugger is attached.
+   *
    * @default `"20 seconds"`
    * @example
    * ```js
@@ -399,6 +497,9 @@ export interface FunctionArgs {
    * And might end up being more [cost effective](https://docs.aws.amazon.com/lambda/latest/operatorguide/computing-power.html).
    * :::
    *
+   * In `sst dev` on Linux, the memory is also limited on your machine. If the function goes
+   * over it, the invocation fails and the function is restarted.
+   *
    * @default `"1024 MB"`
    * @example
    * ```js
@@ -899,6 +1000,11 @@ export interface FunctionArgs {
    * │   └── utils.py
    * └── sst.config.ts
    * ```
+   *
+   * When deploying, the dependencies locked in `uv.lock` are installed with
+   * [uv](https://docs.astral.sh/uv/) for the Lambda platform and Python version of the
+   * function, and placed next to the handler. The `uv.lock` has to be next to the
+   * `pyproject.toml`, run `uv lock` to create it.
    */
   python?: Input<{
     /**
@@ -1216,6 +1322,16 @@ export interface FunctionArgs {
  *   });
  *   ```
  *   </TabItem>
+ *   <TabItem label="Rust">
+ *   Pass in the directory to your Rust crate.
+ *
+ *   ```ts title="sst.config.ts"
+ *   new sst.aws.Function("MyFunction", {
+ *     runtime: "rust",
+ *     handler: "./crates/api"
+ *   });
+ *   ```
+ *   </TabItem>
  * </Tabs>
  *
  * #### Set additional config
@@ -1334,6 +1450,7 @@ export class Function extends Component implements Link.Linkable {
 
     const parent = this;
     const dev = normalizeDev();
+    const devRouting = normalizeDevRouting();
     const isContainer = all([args.python, dev]).apply(
       ([python, dev]) => !dev && (python?.container ?? false),
     );
@@ -1384,6 +1501,8 @@ export class Function extends Component implements Link.Linkable {
         Object.fromEntries(input.map((item) => [item.name, item.properties])),
       ),
       copyFiles,
+      timeout: timeout.apply((timeout) => toSeconds(timeout)),
+      memory: memory.apply((memory) => toMBs(memory)),
       properties: output({ nodejs: args.nodejs, python: args.python }).apply(
         (val) => ({
           ...(val.nodejs || val.python),
@@ -1441,6 +1560,15 @@ export class Function extends Component implements Link.Linkable {
       );
     }
 
+    function normalizeDevRouting() {
+      return output(args.dev).apply((d) => ({
+        timeout: toSeconds(d ? d.timeout ?? "10 seconds" : "10 seconds"),
+        sample: d ? d.sample : undefined,
+        filter: d ? d.filter : undefined,
+        fallback: d ? d.fallback : undefined,
+      }));
+    }
+
     function normalizeInjections() {
       return output(args.injections).apply((injections) => injections ?? []);
     }
@@ -1461,10 +1589,11 @@ export class Function extends Component implements Link.Linkable {
       return all([
         args.environment,
         dev,
+        devRouting,
         bootstrapData,
         Function.encryptionKey().base64,
         args.link,
-      ]).apply(async ([environment, dev, bootstrap, key, link]) => {
+      ]).apply(async ([environment, dev, devRouting, bootstrap, key, link]) => {
         const result = environment ?? {};
         result.SST_RESOURCE_App = JSON.stringify({
           name: $app.name,
@@ -1491,6 +1620,17 @@ export class Function extends Component implements Link.Linkable {
           if (process.env.SST_FUNCTION_TIMEOUT) {
             result.SST_FUNCTION_TIMEOUT = process.env.SST_FUNCTION_TIMEOUT;
           }
+          result.SST_BRIDGE_TIMEOUT = devRouting.timeout.toString();
+          if (devRouting.sample !== undefined)
+            result.SST_BRIDGE_SAMPLE = devRouting.sample.toString();
+          if (devRouting.filter)
+            result.SST_BRIDGE_FILTER = JSON.stringify(devRouting.filter);
+          if (devRouting.fallback)
+            result.SST_BRIDGE_FALLBACK = devRouting.fallback;
+          if (process.env.SST_RELAY_URL) {
+            result.SST_RELAY_URL = process.env.SST_RELAY_URL;
+            result.SST_RELAY_TOKEN = process.env.SST_RELAY_TOKEN ?? "";
+          }
         }
         return result;
       });
@@ -1635,42 +1775,6 @@ export class Function extends Component implements Link.Linkable {
           };
         }
 
-        if (runtime.startsWith("python")) {
-          const buildResult = all([args, isContainer, linkData]).apply(
-            async ([args, isContainer, linkData]) => {
-              if (isContainer) {
-                const result = await buildPythonContainer(name, {
-                  ...args,
-                  links: linkData,
-                });
-                if (result.type === "error") {
-                  throw new VisibleError(
-                    `Failed to build function "${args.handler}": ` +
-                    result.errors.join("\n").trim(),
-                  );
-                }
-                return result;
-              }
-              const result = await buildPython(name, {
-                ...args,
-                links: linkData,
-              });
-              if (result.type === "error") {
-                throw new VisibleError(
-                  `Failed to build function "${args.handler}": ` +
-                  result.errors.join("\n").trim(),
-                );
-              }
-              return result;
-            },
-          );
-
-          return {
-            handler: buildResult.handler,
-            bundle: buildResult.out,
-          };
-        }
-
         const buildResult = buildInput.apply(async (input) => {
           const result = await rpc.call<{
             handler: string;
@@ -1794,8 +1898,13 @@ export class Function extends Component implements Link.Linkable {
         );
       }
 
-      const policy = all([args.permissions || [], linkPermissions, dev]).apply(
-        ([argsPermissions, linkPermissions, dev]) =>
+      const policy = all([
+        args.permissions || [],
+        linkPermissions,
+        dev,
+        devRouting,
+      ]).apply(
+        ([argsPermissions, linkPermissions, dev, devRouting]) =>
           iam.getPolicyDocumentOutput({
             statements: [
               ...argsPermissions,
@@ -1820,6 +1929,15 @@ export class Function extends Component implements Link.Linkable {
                       interpolate`arn:${partition}:s3:::${bootstrapData.asset}/*`,
                     ],
                   },
+                  ...(devRouting.fallback
+                    ? [
+                      {
+                        effect: "allow",
+                        actions: ["lambda:InvokeFunction"],
+                        resources: [devRouting.fallback],
+                      },
+                    ]
+                    : []),
                 ]
                 : []),
             ].map((item) => ({
@@ -1909,7 +2027,8 @@ export class Function extends Component implements Link.Linkable {
             // Cannot use latest tag it breaks lambda because for whatever reason
             // .ref is actually digest + tags and is not properly qualified???
             context: {
-              location: path.join($cli.paths.work, "artifacts", `${name}-src`),
+              // the bundle is the build output with the Dockerfile
+              location: bundle,
             },
             // Use the pushed image as a cache source.
             cacheFrom: [
@@ -1938,6 +2057,9 @@ export class Function extends Component implements Link.Linkable {
                 v === "arm64" ? "linux/arm64" : "linux/amd64",
               ),
             ],
+            buildArgs: {
+              PYTHON_VERSION: runtime.apply((v) => v.replace("python", "")),
+            },
             push: true,
             registries: [
               authToken.apply((authToken) => ({
@@ -2179,7 +2301,7 @@ export class Function extends Component implements Link.Linkable {
                   s3Key: zipAsset!.key,
                   handler: unsecret(handler),
                   runtime: runtime.apply((v) =>
-                    v === "go" ? "provided.al2023" : v,
+                    v === "go" || v === "rust" ? "provided.al2023" : v,
                   ),
                 }),
             },
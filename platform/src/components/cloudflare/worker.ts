@@ -163,10 +163,34 @@ export interface WorkerArgs {
     worker?: Transform<cf.WorkerScriptArgs>;
   };
   /**
-   * @internal
-   * Placehodler for future feature.
+   * Configure how your Worker works in `sst dev`.
+   *
+   * By default, `sst dev` updates the deployed Worker whenever your code changes
+   * and streams its logs to the **Functions** tab.
+   *
+   * Set `local` to instead run it on your machine with
+   * [workerd](https://github.com/cloudflare/workerd), through Wrangler's local mode.
+   * Your KV, R2, and D1 bindings are simulated locally and persisted in
+   * `.sst/cloudflare`. The URL it runs on is shown in the **Functions** tab.
+   *
+   * @example
+   * ```js
+   * {
+   *   dev: {
+   *     local: true
+   *   }
+   * }
+   * ```
+   *
+   * Set it to `false` to deploy the Worker as is in `sst dev`.
    */
-  dev?: boolean;
+  dev?: false | {
+    /**
+     * Run the Worker locally with workerd.
+     * @default `false`
+     */
+    local?: Input<boolean>;
+  };
 }
 
 /**
@@ -267,14 +291,24 @@ export class Worker extends Component implements Link.Linkable {
     this.workerUrl = workerUrl;
     this.workerDomain = workerDomain;
 
-    all([dev, buildInput, script.name]).apply(
-      async ([dev, buildInput, scriptName]) => {
+    all([
+      dev,
+      buildInput,
+      script.name,
+      args.dev,
+      bindings,
+      args.environment,
+    ]).apply(
+      async ([dev, buildInput, scriptName, devArgs, bindings, environment]) => {
         if (!dev) return undefined;
         await rpc.call("Runtime.AddTarget", {
           ...buildInput,
           properties: {
             ...buildInput.properties,
             scriptName,
+            local: devArgs ? devArgs.local ?? false : false,
+            bindings,
+            environment,
           },
         });
       },
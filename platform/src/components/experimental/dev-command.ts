@@ -2,6 +2,7 @@ import { ComponentResourceOptions, output } from "@pulumi/pulumi";
 import { Component } from "../component";
 import { Link } from "../link.js";
 import { Input } from "../input";
+import { Duration, toSeconds } from "../duration";
 
 export interface DevCommandArgs {
   dev?: {
@@ -27,6 +28,68 @@ export interface DevCommandArgs {
      * @default The name of the component.
      */
     title?: Input<string>;
+    /**
+     * Configure if `sst dev` restarts the command when it exits.
+     *
+     * - `"never"`: Leave it stopped.
+     * - `"on-failure"`: Restart it only when it exits with an error.
+     * - `"always"`: Restart it whenever it exits.
+     *
+     * Restarts back off from 1 second up to 30 seconds if it keeps exiting. Stopping it
+     * manually doesn't restart it.
+     *
+     * @default `"never"`
+     */
+    restart?: Input<"never" | "on-failure" | "always">;
+    /**
+     * The names of other dev commands that need to be ready before this one is started.
+     *
+     * @example
+     * ```js
+     * {
+     *   dev: {
+     *     dependsOn: ["Database"]
+     *   }
+     * }
+     * ```
+     */
+    dependsOn?: Input<Input<string>[]>;
+    /**
+     * Configure how `sst dev` tells that the command is ready. Commands that depend on
+     * this one wait until it is. If more than one check is set, they all need to pass.
+     *
+     * @default Ready as soon as it starts.
+     * @example
+     * ```js
+     * {
+     *   dev: {
+     *     ready: {
+     *       port: 5432
+     *     }
+     *   }
+     * }
+     * ```
+     */
+    ready?: Input<{
+      /**
+       * Ready once something is listening on this port on localhost.
+       */
+      port?: Input<number>;
+      /**
+       * Ready once this URL responds with a 2xx or 3xx status.
+       */
+      url?: Input<string>;
+      /**
+       * Ready once the command prints a line that matches this regular expression.
+       */
+      log?: Input<string>;
+      /**
+       * How long to wait for it to be ready. After that it's marked as unhealthy and the
+       * commands that depend on it are started anyway.
+       * @default `"60 seconds"`
+       */
+      timeout?: Input<Duration>;
+    }>;
   };
   /**
    * [Link resources](/docs/linking/) to your command. This will allow you to access it in your
@@ -114,6 +177,18 @@ export class DevCommand extends Component {
         directory: args.dev?.directory,
         autostart: args.dev?.autostart !== false,
         command: args.dev?.command,
+        restart: args.dev?.restart ?? "never",
+        dependsOn: args.dev?.dependsOn ?? [],
+        ready: output(args.dev?.ready).apply((ready) =>
+          ready
+            ? {
+                port: ready.port,
+                url: ready.url,
+                log: ready.log,
+                timeout: ready.timeout ? toSeconds(ready.timeout) : undefined,
+              }
+            : undefined,
+        ),
         aws: {
           role: args.aws?.role,
         },
@@ -14,6 +14,9 @@ export module rpc {
         hostname: url.hostname,
         port: url.port,
         path: url.pathname,
+        auth: url.username
+          ? `${decodeURIComponent(url.username)}:${decodeURIComponent(url.password)}`
+          : undefined,
         method: "POST",
         headers: {
           "Content-Type": "application/json",